@@ -0,0 +1,2 @@
+ALTER TABLE subscriptions ADD COLUMN IF NOT EXISTS billing_period TEXT NOT NULL DEFAULT 'monthly'
+    CHECK (billing_period IN ('weekly', 'monthly', 'quarterly', 'yearly'));
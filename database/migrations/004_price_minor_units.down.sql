@@ -0,0 +1 @@
+ALTER TABLE subscriptions ALTER COLUMN price TYPE INTEGER USING (price / 100)::INTEGER;
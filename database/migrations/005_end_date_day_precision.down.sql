@@ -0,0 +1,3 @@
+UPDATE subscriptions
+SET end_date = date_trunc('month', end_date)::DATE
+WHERE end_date IS NOT NULL;
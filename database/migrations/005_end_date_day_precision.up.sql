@@ -0,0 +1,5 @@
+-- До перехода на даты с точностью до дня end_date хранилась первым числом месяца
+-- и означала, что месяц окончания активен целиком. Переводим в последний день месяца.
+UPDATE subscriptions
+SET end_date = (date_trunc('month', end_date) + INTERVAL '1 month - 1 day')::DATE
+WHERE end_date IS NOT NULL;
@@ -0,0 +1,15 @@
+ALTER TABLE subscriptions ADD COLUMN IF NOT EXISTS status TEXT NOT NULL DEFAULT 'active'
+    CHECK (status IN ('active', 'paused', 'cancelled'));
+
+-- Интервалы приостановки: [paused_on, resumed_on), resumed_on IS NULL - пауза ещё длится
+CREATE TABLE IF NOT EXISTS subscription_pauses (
+    id SERIAL PRIMARY KEY,
+    subscription_id INTEGER NOT NULL REFERENCES subscriptions (id) ON DELETE CASCADE,
+    paused_on DATE NOT NULL,
+    resumed_on DATE,
+    CHECK (resumed_on IS NULL OR resumed_on > paused_on)
+);
+
+-- Не больше одной открытой паузы на подписку
+CREATE UNIQUE INDEX IF NOT EXISTS subscription_pauses_open_idx
+    ON subscription_pauses (subscription_id) WHERE resumed_on IS NULL;
@@ -0,0 +1,14 @@
+-- История цен: цена действует с первого дня effective_month до следующего изменения
+CREATE TABLE IF NOT EXISTS subscription_prices (
+    id SERIAL PRIMARY KEY,
+    subscription_id INTEGER NOT NULL REFERENCES subscriptions (id) ON DELETE CASCADE,
+    effective_month DATE NOT NULL CHECK (effective_month = date_trunc('month', effective_month)),
+    price BIGINT NOT NULL CHECK (price >= 0),
+    UNIQUE (subscription_id, effective_month)
+);
+
+-- Текущая цена существующих подписок считается действующей с месяца начала
+INSERT INTO subscription_prices (subscription_id, effective_month, price)
+SELECT id, date_trunc('month', start_date)::DATE, price
+FROM subscriptions
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,15 @@
+-- Журнал изменений подписок; только добавление записей.
+-- subscription_id без внешнего ключа: записи сохраняются после удаления подписки.
+CREATE TABLE IF NOT EXISTS subscription_audit (
+    id BIGSERIAL PRIMARY KEY,
+    subscription_id INTEGER NOT NULL,
+    user_id UUID NOT NULL,
+    actor TEXT NOT NULL,
+    operation TEXT NOT NULL CHECK (operation IN ('create', 'update', 'delete')),
+    changed_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    before JSONB,
+    after JSONB
+);
+
+CREATE INDEX IF NOT EXISTS subscription_audit_subscription_idx ON subscription_audit (subscription_id, id);
+CREATE INDEX IF NOT EXISTS subscription_audit_user_idx ON subscription_audit (user_id, changed_at);
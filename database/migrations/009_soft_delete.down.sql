@@ -0,0 +1,12 @@
+DELETE FROM subscription_audit WHERE operation IN ('restore', 'purge');
+ALTER TABLE subscription_audit DROP CONSTRAINT IF EXISTS subscription_audit_operation_check;
+ALTER TABLE subscription_audit ADD CONSTRAINT subscription_audit_operation_check
+    CHECK (operation IN ('create', 'update', 'delete'));
+
+DELETE FROM subscriptions WHERE deleted_at IS NOT NULL;
+DROP INDEX IF EXISTS subscriptions_active_unique_idx;
+ALTER TABLE subscriptions ADD CONSTRAINT subscriptions_user_id_service_name_start_date_key
+    UNIQUE (user_id, service_name, start_date);
+
+DROP INDEX IF EXISTS subscriptions_deleted_at_idx;
+ALTER TABLE subscriptions DROP COLUMN IF EXISTS deleted_at;
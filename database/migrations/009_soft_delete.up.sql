@@ -0,0 +1,13 @@
+-- Мягкое удаление: удалённые записи помечаются deleted_at и окончательно удаляются фоновой очисткой
+ALTER TABLE subscriptions ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS subscriptions_deleted_at_idx ON subscriptions (deleted_at) WHERE deleted_at IS NOT NULL;
+
+-- Уникальность только среди неудалённых: удалённую подписку можно создать заново
+ALTER TABLE subscriptions DROP CONSTRAINT IF EXISTS subscriptions_user_id_service_name_start_date_key;
+CREATE UNIQUE INDEX IF NOT EXISTS subscriptions_active_unique_idx
+    ON subscriptions (user_id, service_name, start_date) WHERE deleted_at IS NULL;
+
+ALTER TABLE subscription_audit DROP CONSTRAINT IF EXISTS subscription_audit_operation_check;
+ALTER TABLE subscription_audit ADD CONSTRAINT subscription_audit_operation_check
+    CHECK (operation IN ('create', 'update', 'delete', 'restore', 'purge'));
@@ -0,0 +1,12 @@
+-- API-ключи: хранится только SHA-256 ключа
+CREATE TABLE IF NOT EXISTS api_keys (
+    id SERIAL PRIMARY KEY,
+    name TEXT NOT NULL,
+    prefix TEXT NOT NULL,
+    key_hash TEXT NOT NULL UNIQUE,
+    role TEXT NOT NULL CHECK (role IN ('admin', 'user')),
+    user_id UUID,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    revoked_at TIMESTAMPTZ,
+    CHECK ((role = 'admin' AND user_id IS NULL) OR (role = 'user' AND user_id IS NOT NULL))
+);
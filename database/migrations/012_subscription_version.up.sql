@@ -0,0 +1,2 @@
+-- Версия записи для оптимистичной блокировки (ETag / If-Match); увеличивается при каждом изменении
+ALTER TABLE subscriptions ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1;
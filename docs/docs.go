@@ -15,39 +15,52 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/subscriptions": {
+        "/admin/api-keys": {
             "get": {
-                "description": "Возвращает список всех подписок",
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Возвращает все ключи, включая отозванные, без секретов",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Subscriptions"
+                    "API keys"
                 ],
-                "summary": "Получить все подписки",
+                "summary": "Список API-ключей",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
                             "type": "array",
                             "items": {
-                                "$ref": "#/definitions/models.Subscription"
+                                "$ref": "#/definitions/models.APIKey"
                             }
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "403": {
+                        "description": "Forbidden",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/utils.Problem"
                         }
                     }
                 }
             },
             "post": {
-                "description": "Добавляет новую подписку в базу данных",
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Создаёт ключ администратора (role=admin) или пользователя (role=user с user_id).\nСекрет ключа возвращается только в этом ответе, в хранилище сохраняется его хеш.",
                 "consumes": [
                     "application/json"
                 ],
@@ -55,178 +68,255 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Subscriptions"
+                    "API keys"
                 ],
-                "summary": "Создать новую подписку",
+                "summary": "Создать API-ключ",
                 "parameters": [
                     {
-                        "description": "Данные подписки",
-                        "name": "subscription",
+                        "description": "Параметры ключа",
+                        "name": "key",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/models.SubscriptionInput"
+                            "$ref": "#/definitions/models.APIKeyInput"
                         }
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK",
+                    "201": {
+                        "description": "Created",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "integer"
-                            }
+                            "$ref": "#/definitions/models.CreatedAPIKey"
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "400": {
+                        "description": "Bad Request",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/utils.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Problem"
                         }
                     }
                 }
             }
         },
-        "/subscriptions/total-cost": {
+        "/admin/api-keys/{id}": {
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Отзывает ключ: запросы с ним больше не принимаются",
+                "tags": [
+                    "API keys"
+                ],
+                "summary": "Отозвать API-ключ",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID ключа",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Ключ отозван"
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/audit": {
             "get": {
-                "description": "Возвращает суммарную стоимость подписок пользователя за указанный период с фильтрацией по названию сервиса (счет по месяцам)",
-                "consumes": [
-                    "application/json"
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
                 ],
+                "description": "Возвращает записи журнала изменений всех подписок от новых к старым.\nДля следующей страницы передайте before_id = id последней полученной записи.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Subscriptions"
+                    "Audit"
                 ],
-                "summary": "Получение суммарной стоимости подписок",
+                "summary": "Журнал изменений подписок",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "ID пользователя (UUID)",
+                        "description": "Владелец подписки (UUID)",
                         "name": "user_id",
                         "in": "query"
                     },
                     {
                         "type": "string",
-                        "description": "Название сервиса (опционально)",
-                        "name": "service_name",
+                        "description": "Начало интервала (RFC 3339 или YYYY-MM-DD)",
+                        "name": "from",
                         "in": "query"
                     },
                     {
                         "type": "string",
-                        "description": "Дата начала периода (в формате MM-YYYY) (опционально)",
-                        "name": "start_date",
-                        "in": "query",
-                        "required": true
+                        "description": "Конец интервала включительно (RFC 3339 или YYYY-MM-DD)",
+                        "name": "to",
+                        "in": "query"
                     },
                     {
-                        "type": "string",
-                        "description": "Дата окончания периода (в формате MM-YYYY) (опционально)",
-                        "name": "end_date",
-                        "in": "query",
-                        "required": true
+                        "type": "integer",
+                        "description": "Только записи с id меньше указанного",
+                        "name": "before_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Количество записей (по умолчанию 50, максимум 500)",
+                        "name": "limit",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Суммарная стоимость, например {\\\"total_cost\\\": 900}",
+                        "description": "OK",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "integer"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.AuditEntry"
                             }
                         }
                     },
                     "400": {
-                        "description": "Ошибка валидации",
+                        "description": "Bad Request",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/utils.Problem"
                         }
                     },
                     "500": {
-                        "description": "Ошибка сервера",
+                        "description": "Internal Server Error",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/utils.Problem"
                         }
                     }
                 }
             }
         },
-        "/subscriptions/{id}": {
+        "/admin/exchange-rates": {
             "get": {
-                "description": "Возвращает одну подписку по её ID",
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Возвращает курсы валюты к рублю, действующие не позже указанной даты",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Subscriptions"
+                    "Exchange rates"
                 ],
-                "summary": "Получить подписку по ID",
+                "summary": "Получить курсы валюты",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "ID подписки",
-                        "name": "id",
-                        "in": "path",
+                        "type": "string",
+                        "description": "Код валюты ISO 4217",
+                        "name": "currency",
+                        "in": "query",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Дата YYYY-MM-DD (по умолчанию сегодня)",
+                        "name": "until",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.Subscription"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.ExchangeRate"
+                            }
                         }
                     },
-                    "404": {
-                        "description": "Not Found",
+                    "400": {
+                        "description": "Bad Request",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/utils.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Problem"
                         }
                     }
                 }
             },
-            "put": {
-                "description": "Обновляет поля подписки по ID (только переданные и непустые поля)",
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Загружает таблицу курсов валют к рублю из CSV (колонки date,currency,rate[,nominal]) или XML в формате ЦБ РФ.\nФормат определяется параметром format или заголовком Content-Type. Курс на ту же дату перезаписывается.",
                 "consumes": [
-                    "application/json"
+                    "text/plain"
                 ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Subscriptions"
+                    "Exchange rates"
                 ],
-                "summary": "Обновить подписку",
+                "summary": "Загрузить курсы валют",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "ID подписки",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "enum": [
+                            "csv",
+                            "cbr"
+                        ],
+                        "type": "string",
+                        "description": "Формат файла",
+                        "name": "format",
+                        "in": "query"
                     },
                     {
-                        "description": "Обновлённые поля подписки",
-                        "name": "subscription",
+                        "description": "Содержимое файла курсов",
+                        "name": "file",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/models.UpdateSubscription"
+                            "type": "string"
                         }
                     }
                 ],
@@ -234,103 +324,2540 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.Subscription"
+                            "$ref": "#/definitions/models.RatesImportResult"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Problem"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/utils.Problem"
                         }
                     }
                 }
-            },
-            "delete": {
-                "description": "Удаляет подписку по ID",
+            }
+        },
+        "/subscriptions": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Возвращает страницу подписок с фильтрацией, сортировкой и курсорной пагинацией",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "Subscriptions"
                 ],
-                "summary": "Удалить подписку",
+                "summary": "Получить список подписок",
                 "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID пользователя (UUID)",
+                        "name": "user_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Название сервиса (точное совпадение)",
+                        "name": "service_name",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Подстрока названия сервиса (без учёта регистра)",
+                        "name": "service_name_contains",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Подписка активна с даты (YYYY-MM-DD или MM-YYYY)",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Подписка активна по дату включительно (YYYY-MM-DD или MM-YYYY - до конца месяца)",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Минимальная цена, действующая сегодня, например 99.90",
+                        "name": "price_min",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Максимальная цена, действующая сегодня, например 999.00",
+                        "name": "price_max",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "id",
+                            "price",
+                            "start_date",
+                            "service_name"
+                        ],
+                        "type": "string",
+                        "description": "Поле сортировки; price - цена, действующая сегодня",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "asc",
+                            "desc"
+                        ],
+                        "type": "string",
+                        "description": "Направление сортировки",
+                        "name": "order",
+                        "in": "query"
+                    },
                     {
                         "type": "integer",
-                        "description": "ID подписки",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "description": "Размер страницы (по умолчанию 50, максимум 500)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Включать мягко удалённые подписки",
+                        "name": "include_deleted",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Курсор следующей страницы (next_cursor из предыдущего ответа)",
+                        "name": "cursor",
+                        "in": "query"
                     }
                 ],
                 "responses": {
-                    "204": {
-                        "description": "Подписка удалена"
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.SubscriptionPage"
+                        }
                     },
-                    "404": {
-                        "description": "Not Found",
+                    "400": {
+                        "description": "Bad Request",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/utils.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Нет доступа к данным другого пользователя",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Problem"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/utils.Problem"
                         }
                     }
                 }
-            }
-        }
-    },
-    "definitions": {
-        "models.Subscription": {
-            "type": "object",
-            "properties": {
-                "end_date": {
-                    "description": "Опционально: дата окончания",
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Добавляет новую подписку в базу данных. Для ключа пользователя user_id можно не указывать.\nС заголовком Idempotency-Key повтор запроса возвращает сохранённый ответ первого (заголовок Idempotent-Replayed: true).",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Subscriptions"
+                ],
+                "summary": "Создать новую подписку",
+                "parameters": [
+                    {
+                        "description": "Данные подписки",
+                        "name": "subscription",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.SubscriptionInput"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Ключ идемпотентности (до 255 символов)",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "integer"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Нет доступа к данным другого пользователя",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "Подписка уже существует или запрос с тем же Idempotency-Key ещё выполняется",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "Idempotency-Key уже использован с другим телом запроса",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/batch": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Выполняет до 100 операций create, update (JSON Merge Patch) и delete одним запросом.\nmode=atomic (по умолчанию) - все операции в одной транзакции: некорректная операция отклоняет весь запрос с 400,\nошибка при выполнении отменяет пакет, остальные операции получают статус 424.\nmode=best_effort - операции выполняются независимо. Итог каждой операции - в results;\nответ 200, если выполнены все операции, иначе 207.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Subscriptions"
+                ],
+                "summary": "Пакетное изменение подписок",
+                "parameters": [
+                    {
+                        "description": "Операции",
+                        "name": "batch",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.BatchInput"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Ключ идемпотентности (до 255 символов)",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.BatchResponse"
+                        }
+                    },
+                    "207": {
+                        "description": "Часть операций не выполнена",
+                        "schema": {
+                            "$ref": "#/definitions/models.BatchResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Нет доступа к данным другого пользователя",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/cost-breakdown": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Возвращает стоимость подписок по каждому месяцу периода, опционально с группировкой по сервису или пользователю",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Subscriptions"
+                ],
+                "summary": "Помесячная разбивка стоимости подписок",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID пользователя (UUID)",
+                        "name": "user_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Название сервиса (опционально)",
+                        "name": "service_name",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Дата начала периода (в формате YYYY-MM-DD или MM-YYYY)",
+                        "name": "start_date",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Дата окончания периода включительно (в формате YYYY-MM-DD или MM-YYYY); период не длиннее 120 месяцев",
+                        "name": "end_date",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "service_name",
+                            "user_id"
+                        ],
+                        "type": "string",
+                        "description": "Группировка внутри месяца",
+                        "name": "group_by",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "charges",
+                            "monthly_equivalent"
+                        ],
+                        "type": "string",
+                        "description": "Режим подсчёта (по умолчанию charges)",
+                        "name": "mode",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "none",
+                            "daily"
+                        ],
+                        "type": "string",
+                        "description": "Пропорциональный учёт неполных периодов по дням (по умолчанию none)",
+                        "name": "proration",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Валюта результата (по умолчанию RUB)",
+                        "name": "currency",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Учитывать мягко удалённые подписки",
+                        "name": "include_deleted",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.CostBreakdown"
+                        }
+                    },
+                    "400": {
+                        "description": "Ошибка валидации",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Нет доступа к данным другого пользователя",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "Нет курса валюты на один из месяцев",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/cost-breakdown/export": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Выгружает разбивку стоимости с теми же параметрами, что и GET /subscriptions/cost-breakdown:\nстрока на месяц (или на месяц и значение group_by) и итоговая строка total.\nФормат задаётся параметром format или заголовком Accept (по умолчанию CSV).",
+                "produces": [
+                    "text/csv",
+                    "application/json",
+                    "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+                ],
+                "tags": [
+                    "Subscriptions"
+                ],
+                "summary": "Выгрузить помесячную разбивку стоимости",
+                "parameters": [
+                    {
+                        "enum": [
+                            "csv",
+                            "xlsx",
+                            "json"
+                        ],
+                        "type": "string",
+                        "description": "Формат файла",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID пользователя (UUID)",
+                        "name": "user_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Название сервиса (опционально)",
+                        "name": "service_name",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Дата начала периода (в формате YYYY-MM-DD или MM-YYYY)",
+                        "name": "start_date",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Дата окончания периода включительно (в формате YYYY-MM-DD или MM-YYYY)",
+                        "name": "end_date",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "service_name",
+                            "user_id"
+                        ],
+                        "type": "string",
+                        "description": "Группировка внутри месяца",
+                        "name": "group_by",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "charges",
+                            "monthly_equivalent"
+                        ],
+                        "type": "string",
+                        "description": "Режим подсчёта (по умолчанию charges)",
+                        "name": "mode",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "none",
+                            "daily"
+                        ],
+                        "type": "string",
+                        "description": "Пропорциональный учёт неполных периодов по дням (по умолчанию none)",
+                        "name": "proration",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Валюта результата (по умолчанию RUB)",
+                        "name": "currency",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Учитывать мягко удалённые подписки",
+                        "name": "include_deleted",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Файл выгрузки",
+                        "schema": {
+                            "type": "file"
+                        },
+                        "headers": {
+                            "Content-Disposition": {
+                                "type": "string",
+                                "description": "attachment; filename=cost-breakdown-YYYY-MM-DD-YYYY-MM-DD.csv"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Ошибка валидации",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Нет доступа к данным другого пользователя",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Problem"
+                        }
+                    },
+                    "406": {
+                        "description": "Accept не допускает ни один из форматов выгрузки",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "Нет курса валюты на один из месяцев",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/export": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Выгружает подписки по тем же фильтрам и сортировке, что и GET /subscriptions, без разбиения на страницы.\nФормат задаётся параметром format или заголовком Accept (по умолчанию CSV). Строки передаются потоком.",
+                "produces": [
+                    "text/csv",
+                    "application/json",
+                    "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+                ],
+                "tags": [
+                    "Subscriptions"
+                ],
+                "summary": "Выгрузить подписки",
+                "parameters": [
+                    {
+                        "enum": [
+                            "csv",
+                            "xlsx",
+                            "json"
+                        ],
+                        "type": "string",
+                        "description": "Формат файла",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID пользователя (UUID)",
+                        "name": "user_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Название сервиса (точное совпадение)",
+                        "name": "service_name",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Подстрока названия сервиса (без учёта регистра)",
+                        "name": "service_name_contains",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Подписка активна с даты (YYYY-MM-DD или MM-YYYY)",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Подписка активна по дату включительно (YYYY-MM-DD или MM-YYYY - до конца месяца)",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Минимальная цена, например 99.90",
+                        "name": "price_min",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Максимальная цена, например 999.00",
+                        "name": "price_max",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "id",
+                            "price",
+                            "start_date",
+                            "service_name"
+                        ],
+                        "type": "string",
+                        "description": "Поле сортировки",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "asc",
+                            "desc"
+                        ],
+                        "type": "string",
+                        "description": "Направление сортировки",
+                        "name": "order",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Включать мягко удалённые подписки (только администратор)",
+                        "name": "include_deleted",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Файл выгрузки",
+                        "schema": {
+                            "type": "file"
+                        },
+                        "headers": {
+                            "Content-Disposition": {
+                                "type": "string",
+                                "description": "attachment; filename=subscriptions-YYYY-MM-DD.csv"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Нет доступа к данным другого пользователя",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Problem"
+                        }
+                    },
+                    "406": {
+                        "description": "Accept не допускает ни один из форматов выгрузки",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/forecast": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Прогнозирует помесячные расходы на months месяцев начиная с текущего: списания по периоду подписки\nс учётом end_date, пауз и запланированных изменений цены. inflation задаёт годовой рост цен в процентах\nдля всех сервисов, service_inflation=\u003cservice_name\u003e:\u003cпроцент\u003e (можно повторять) - для отдельного сервиса.\nРост применяется к цене, действующей на дату списания, за каждый полный год от начала прогноза\n(или от месяца изменения цены, если оно позже). Возвращаются суммы по месяцам, накопленный итог и общий итог.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Subscriptions"
+                ],
+                "summary": "Прогноз расходов на подписки",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID пользователя (UUID)",
+                        "name": "user_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Название сервиса (опционально)",
+                        "name": "service_name",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Число месяцев прогноза (по умолчанию 12, не больше 120)",
+                        "name": "months",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Валюта результата (по умолчанию RUB)",
+                        "name": "currency",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Годовой рост цен всех сервисов в процентах, например 7.5",
+                        "name": "inflation",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Годовой рост цен сервиса: \u003cservice_name\u003e:\u003cпроцент\u003e",
+                        "name": "service_inflation",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Forecast"
+                        }
+                    },
+                    "400": {
+                        "description": "Ошибка валидации",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Нет доступа к данным другого пользователя",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "Нет курса валюты",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/import": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Загружает подписки из CSV (заголовок service_name,price,currency,billing_period,user_id,start_date,end_date)\nили JSON Lines (по объекту подписки в строке). Файл читается построчно, каждая строка проверяется и сохраняется отдельно.\nФормат определяется параметром format или заголовком Content-Type. dry_run=true только проверяет файл.\non_conflict задаёт поведение при совпадении (user_id, service_name, start_date) с существующей подпиской:\nskip - пропустить строку, overwrite - заменить подписку, fail - считать строку ошибкой.\nОтвет - отчёт-вложение с итогом каждой строки (created, updated, skipped, failed) и ошибками строк;\nreport=csv возвращает его CSV-файлом, итоги - в заголовках X-Import-*. Если импорт прерван\nпосле сохранения части строк, отчёт содержит эти строки, а error - ошибку с её статусом ответа.",
+                "consumes": [
+                    "text/plain"
+                ],
+                "produces": [
+                    "application/json",
+                    "text/csv"
+                ],
+                "tags": [
+                    "Subscriptions"
+                ],
+                "summary": "Импортировать подписки из файла",
+                "parameters": [
+                    {
+                        "enum": [
+                            "csv",
+                            "jsonl"
+                        ],
+                        "type": "string",
+                        "description": "Формат файла",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Только проверить файл, ничего не сохраняя",
+                        "name": "dry_run",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "skip",
+                            "overwrite",
+                            "fail"
+                        ],
+                        "type": "string",
+                        "description": "Поведение при совпадении с существующей подпиской (по умолчанию skip)",
+                        "name": "on_conflict",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "json",
+                            "csv"
+                        ],
+                        "type": "string",
+                        "description": "Формат ответа (по умолчанию json)",
+                        "name": "report",
+                        "in": "query"
+                    },
+                    {
+                        "description": "Содержимое файла",
+                        "name": "file",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.ImportResult"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Нет доступа к данным другого пользователя",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/total-cost": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Возвращает суммарную стоимость подписок пользователя за указанный период с фильтрацией по названию сервиса.\nВ режиме charges учитываются фактические списания, попавшие в период (годовая подписка - один раз в месяце списания),\nв режиме monthly_equivalent - цена, приведённая к месяцу, за каждый активный месяц.\nСтоимость в других валютах пересчитывается в currency по курсу каждого месяца.\nС proration=daily неполные периоды списания учитываются пропорционально числу активных дней.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Subscriptions"
+                ],
+                "summary": "Получение суммарной стоимости подписок",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID пользователя (UUID)",
+                        "name": "user_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Название сервиса (опционально)",
+                        "name": "service_name",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Дата начала периода (в формате YYYY-MM-DD или MM-YYYY)",
+                        "name": "start_date",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Дата окончания периода включительно (в формате YYYY-MM-DD или MM-YYYY); период не длиннее 120 месяцев",
+                        "name": "end_date",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "charges",
+                            "monthly_equivalent"
+                        ],
+                        "type": "string",
+                        "description": "Режим подсчёта (по умолчанию charges)",
+                        "name": "mode",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "none",
+                            "daily"
+                        ],
+                        "type": "string",
+                        "description": "Пропорциональный учёт неполных периодов по дням (по умолчанию none)",
+                        "name": "proration",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Валюта результата (по умолчанию RUB)",
+                        "name": "currency",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Учитывать мягко удалённые подписки",
+                        "name": "include_deleted",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Суммарная стоимость, например {\\\"total_cost\\\": 900, \\\"currency\\\": \\\"RUB\\\"}",
+                        "schema": {
+                            "$ref": "#/definitions/models.TotalCost"
+                        }
+                    },
+                    "400": {
+                        "description": "Ошибка валидации",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Нет доступа к данным другого пользователя",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "Нет курса валюты на один из месяцев",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/upcoming": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Возвращает списания, которые придутся на ближайшие days дней начиная с сегодняшнего:\nдаты считаются от start_date с периодом списания подписки, списания после end_date и во время пауз не учитываются,\nсумма - цена, действующая на дату списания. В expiring - подписки, end_date которых попадает в то же окно.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Subscriptions"
+                ],
+                "summary": "Предстоящие списания и окончания подписок",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID пользователя (UUID)",
+                        "name": "user_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Название сервиса (опционально)",
+                        "name": "service_name",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Длина окна в днях, включая сегодняшний (по умолчанию 30, не больше 366)",
+                        "name": "days",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Upcoming"
+                        }
+                    },
+                    "400": {
+                        "description": "Ошибка валидации",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Нет доступа к данным другого пользователя",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Возвращает одну подписку по её ID",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Subscriptions"
+                ],
+                "summary": "Получить подписку по ID",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID подписки",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Возвращать мягко удалённую подписку",
+                        "name": "include_deleted",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Subscription"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Версия записи для If-Match"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Нет доступа к данным другого пользователя",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Problem"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Полностью заменяет поля подписки по ID: тело - как при создании, все обязательные поля нужны.\nНеобязательные поля, которых нет в теле, получают значения по умолчанию (end_date - без даты окончания).\nНовая цена действует с текущего месяца, прошлые месяцы считаются по прежней цене.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Subscriptions"
+                ],
+                "summary": "Заменить подписку",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID подписки",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Новые данные подписки",
+                        "name": "subscription",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.SubscriptionInput"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag из предыдущего ответа; при несовпадении версии - 412",
+                        "name": "If-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Subscription"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Новая версия записи"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Нет доступа к данным другого пользователя",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Problem"
+                        }
+                    },
+                    "412": {
+                        "description": "Запись изменена другим запросом",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Problem"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Мягко удаляет подписку по ID: запись скрывается из выборок и подсчёта стоимости\nи может быть восстановлена до окончательной очистки по истечении срока хранения",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Subscriptions"
+                ],
+                "summary": "Удалить подписку",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID подписки",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag из предыдущего ответа; при несовпадении версии - 412",
+                        "name": "If-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Подписка удалена"
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Problem"
+                        }
+                    },
+                    "412": {
+                        "description": "Запись изменена другим запросом",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Problem"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Изменяет подписку по правилам JSON Merge Patch (RFC 7396): отсутствующие поля не меняются,\nnull у end_date снимает дату окончания, нулевая цена допустима. Остальные поля удалить (null) нельзя.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Subscriptions"
+                ],
+                "summary": "Частично изменить подписку",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID подписки",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Изменяемые поля",
+                        "name": "patch",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.UpdateSubscriptionInput"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag из предыдущего ответа; при несовпадении версии - 412",
+                        "name": "If-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Subscription"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Новая версия записи"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Нет доступа к данным другого пользователя",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Problem"
+                        }
+                    },
+                    "412": {
+                        "description": "Запись изменена другим запросом",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Problem"
+                        }
+                    },
+                    "415": {
+                        "description": "Unsupported Media Type",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/{id}/cancel": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Отменяет подписку: указанная дата (по умолчанию сегодня) становится последним днём действия",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Lifecycle"
+                ],
+                "summary": "Отменить подписку",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID подписки",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Последний день действия",
+                        "name": "body",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/models.LifecycleInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Subscription"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "Подписка уже отменена",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/{id}/history": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Возвращает журнал изменений подписки (создание, изменения, удаление) от новых к старым\nсо снимками записи до и после каждого изменения. Доступна и для удалённых подписок.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Audit"
+                ],
+                "summary": "История изменений подписки",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID подписки",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.AuditEntry"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/{id}/pause": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Приостанавливает активную подписку с указанной даты (по умолчанию сегодня). Списания на время паузы не учитываются в стоимости.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Lifecycle"
+                ],
+                "summary": "Приостановить подписку",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID подписки",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Дата начала паузы",
+                        "name": "body",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/models.LifecycleInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Subscription"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "Подписка не активна",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/{id}/prices": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Возвращает цены подписки с месяцами, с которых они действуют, в порядке возрастания",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Prices"
+                ],
+                "summary": "История цен подписки",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID подписки",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.PriceChange"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Problem"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Устанавливает цену, действующую с указанного месяца (в том числе будущего). Стоимость за предыдущие месяцы\nсчитается по прежней цене. Повторное изменение в том же месяце заменяет цену этого месяца.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Prices"
+                ],
+                "summary": "Изменить цену подписки",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID подписки",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Новая цена и месяц начала действия",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.PriceChangeInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Subscription"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/{id}/restore": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Снимает пометку удаления с мягко удалённой подписки",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Lifecycle"
+                ],
+                "summary": "Восстановить удалённую подписку",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID подписки",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Subscription"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "Подписка не удалена или уже существует неудалённая с теми же user_id, service_name и start_date",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/{id}/resume": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Возобновляет приостановленную подписку с указанной даты (по умолчанию сегодня)",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Lifecycle"
+                ],
+                "summary": "Возобновить подписку",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID подписки",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Первый активный день после паузы",
+                        "name": "body",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/models.LifecycleInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Subscription"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "Подписка не приостановлена",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{user_id}/calendar-token": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Возвращает ссылку на GET /users/{user_id}/renewals.ics с токеном, по которой календарные приложения\nподписываются на календарь без заголовков аутентификации. Токен даёт только чтение календаря\nэтого пользователя; все ссылки отзываются сменой CALENDAR_TOKEN_SECRET.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Calendar"
+                ],
+                "summary": "Секретная ссылка на календарь продлений",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID пользователя (UUID)",
+                        "name": "user_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.CalendarFeed"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Нет доступа к данным другого пользователя",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Ссылки на календарь не настроены",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{user_id}/renewals.ics": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Календарь iCalendar (RFC 5545): по повторяющемуся событию на каждую действующую подписку пользователя.\nСобытия повторяются с периодом списания от start_date до end_date; даты списаний, пропущенных из-за пауз, исключены.\nВ описании события - сервис, стоимость и период списания. Без учётных данных календарь доступен\nпо секретной ссылке с параметром token (GET /users/{user_id}/calendar-token).",
+                "produces": [
+                    "text/calendar"
+                ],
+                "tags": [
+                    "Calendar"
+                ],
+                "summary": "Календарь продлений подписок",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID пользователя (UUID)",
+                        "name": "user_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Токен секретной ссылки; заменяет API-ключ и JWT",
+                        "name": "token",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Календарь",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Недействительный токен ссылки",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Нет доступа к данным другого пользователя",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Problem"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "models.APIKey": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "prefix": {
+                    "description": "Начало ключа для опознания в списке",
+                    "type": "string"
+                },
+                "revoked_at": {
+                    "type": "string"
+                },
+                "role": {
+                    "type": "string",
+                    "enum": [
+                        "admin",
+                        "user"
+                    ]
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.APIKeyInput": {
+            "type": "object",
+            "properties": {
+                "name": {
+                    "type": "string",
+                    "example": "mobile-app"
+                },
+                "role": {
+                    "type": "string",
+                    "enum": [
+                        "admin",
+                        "user"
+                    ],
+                    "example": "user"
+                },
+                "user_id": {
+                    "type": "string",
+                    "example": "60601fee-2bf1-4721-ae6f-7636e79a0cba"
+                }
+            }
+        },
+        "models.AuditEntry": {
+            "type": "object",
+            "properties": {
+                "actor": {
+                    "description": "Кто выполнил изменение",
+                    "type": "string"
+                },
+                "after": {
+                    "type": "object"
+                },
+                "before": {
+                    "type": "object"
+                },
+                "changed_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "operation": {
+                    "type": "string",
+                    "enum": [
+                        "create",
+                        "update",
+                        "delete",
+                        "restore",
+                        "purge"
+                    ]
+                },
+                "subscription_id": {
+                    "type": "integer"
+                },
+                "user_id": {
+                    "description": "Владелец подписки",
+                    "type": "string"
+                }
+            }
+        },
+        "models.BatchInput": {
+            "type": "object",
+            "properties": {
+                "mode": {
+                    "description": "atomic (по умолчанию) или best_effort",
+                    "type": "string",
+                    "example": "atomic"
+                },
+                "operations": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.BatchOperationInput"
+                    }
+                }
+            }
+        },
+        "models.BatchOperationInput": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "description": "create - данные подписки, update - merge patch",
+                    "type": "object"
+                },
+                "id": {
+                    "description": "ID подписки для update и delete",
+                    "type": "integer"
+                },
+                "op": {
+                    "description": "create, update или delete",
+                    "type": "string",
+                    "example": "create"
+                },
+                "version": {
+                    "description": "Ожидаемая версия записи (аналог If-Match)",
+                    "type": "integer"
+                }
+            }
+        },
+        "models.BatchResponse": {
+            "type": "object",
+            "properties": {
+                "failed": {
+                    "description": "Число операций с ошибкой или отменённых",
+                    "type": "integer"
+                },
+                "mode": {
+                    "type": "string",
+                    "example": "atomic"
+                },
+                "results": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.BatchResult"
+                    }
+                },
+                "succeeded": {
+                    "description": "Число выполненных операций",
+                    "type": "integer"
+                }
+            }
+        },
+        "models.BatchResult": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "description": "Ошибка операции",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/utils.Problem"
+                        }
+                    ]
+                },
+                "id": {
+                    "description": "ID созданной, изменённой или удалённой подписки",
+                    "type": "integer"
+                },
+                "index": {
+                    "description": "Позиция операции в запросе",
+                    "type": "integer"
+                },
+                "op": {
+                    "description": "Операция",
+                    "type": "string",
+                    "example": "create"
+                },
+                "status": {
+                    "description": "HTTP-статус, который получила бы операция отдельным запросом",
+                    "type": "integer",
+                    "example": 200
+                },
+                "subscription": {
+                    "description": "Подписка после update",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.Subscription"
+                        }
+                    ]
+                }
+            }
+        },
+        "models.CalendarFeed": {
+            "type": "object",
+            "properties": {
+                "token": {
+                    "type": "string",
+                    "example": "cal_..."
+                },
+                "url": {
+                    "type": "string",
+                    "example": "https://example.com/users/60601fee-2bf1-4721-ae6f-7636e79a0cba/renewals.ics?token=cal_..."
+                }
+            }
+        },
+        "models.CostBreakdown": {
+            "type": "object",
+            "properties": {
+                "currency": {
+                    "type": "string"
+                },
+                "group_by": {
+                    "type": "string"
+                },
+                "months": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.MonthlyCost"
+                    }
+                },
+                "total": {
+                    "description": "Совпадает с total_cost за тот же период и режим",
+                    "type": "string",
+                    "example": "900.00"
+                }
+            }
+        },
+        "models.CreatedAPIKey": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "key": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "prefix": {
+                    "description": "Начало ключа для опознания в списке",
+                    "type": "string"
+                },
+                "revoked_at": {
+                    "type": "string"
+                },
+                "role": {
+                    "type": "string",
+                    "enum": [
+                        "admin",
+                        "user"
+                    ]
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.ExchangeRate": {
+            "type": "object",
+            "properties": {
+                "currency": {
+                    "description": "Код валюты ISO 4217",
+                    "type": "string"
+                },
+                "date": {
+                    "description": "Дата, с которой действует курс",
+                    "type": "string"
+                },
+                "rate": {
+                    "description": "Рублей за единицу валюты, десятичная строка",
+                    "type": "string"
+                }
+            }
+        },
+        "models.ExpiringSubscription": {
+            "type": "object",
+            "properties": {
+                "currency": {
+                    "type": "string"
+                },
+                "end_date": {
+                    "description": "Последний день действия YYYY-MM-DD",
+                    "type": "string",
+                    "example": "2026-11-30"
+                },
+                "price": {
+                    "description": "Цена, действующая на end_date",
+                    "type": "string",
+                    "example": "299.90"
+                },
+                "service_name": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "subscription_id": {
+                    "type": "integer"
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.Forecast": {
+            "type": "object",
+            "properties": {
+                "currency": {
+                    "type": "string"
+                },
+                "months": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.ForecastMonth"
+                    }
+                },
+                "total": {
+                    "description": "Сумма за все месяцы прогноза",
+                    "type": "string",
+                    "example": "10800.00"
+                }
+            }
+        },
+        "models.ForecastMonth": {
+            "type": "object",
+            "properties": {
+                "cumulative": {
+                    "description": "Списания с начала прогноза по этот месяц включительно",
+                    "type": "string",
+                    "example": "1800.00"
+                },
+                "month": {
+                    "description": "Месяц в формате MM-YYYY",
+                    "type": "string",
+                    "example": "11-2026"
+                },
+                "total": {
+                    "description": "Списания за месяц",
+                    "type": "string",
+                    "example": "900.00"
+                }
+            }
+        },
+        "models.ImportResult": {
+            "type": "object",
+            "properties": {
+                "created": {
+                    "description": "Создано подписок (при dry_run - было бы создано)",
+                    "type": "integer"
+                },
+                "dry_run": {
+                    "type": "boolean"
+                },
+                "error": {
+                    "description": "Error - импорт прерван после строки Rows; строки до неё уже сохранены и перечислены в results",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/utils.Problem"
+                        }
+                    ]
+                },
+                "failed": {
+                    "description": "Строк с ошибками",
+                    "type": "integer"
+                },
+                "results": {
+                    "description": "Results - итог каждой строки в порядке файла, включая уже сохранённые",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.ImportRowResult"
+                    }
+                },
+                "rows": {
+                    "description": "Число обработанных строк данных",
+                    "type": "integer"
+                },
+                "skipped": {
+                    "description": "Пропущено из-за совпадения с существующей подпиской",
+                    "type": "integer"
+                },
+                "truncated": {
+                    "description": "В results попали не все строки",
+                    "type": "boolean"
+                },
+                "updated": {
+                    "description": "Заменено существующих подписок",
+                    "type": "integer"
+                }
+            }
+        },
+        "models.ImportRowError": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "description": "Машиночитаемый код ошибки",
+                    "type": "string",
+                    "example": "validation_failed"
+                },
+                "field": {
+                    "description": "Поле строки; пусто - ошибка всей строки",
+                    "type": "string",
+                    "example": "price"
+                },
+                "message": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.ImportRowResult": {
+            "type": "object",
+            "properties": {
+                "errors": {
+                    "description": "Ошибки строки со статусом failed",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.ImportRowError"
+                    }
+                },
+                "line": {
+                    "description": "Номер строки файла, начиная с 1",
+                    "type": "integer",
+                    "example": 3
+                },
+                "status": {
+                    "type": "string",
+                    "enum": [
+                        "created",
+                        "updated",
+                        "skipped",
+                        "failed"
+                    ]
+                },
+                "subscription_id": {
+                    "description": "Созданная или заменённая подписка (не при dry_run)",
+                    "type": "integer"
+                }
+            }
+        },
+        "models.LifecycleInput": {
+            "type": "object",
+            "properties": {
+                "date": {
+                    "description": "YYYY-MM-DD, по умолчанию сегодня",
+                    "type": "string",
+                    "example": "2025-07-15"
+                }
+            }
+        },
+        "models.MonthlyCost": {
+            "type": "object",
+            "properties": {
+                "groups": {
+                    "description": "Сумма по значениям group_by",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "month": {
+                    "description": "Месяц в формате MM-YYYY",
+                    "type": "string"
+                },
+                "total": {
+                    "description": "Сумма за месяц",
+                    "type": "string",
+                    "example": "900.00"
+                }
+            }
+        },
+        "models.Pause": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "integer"
+                },
+                "paused_on": {
+                    "description": "Первый день паузы",
+                    "type": "string"
+                },
+                "resumed_on": {
+                    "description": "Первый активный день после паузы; null - пауза ещё длится",
+                    "type": "string"
+                }
+            }
+        },
+        "models.PriceChange": {
+            "type": "object",
+            "properties": {
+                "effective_month": {
+                    "description": "Первое число месяца",
                     "type": "string"
                 },
                 "id": {
-                    "description": "Primary key",
                     "type": "integer"
                 },
                 "price": {
-                    "description": "Стоимость в рублях",
+                    "description": "Цена за период списания",
+                    "type": "string",
+                    "example": "299.90"
+                }
+            }
+        },
+        "models.PriceChangeInput": {
+            "type": "object",
+            "properties": {
+                "effective_month": {
+                    "description": "MM-YYYY",
+                    "type": "string",
+                    "example": "09-2025"
+                },
+                "price": {
+                    "type": "string",
+                    "example": "349.90"
+                }
+            }
+        },
+        "models.RatesImportResult": {
+            "type": "object",
+            "properties": {
+                "imported": {
+                    "description": "Количество загруженных (добавленных или обновлённых) курсов",
+                    "type": "integer"
+                }
+            }
+        },
+        "models.Subscription": {
+            "type": "object",
+            "properties": {
+                "billing_period": {
+                    "description": "Период списания: weekly, monthly, quarterly, yearly",
+                    "type": "string"
+                },
+                "currency": {
+                    "description": "Код валюты ISO 4217 с двумя знаками после запятой, например RUB, USD, EUR",
+                    "type": "string"
+                },
+                "deleted_at": {
+                    "description": "Момент мягкого удаления",
+                    "type": "string"
+                },
+                "end_date": {
+                    "description": "Опционально: дата окончания (последний день действия)",
+                    "type": "string"
+                },
+                "id": {
+                    "description": "Primary key",
                     "type": "integer"
                 },
+                "pauses": {
+                    "description": "Интервалы приостановки по возрастанию даты",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Pause"
+                    }
+                },
+                "price": {
+                    "description": "Действующая стоимость за один период списания в валюте currency",
+                    "type": "string",
+                    "example": "299.90"
+                },
+                "price_history": {
+                    "description": "Изменения цены по возрастанию месяца",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.PriceChange"
+                    }
+                },
                 "service_name": {
                     "description": "Название сервиса",
                     "type": "string"
                 },
                 "start_date": {
-                    "description": "Дата начала",
+                    "description": "Дата начала (дата первого списания)",
+                    "type": "string"
+                },
+                "status": {
+                    "description": "Состояние: active, paused, cancelled",
                     "type": "string"
                 },
                 "user_id": {
                     "description": "UUID пользователя",
                     "type": "string"
+                },
+                "version": {
+                    "description": "Увеличивается при каждом изменении, передаётся в ETag",
+                    "type": "integer"
                 }
             }
         },
         "models.SubscriptionInput": {
             "type": "object",
             "properties": {
+                "billing_period": {
+                    "description": "по умолчанию monthly",
+                    "type": "string"
+                },
+                "currency": {
+                    "description": "по умолчанию RUB",
+                    "type": "string"
+                },
                 "end_date": {
                     "description": "аналогично",
                     "type": "string"
                 },
                 "price": {
-                    "type": "integer"
+                    "type": "string",
+                    "example": "299.90"
                 },
                 "service_name": {
                     "type": "string"
@@ -344,14 +2871,114 @@ const docTemplate = `{
                 }
             }
         },
-        "models.UpdateSubscription": {
+        "models.SubscriptionPage": {
+            "type": "object",
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Subscription"
+                    }
+                },
+                "next_cursor": {
+                    "description": "Пусто, если страница последняя",
+                    "type": "string"
+                }
+            }
+        },
+        "models.TotalCost": {
+            "type": "object",
+            "properties": {
+                "currency": {
+                    "type": "string"
+                },
+                "total_cost": {
+                    "type": "string",
+                    "example": "900.00"
+                }
+            }
+        },
+        "models.Upcoming": {
+            "type": "object",
+            "properties": {
+                "charges": {
+                    "description": "По возрастанию даты",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.UpcomingCharge"
+                    }
+                },
+                "expiring": {
+                    "description": "По возрастанию end_date",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.ExpiringSubscription"
+                    }
+                },
+                "from": {
+                    "description": "Первый день окна (сегодня)",
+                    "type": "string",
+                    "example": "2026-10-17"
+                },
+                "to": {
+                    "description": "Последний день окна включительно",
+                    "type": "string",
+                    "example": "2026-11-15"
+                },
+                "totals": {
+                    "description": "Сумма списаний по валютам",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "models.UpcomingCharge": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "description": "Цена, действующая на дату списания",
+                    "type": "string",
+                    "example": "299.90"
+                },
+                "billing_period": {
+                    "type": "string"
+                },
+                "currency": {
+                    "type": "string"
+                },
+                "date": {
+                    "description": "Дата списания YYYY-MM-DD",
+                    "type": "string",
+                    "example": "2026-11-01"
+                },
+                "service_name": {
+                    "type": "string"
+                },
+                "subscription_id": {
+                    "type": "integer"
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.UpdateSubscriptionInput": {
             "type": "object",
             "properties": {
+                "billing_period": {
+                    "type": "string"
+                },
+                "currency": {
+                    "type": "string"
+                },
                 "end_date": {
                     "type": "string"
                 },
                 "price": {
-                    "type": "integer"
+                    "type": "string",
+                    "example": "299.90"
                 },
                 "service_name": {
                     "type": "string"
@@ -363,6 +2990,67 @@ const docTemplate = `{
                     "type": "string"
                 }
             }
+        },
+        "utils.FieldError": {
+            "type": "object",
+            "properties": {
+                "field": {
+                    "type": "string",
+                    "example": "start_date"
+                },
+                "message": {
+                    "type": "string",
+                    "example": "Неверный формат start_date (YYYY-MM-DD или MM-YYYY)"
+                }
+            }
+        },
+        "utils.Problem": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "string",
+                    "example": "validation_failed"
+                },
+                "detail": {
+                    "type": "string",
+                    "example": "Неверный формат start_date (YYYY-MM-DD или MM-YYYY)"
+                },
+                "errors": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/utils.FieldError"
+                    }
+                },
+                "instance": {
+                    "type": "string",
+                    "example": "/subscriptions"
+                },
+                "status": {
+                    "type": "integer",
+                    "example": 400
+                },
+                "title": {
+                    "type": "string",
+                    "example": "Ошибка проверки данных"
+                },
+                "type": {
+                    "type": "string",
+                    "example": "/problems/validation_failed"
+                }
+            }
+        }
+    },
+    "securityDefinitions": {
+        "ApiKeyAuth": {
+            "type": "apiKey",
+            "name": "X-API-Key",
+            "in": "header"
+        },
+        "BearerAuth": {
+            "description": "JWT в формате \"Bearer \u003ctoken\u003e\"",
+            "type": "apiKey",
+            "name": "Authorization",
+            "in": "header"
         }
     }
 }`
@@ -0,0 +1,191 @@
+package export
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"sort"
+	"strconv"
+	"strings"
+	"usersubs/models"
+)
+
+// Форматы выгрузки
+const (
+	FormatCSV  = "csv"
+	FormatXLSX = "xlsx"
+	FormatJSON = "json"
+)
+
+var contentTypes = map[string]string{
+	FormatCSV:  "text/csv; charset=utf-8",
+	FormatXLSX: "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+	FormatJSON: "application/json",
+}
+
+// IsValidFormat проверяет, что формат выгрузки поддерживается
+func IsValidFormat(format string) bool {
+	_, ok := contentTypes[format]
+	return ok
+}
+
+// ContentType возвращает тип содержимого выгрузки в формате format
+func ContentType(format string) string {
+	return contentTypes[format]
+}
+
+// Negotiate выбирает формат по заголовку Accept с учётом q. Без заголовка и для */* - CSV;
+// пусто - ни один из поддерживаемых форматов не подходит.
+func Negotiate(accept string) string {
+	if strings.TrimSpace(accept) == "" {
+		return FormatCSV
+	}
+	type candidate struct {
+		format string
+		q      float64
+	}
+	var candidates []candidate
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if v, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(v, 64); err != nil {
+				continue
+			}
+		}
+		if q <= 0 {
+			continue
+		}
+		switch mediaType {
+		case "*/*", "text/*", "text/csv":
+			candidates = append(candidates, candidate{FormatCSV, q})
+		case "application/json":
+			candidates = append(candidates, candidate{FormatJSON, q})
+		case contentTypes[FormatXLSX]:
+			candidates = append(candidates, candidate{FormatXLSX, q})
+		}
+	}
+	if len(candidates) == 0 {
+		return ""
+	}
+	// При равном q побеждает формат, указанный раньше
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].q > candidates[j].q })
+	return candidates[0].format
+}
+
+// Writer пишет таблицу построчно, не накапливая строки в памяти.
+// Значения: строки, числа, models.Money и nil (пустая ячейка).
+type Writer interface {
+	// WriteRow пишет строку; значения идут в порядке колонок
+	WriteRow(values ...any) error
+	// Close дописывает окончание файла; после Close писать нельзя
+	Close() error
+}
+
+// NewWriter начинает выгрузку в формате format и сразу пишет заголовок с колонками columns
+func NewWriter(format string, w io.Writer, columns []string) (Writer, error) {
+	switch format {
+	case FormatCSV:
+		return newCSVWriter(w, columns)
+	case FormatXLSX:
+		return newXLSXWriter(w, columns)
+	case FormatJSON:
+		return newJSONWriter(w, columns)
+	}
+	return nil, fmt.Errorf("неизвестный формат выгрузки %q", format)
+}
+
+// text возвращает значение ячейки строкой
+func text(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case fmt.Stringer:
+		return v.String()
+	}
+	return fmt.Sprint(value)
+}
+
+// isNumber проверяет, что значение выгружается числом
+func isNumber(value any) bool {
+	switch value.(type) {
+	case int, int64, float64, models.Money:
+		return true
+	}
+	return false
+}
+
+type csvWriter struct {
+	out *csv.Writer
+}
+
+func newCSVWriter(w io.Writer, columns []string) (*csvWriter, error) {
+	cw := &csvWriter{out: csv.NewWriter(w)}
+	return cw, cw.out.Write(columns)
+}
+
+func (cw *csvWriter) WriteRow(values ...any) error {
+	record := make([]string, len(values))
+	for i, v := range values {
+		record[i] = text(v)
+	}
+	return cw.out.Write(record)
+}
+
+func (cw *csvWriter) Close() error {
+	cw.out.Flush()
+	return cw.out.Error()
+}
+
+// jsonWriter пишет массив объектов; ключи - имена колонок в их порядке
+type jsonWriter struct {
+	out     *bufio.Writer
+	columns []string
+	rows    int
+}
+
+func newJSONWriter(w io.Writer, columns []string) (*jsonWriter, error) {
+	jw := &jsonWriter{out: bufio.NewWriter(w), columns: columns}
+	_, err := jw.out.WriteString("[")
+	return jw, err
+}
+
+func (jw *jsonWriter) WriteRow(values ...any) error {
+	if jw.rows > 0 {
+		jw.out.WriteString(",")
+	}
+	jw.rows++
+	jw.out.WriteString("\n{")
+	for i, column := range jw.columns {
+		if i > 0 {
+			jw.out.WriteString(",")
+		}
+		var value any
+		if i < len(values) {
+			value = values[i]
+		}
+		key, _ := json.Marshal(column)
+		data, err := json.Marshal(value)
+		if err != nil {
+			return err
+		}
+		jw.out.Write(key)
+		jw.out.WriteString(":")
+		jw.out.Write(data)
+	}
+	_, err := jw.out.WriteString("}")
+	return err
+}
+
+func (jw *jsonWriter) Close() error {
+	jw.out.WriteString("\n]\n")
+	return jw.out.Flush()
+}
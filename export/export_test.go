@@ -0,0 +1,131 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"io"
+	"slices"
+	"testing"
+	"usersubs/models"
+)
+
+func TestNegotiate(t *testing.T) {
+	tests := []struct {
+		accept, want string
+	}{
+		{"", FormatCSV},
+		{"*/*", FormatCSV},
+		{"text/csv", FormatCSV},
+		{"application/json", FormatJSON},
+		{"application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", FormatXLSX},
+		{"text/csv;q=0.5, application/json", FormatJSON},
+		{"application/json, text/csv", FormatJSON},
+		{"application/json;q=0, */*;q=0.1", FormatCSV},
+		{"application/xml", ""},
+		{"text/csv;q=abc", ""},
+	}
+	for _, tt := range tests {
+		if got := Negotiate(tt.accept); got != tt.want {
+			t.Errorf("Negotiate(%q) = %q, want %q", tt.accept, got, tt.want)
+		}
+	}
+}
+
+// exportRows - строки, на которых проверяются все форматы
+var exportRows = [][]any{
+	{1, "Okko", models.Money(29990), nil},
+	{2, `Ivi <"HD"> & Co`, models.Money(-5), "  пробелы  "},
+}
+
+func writeAll(t *testing.T, format string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w, err := NewWriter(format, &buf, []string{"id", "service_name", "price", "comment"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, row := range exportRows {
+		if err := w.WriteRow(row...); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestCSVWriter(t *testing.T) {
+	want := "id,service_name,price,comment\n" +
+		"1,Okko,299.90,\n" +
+		"2,\"Ivi <\"\"HD\"\"> & Co\",-0.05,\"  пробелы  \"\n"
+	if got := string(writeAll(t, FormatCSV)); got != want {
+		t.Errorf("CSV =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestJSONWriter(t *testing.T) {
+	want := "[\n" +
+		`{"id":1,"service_name":"Okko","price":"299.90","comment":null},` + "\n" +
+		`{"id":2,"service_name":"Ivi \u003c\"HD\"\u003e \u0026 Co","price":"-0.05","comment":"  пробелы  "}` + "\n]\n"
+	if got := string(writeAll(t, FormatJSON)); got != want {
+		t.Errorf("JSON =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestXLSXWriter(t *testing.T) {
+	data := writeAll(t, FormatXLSX)
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("not a zip archive: %v", err)
+	}
+	var names []string
+	var sheet []byte
+	for _, f := range archive.File {
+		names = append(names, f.Name)
+		if f.Name == "xl/worksheets/sheet1.xml" {
+			r, err := f.Open()
+			if err != nil {
+				t.Fatal(err)
+			}
+			sheet, _ = io.ReadAll(r)
+			r.Close()
+		}
+	}
+	wantNames := []string{"[Content_Types].xml", "_rels/.rels", "xl/workbook.xml", "xl/_rels/workbook.xml.rels", "xl/worksheets/sheet1.xml"}
+	if !slices.Equal(names, wantNames) {
+		t.Fatalf("parts = %v, want %v", names, wantNames)
+	}
+
+	var ws struct {
+		Rows []struct {
+			Cells []struct {
+				Type   string `xml:"t,attr"`
+				Value  string `xml:"v"`
+				Inline string `xml:"is>t"`
+			} `xml:"c"`
+		} `xml:"sheetData>row"`
+	}
+	if err := xml.Unmarshal(sheet, &ws); err != nil {
+		t.Fatalf("sheet is not valid XML: %v", err)
+	}
+	// Ячейка: тип и значение; числа - в <v>, строки - в <is><t>
+	want := [][]string{
+		{"inlineStr:id", "inlineStr:service_name", "inlineStr:price", "inlineStr:comment"},
+		{"n:1", "inlineStr:Okko", "n:299.90", ":"},
+		{"n:2", `inlineStr:Ivi <"HD"> & Co`, "n:-0.05", "inlineStr:  пробелы  "},
+	}
+	if len(ws.Rows) != len(want) {
+		t.Fatalf("rows = %d, want %d", len(ws.Rows), len(want))
+	}
+	for i, row := range ws.Rows {
+		var got []string
+		for _, c := range row.Cells {
+			got = append(got, c.Type+":"+c.Value+c.Inline)
+		}
+		if !slices.Equal(got, want[i]) {
+			t.Errorf("row %d = %q, want %q", i, got, want[i])
+		}
+	}
+}
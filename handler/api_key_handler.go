@@ -0,0 +1,120 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"usersubs/logger"
+	"usersubs/models"
+	"usersubs/service"
+	"usersubs/utils"
+
+	"go.uber.org/zap"
+)
+
+type APIKeyHandler struct {
+	Service *service.AuthService
+}
+
+func NewAPIKeyHandler(s *service.AuthService) *APIKeyHandler {
+	return &APIKeyHandler{Service: s}
+}
+
+// HandleAPIKeys обрабатывает /admin/api-keys и /admin/api-keys/{id}
+func (h *APIKeyHandler) HandleAPIKeys(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	idStr := strings.TrimPrefix(strings.TrimPrefix(r.URL.Path, "/admin/api-keys"), "/")
+	if idStr == "" {
+		switch r.Method {
+		case http.MethodGet:
+			h.ListAPIKeys(w, r)
+		case http.MethodPost:
+			h.CreateAPIKey(w, r)
+		default:
+			utils.MethodNotAllowed(w, r)
+		}
+		return
+	}
+
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		utils.InvalidField(w, r, "id", utils.MsgInvalidKeyID)
+		return
+	}
+	if r.Method != http.MethodDelete {
+		utils.MethodNotAllowed(w, r)
+		return
+	}
+	h.RevokeAPIKey(w, r, id)
+}
+
+// @Summary Создать API-ключ
+// @Description Создаёт ключ администратора (role=admin) или пользователя (role=user с user_id).
+// @Description Секрет ключа возвращается только в этом ответе, в хранилище сохраняется его хеш.
+// @Tags API keys
+// @Accept json
+// @Produce json
+// @Param key body models.APIKeyInput true "Параметры ключа"
+// @Success 201 {object} models.CreatedAPIKey
+// @Failure 400 {object} utils.Problem
+// @Failure 403 {object} utils.Problem
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /admin/api-keys [post]
+func (h *APIKeyHandler) CreateAPIKey(w http.ResponseWriter, r *http.Request) {
+	var input models.APIKeyInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		logger.L().Error("Неправильное тело запроса", zap.Error(err))
+		utils.Error(w, r, http.StatusBadRequest, utils.CodeBadRequest, utils.MsgBadBody)
+		return
+	}
+
+	created, err := h.Service.CreateAPIKey(r.Context(), input)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	logger.L().Info("Создан API-ключ", zap.Int("id", created.ID), zap.String("role", created.Role))
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(created)
+}
+
+// @Summary Список API-ключей
+// @Description Возвращает все ключи, включая отозванные, без секретов
+// @Tags API keys
+// @Produce json
+// @Success 200 {array} models.APIKey
+// @Failure 403 {object} utils.Problem
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /admin/api-keys [get]
+func (h *APIKeyHandler) ListAPIKeys(w http.ResponseWriter, r *http.Request) {
+	keys, err := h.Service.ListAPIKeys(r.Context())
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	json.NewEncoder(w).Encode(keys)
+}
+
+// @Summary Отозвать API-ключ
+// @Description Отзывает ключ: запросы с ним больше не принимаются
+// @Tags API keys
+// @Param id path int true "ID ключа"
+// @Success 204 "Ключ отозван"
+// @Failure 403 {object} utils.Problem
+// @Failure 404 {object} utils.Problem
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /admin/api-keys/{id} [delete]
+func (h *APIKeyHandler) RevokeAPIKey(w http.ResponseWriter, r *http.Request, id int) {
+	if err := h.Service.RevokeAPIKey(r.Context(), id); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	logger.L().Info("API-ключ отозван", zap.Int("id", id))
+	w.WriteHeader(http.StatusNoContent)
+}
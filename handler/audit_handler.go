@@ -0,0 +1,114 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+	"usersubs/models"
+	"usersubs/utils"
+)
+
+// @Summary История изменений подписки
+// @Description Возвращает журнал изменений подписки (создание, изменения, удаление) от новых к старым
+// @Description со снимками записи до и после каждого изменения. Доступна и для удалённых подписок.
+// @Tags Audit
+// @Produce json
+// @Param id path int true "ID подписки"
+// @Success 200 {array} models.AuditEntry
+// @Failure 404 {object} utils.Problem
+// @Failure 500 {object} utils.Problem
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /subscriptions/{id}/history [get]
+func (h *SubscriptionHandler) GetSubscriptionHistory(w http.ResponseWriter, r *http.Request, id int) {
+	entries, err := h.Service.SubscriptionHistory(r.Context(), id)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	json.NewEncoder(w).Encode(entries)
+}
+
+// @Summary Журнал изменений подписок
+// @Description Возвращает записи журнала изменений всех подписок от новых к старым.
+// @Description Для следующей страницы передайте before_id = id последней полученной записи.
+// @Tags Audit
+// @Produce json
+// @Param user_id query string false "Владелец подписки (UUID)"
+// @Param from query string false "Начало интервала (RFC 3339 или YYYY-MM-DD)"
+// @Param to query string false "Конец интервала включительно (RFC 3339 или YYYY-MM-DD)"
+// @Param before_id query int false "Только записи с id меньше указанного"
+// @Param limit query int false "Количество записей (по умолчанию 50, максимум 500)"
+// @Success 200 {array} models.AuditEntry
+// @Failure 400 {object} utils.Problem
+// @Failure 500 {object} utils.Problem
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /admin/audit [get]
+func (h *SubscriptionHandler) ListAudit(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if r.Method != http.MethodGet {
+		utils.MethodNotAllowed(w, r)
+		return
+	}
+	if !requireScope(w, r, models.ScopeSubscriptionsRead) {
+		return
+	}
+	query := r.URL.Query()
+
+	filter := models.AuditFilter{UserID: query.Get("user_id")}
+	var err error
+	if filter.From, err = parseAuditTime(query.Get("from"), false); err != nil {
+		utils.InvalidField(w, r, "from", utils.MsgInvalidTimestamp, "from")
+		return
+	}
+	if filter.To, err = parseAuditTime(query.Get("to"), true); err != nil {
+		utils.InvalidField(w, r, "to", utils.MsgInvalidTimestamp, "to")
+		return
+	}
+	if s := query.Get("before_id"); s != "" {
+		filter.BeforeID, err = strconv.ParseInt(s, 10, 64)
+		if err != nil || filter.BeforeID <= 0 {
+			utils.InvalidField(w, r, "before_id", utils.MsgPositiveInteger, "before_id")
+			return
+		}
+	}
+	if s := query.Get("limit"); s != "" {
+		filter.Limit, err = strconv.Atoi(s)
+		if err != nil || filter.Limit <= 0 {
+			utils.InvalidField(w, r, "limit", utils.MsgPositiveInteger, "limit")
+			return
+		}
+	}
+
+	entries, err := h.Service.ListAudit(r.Context(), filter)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	json.NewEncoder(w).Encode(entries)
+}
+
+// parseAuditTime разбирает момент времени в RFC 3339 или дату YYYY-MM-DD.
+// Для конца интервала (end) дата включается целиком: возвращается начало следующего дня.
+func parseAuditTime(s string, end bool) (*time.Time, error) {
+	if s == "" {
+		return nil, nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		if end {
+			// Граница в запросе - включительно, в фильтре - исключительно
+			t = t.Add(time.Nanosecond)
+		}
+		return &t, nil
+	}
+	t, err := time.Parse("2006-01-02", s)
+	if err != nil {
+		return nil, err
+	}
+	if end {
+		t = t.AddDate(0, 0, 1)
+	}
+	return &t, nil
+}
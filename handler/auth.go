@@ -0,0 +1,88 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"usersubs/logger"
+	"usersubs/models"
+	"usersubs/repository"
+	"usersubs/service"
+	"usersubs/utils"
+
+	"go.uber.org/zap"
+)
+
+// Заголовок с API-ключом
+const apiKeyHeader = "X-API-Key"
+
+// Authenticate проверяет JWT из Authorization: Bearer или API-ключ и передаёт клиента в контекст запроса.
+// Swagger UI доступен без аутентификации, календарь продлений - по токену секретной ссылки.
+func Authenticate(auth *service.AuthService, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/swagger/") {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		var principal models.Principal
+		var err error
+		if userID, ok := calendarFeedUser(r); ok {
+			principal, err = auth.AuthenticateCalendarToken(userID, r.URL.Query().Get("token"))
+		} else if token, ok := bearerToken(r); ok {
+			principal, err = auth.AuthenticateBearer(r.Context(), token)
+		} else {
+			principal, err = auth.AuthenticateAPIKey(r.Context(), r.Header.Get(apiKeyHeader))
+		}
+		if errors.Is(err, service.ErrUnauthorized) {
+			logger.L().Warn("Запрос без действительных учётных данных", zap.String("path", r.URL.Path), zap.Error(err))
+			w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+			utils.Error(w, r, http.StatusUnauthorized, utils.CodeUnauthorized, utils.MsgUnauthenticated)
+			return
+		}
+		if err != nil {
+			logger.L().Error("Ошибка проверки API-ключа", zap.Error(err))
+			utils.InternalServerError(w, r)
+			return
+		}
+
+		ctx := service.WithPrincipal(r.Context(), principal)
+		ctx = repository.WithActor(ctx, principal.Subject)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// bearerToken возвращает токен из заголовка Authorization: Bearer
+func bearerToken(r *http.Request) (string, bool) {
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+	return strings.TrimSpace(token), true
+}
+
+// requireScope проверяет область доступа токена; при её отсутствии пишет ответ 403 и возвращает false
+func requireScope(w http.ResponseWriter, r *http.Request, scope string) bool {
+	if p, ok := service.PrincipalFromContext(r.Context()); ok && !p.HasScope(scope) {
+		w.Header().Set("WWW-Authenticate", `Bearer error="insufficient_scope", scope="`+scope+`"`)
+		utils.Error(w, r, http.StatusForbidden, utils.CodeInsufficientScope, utils.MsgInsufficientScope, scope)
+		return false
+	}
+	return true
+}
+
+// RequireAdmin пропускает только запросы с ключом администратора
+func RequireAdmin(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if p, ok := service.PrincipalFromContext(r.Context()); !ok || !p.IsAdmin() {
+			writeForbidden(w, r)
+			return
+		}
+		next(w, r)
+	}
+}
+
+// writeForbidden пишет ответ 403 на обращение к чужим данным или административным методам
+func writeForbidden(w http.ResponseWriter, r *http.Request) {
+	utils.Error(w, r, http.StatusForbidden, utils.CodeForbidden, utils.MsgForbidden)
+}
@@ -0,0 +1,198 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"usersubs/logger"
+	"usersubs/models"
+	"usersubs/service"
+	"usersubs/utils"
+	"usersubs/validation"
+
+	"go.uber.org/zap"
+)
+
+func (h *SubscriptionHandler) HandleBatch(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != http.MethodPost {
+		utils.MethodNotAllowed(w, r)
+		return
+	}
+	if requireScope(w, r, models.ScopeSubscriptionsWrite) {
+		h.idempotent(h.Batch)(w, r)
+	}
+}
+
+// @Summary Пакетное изменение подписок
+// @Description Выполняет до 100 операций create, update (JSON Merge Patch) и delete одним запросом.
+// @Description mode=atomic (по умолчанию) - все операции в одной транзакции: некорректная операция отклоняет весь запрос с 400,
+// @Description ошибка при выполнении отменяет пакет, остальные операции получают статус 424.
+// @Description mode=best_effort - операции выполняются независимо. Итог каждой операции - в results;
+// @Description ответ 200, если выполнены все операции, иначе 207.
+// @Tags Subscriptions
+// @Accept json
+// @Produce json
+// @Param batch body models.BatchInput true "Операции"
+// @Param Idempotency-Key header string false "Ключ идемпотентности (до 255 символов)"
+// @Success 200 {object} models.BatchResponse
+// @Success 207 {object} models.BatchResponse "Часть операций не выполнена"
+// @Failure 400 {object} utils.Problem
+// @Failure 403 {object} utils.Problem "Нет доступа к данным другого пользователя"
+// @Failure 500 {object} utils.Problem
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /subscriptions/batch [post]
+func (h *SubscriptionHandler) Batch(w http.ResponseWriter, r *http.Request) {
+	var input models.BatchInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		logger.L().Warn("Неправильное тело запроса", zap.Error(err))
+		utils.Error(w, r, http.StatusBadRequest, utils.CodeBadRequest, utils.MsgBadBody)
+		return
+	}
+	if input.Mode == "" {
+		input.Mode = models.BatchAtomic
+	}
+	if input.Mode != models.BatchAtomic && input.Mode != models.BatchBestEffort {
+		utils.InvalidField(w, r, "mode", utils.MsgAllowedValues, "mode", "atomic, best_effort")
+		return
+	}
+	if len(input.Operations) == 0 {
+		utils.InvalidField(w, r, "operations", utils.MsgRequiredField)
+		return
+	}
+	if len(input.Operations) > service.MaxBatchOperations {
+		utils.InvalidField(w, r, "operations", utils.MsgBatchTooLarge, service.MaxBatchOperations)
+		return
+	}
+
+	ops := make([]models.BatchOperation, len(input.Operations))
+	var invalid validation.Errors
+	var rejected error
+	for i, in := range input.Operations {
+		ops[i] = parseBatchOperation(r, in)
+		var errs validation.Errors
+		if errors.As(ops[i].Err, &errs) {
+			invalid = append(invalid, errs.WithPrefix(fmt.Sprintf("operations[%d]", i))...)
+		} else if ops[i].Err != nil && rejected == nil {
+			rejected = ops[i].Err
+		}
+	}
+	// Атомарный пакет выполняется, только если все операции корректны
+	if input.Mode == models.BatchAtomic {
+		if rejected != nil {
+			writeError(w, r, rejected)
+			return
+		}
+		if err := invalid.Err(); err != nil {
+			writeError(w, r, err)
+			return
+		}
+	}
+
+	results, err := h.Service.Batch(r.Context(), ops, input.Mode)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	lang := utils.Language(r)
+	response := models.BatchResponse{Mode: input.Mode, Results: results}
+	for i := range results {
+		writeBatchResult(r, &results[i], lang)
+		if results[i].Error == nil {
+			response.Succeeded++
+		} else {
+			response.Failed++
+		}
+	}
+	logger.L().Info("Пакет выполнен", zap.String("mode", input.Mode), zap.Int("succeeded", response.Succeeded), zap.Int("failed", response.Failed))
+
+	w.Header().Set("Content-Language", lang)
+	w.Header().Add("Vary", "Accept-Language")
+	if response.Failed > 0 {
+		w.WriteHeader(http.StatusMultiStatus)
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+// parseBatchOperation разбирает и проверяет операцию пакета; ошибка сохраняется в Err операции.
+// Пути полей в ошибках - относительно операции, например data.price.
+func parseBatchOperation(r *http.Request, in models.BatchOperationInput) models.BatchOperation {
+	op := models.BatchOperation{Op: in.Op, ID: in.ID}
+	if in.Version != nil {
+		op.IfMatch = []int{*in.Version}
+	}
+
+	var errs validation.Errors
+	switch in.Op {
+	case models.BatchCreate:
+		var input models.SubscriptionInput
+		err := json.Unmarshal(in.Data, &input)
+		switch {
+		case errors.Is(err, models.ErrInvalidMoney):
+			errs.Add("data.price", utils.MsgInvalidMoney, "price")
+		case err != nil:
+			errs.Add("data", utils.MsgExpectedObject)
+		}
+		if err != nil {
+			op.Err = errs.Err()
+			return op
+		}
+		// Ключу пользователя user_id подставляется автоматически
+		if input.UserID, err = service.ScopeUserID(r.Context(), input.UserID); err != nil {
+			op.Err = err
+			return op
+		}
+		op.Subscription, err = validation.Subscription(input)
+		if errors.As(err, &errs) {
+			errs = errs.WithPrefix("data")
+		}
+	case models.BatchUpdate:
+		if in.ID <= 0 {
+			errs.Add("id", utils.MsgInvalidRecordID)
+		}
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal(in.Data, &fields); err != nil || fields == nil {
+			errs.Add("data", utils.MsgExpectedObject)
+			break
+		}
+		var patchErrs validation.Errors
+		var err error
+		op.Update, err = parseMergePatch(fields)
+		if errors.As(err, &patchErrs) {
+			errs = append(errs, patchErrs.WithPrefix("data")...)
+		}
+	case models.BatchDelete:
+		if in.ID <= 0 {
+			errs.Add("id", utils.MsgInvalidRecordID)
+		}
+	case "":
+		errs.Add("op", utils.MsgRequiredField)
+	default:
+		errs.Add("op", utils.MsgAllowedValues, "op", "create, update, delete")
+	}
+	op.Err = errs.Err()
+	return op
+}
+
+// writeBatchResult заполняет статус и ошибку итога операции на языке lang
+func writeBatchResult(r *http.Request, result *models.BatchResult, lang string) {
+	if result.Err == nil {
+		result.Status = http.StatusOK
+		if result.Op == models.BatchDelete {
+			result.Status = http.StatusNoContent
+		}
+		return
+	}
+	p := problemFor(result.Err)
+	if p == nil {
+		logger.L().Error("Внутренняя ошибка операции пакета", zap.Int("index", result.Index), zap.Error(result.Err))
+		p = utils.NewProblem(http.StatusInternalServerError, utils.CodeInternal, "")
+	}
+	p.Instance = r.URL.Path
+	result.Status = p.Status
+	result.Error = p.Localize(lang)
+}
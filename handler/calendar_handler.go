@@ -0,0 +1,183 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+	"usersubs/ical"
+	"usersubs/logger"
+	"usersubs/models"
+	"usersubs/service"
+	"usersubs/utils"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// Календарь продлений: /users/{user_id}/renewals.ics
+	renewalsFeedName = "renewals.ics"
+	// Секретная ссылка на календарь: /users/{user_id}/calendar-token
+	calendarTokenName = "calendar-token"
+	calendarProdID    = "-//usersubs//Subscription renewals//RU"
+)
+
+type CalendarHandler struct {
+	Subscriptions *service.SubscriptionsService
+	Auth          *service.AuthService
+}
+
+func NewCalendarHandler(subs *service.SubscriptionsService, auth *service.AuthService) *CalendarHandler {
+	return &CalendarHandler{Subscriptions: subs, Auth: auth}
+}
+
+// splitUserPath разбирает путь /users/{user_id}/{resource}
+func splitUserPath(path string) (userID, resource string, ok bool) {
+	userID, resource, ok = strings.Cut(strings.TrimPrefix(path, "/users/"), "/")
+	if !ok || !strings.HasPrefix(path, "/users/") || userID == "" || strings.Contains(resource, "/") {
+		return "", "", false
+	}
+	return userID, resource, true
+}
+
+// calendarFeedUser возвращает user_id, если запрос обращается к календарю продлений по секретной ссылке
+func calendarFeedUser(r *http.Request) (string, bool) {
+	userID, resource, ok := splitUserPath(r.URL.Path)
+	if !ok || resource != renewalsFeedName || !r.URL.Query().Has("token") {
+		return "", false
+	}
+	return userID, utils.IsValidUUID(userID)
+}
+
+// HandleUsers обрабатывает /users/{user_id}/renewals.ics и /users/{user_id}/calendar-token
+func (h *CalendarHandler) HandleUsers(w http.ResponseWriter, r *http.Request) {
+	userID, resource, ok := splitUserPath(r.URL.Path)
+	if !ok || (resource != renewalsFeedName && resource != calendarTokenName) {
+		utils.NotFound(w, r)
+		return
+	}
+	if r.Method != http.MethodGet {
+		utils.MethodNotAllowed(w, r)
+		return
+	}
+	if !utils.IsValidUUID(userID) {
+		utils.InvalidField(w, r, "user_id", utils.MsgExpectedUUID)
+		return
+	}
+	if !requireScope(w, r, models.ScopeSubscriptionsRead) {
+		return
+	}
+	userID = strings.ToLower(userID)
+	if resource == calendarTokenName {
+		h.GetCalendarToken(w, r, userID)
+		return
+	}
+	h.GetRenewalsCalendar(w, r, userID)
+}
+
+// @Summary Календарь продлений подписок
+// @Description Календарь iCalendar (RFC 5545): по повторяющемуся событию на каждую действующую подписку пользователя.
+// @Description События повторяются с периодом списания от start_date до end_date; даты списаний, пропущенных из-за пауз, исключены.
+// @Description В описании события - сервис, стоимость и период списания. Без учётных данных календарь доступен
+// @Description по секретной ссылке с параметром token (GET /users/{user_id}/calendar-token).
+// @Tags Calendar
+// @Produce text/calendar
+// @Param user_id path string true "ID пользователя (UUID)"
+// @Param token query string false "Токен секретной ссылки; заменяет API-ключ и JWT"
+// @Success 200 {file} file "Календарь"
+// @Failure 400 {object} utils.Problem
+// @Failure 401 {object} utils.Problem "Недействительный токен ссылки"
+// @Failure 403 {object} utils.Problem "Нет доступа к данным другого пользователя"
+// @Failure 500 {object} utils.Problem
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /users/{user_id}/renewals.ics [get]
+func (h *CalendarHandler) GetRenewalsCalendar(w http.ResponseWriter, r *http.Request, userID string) {
+	lang := utils.Language(r)
+	stamp := time.Now().UTC()
+
+	var cal *ical.Writer
+	err := h.Subscriptions.Renewals(r.Context(), userID, func(sub models.Subscription) error {
+		if cal == nil {
+			cal = h.startCalendar(w, lang)
+		}
+		rule, exDates := service.RenewalRule(sub)
+		return cal.WriteEvent(ical.Event{
+			UID:         "subscription-" + strconv.Itoa(sub.ID) + "@usersubs",
+			Stamp:       stamp,
+			Sequence:    sub.Version,
+			Start:       sub.StartDate,
+			RRule:       rule,
+			ExDates:     exDates,
+			Summary:     utils.Translate(lang, utils.MsgRenewalSummary, sub.ServiceName),
+			Description: utils.Translate(lang, utils.MsgRenewalDescription, sub.ServiceName, sub.Price, sub.Currency, sub.BillingPeriod),
+		})
+	})
+	if err != nil && cal == nil {
+		writeError(w, r, err)
+		return
+	}
+	if cal == nil {
+		cal = h.startCalendar(w, lang)
+	}
+	if err == nil {
+		err = cal.Close()
+	}
+	if err != nil {
+		logger.L().Error("Календарь продлений прерван", zap.String("user_id", userID), zap.Error(err))
+	}
+}
+
+// startCalendar пишет заголовки ответа и начало календаря
+func (h *CalendarHandler) startCalendar(w http.ResponseWriter, lang string) *ical.Writer {
+	w.Header().Set("Content-Type", ical.ContentType)
+	w.Header().Set("Content-Language", lang)
+	w.Header().Set("Cache-Control", "private, no-cache")
+	w.Header().Add("Vary", "Accept-Language")
+	return ical.NewWriter(w, calendarProdID, utils.Translate(lang, utils.MsgCalendarName))
+}
+
+// @Summary Секретная ссылка на календарь продлений
+// @Description Возвращает ссылку на GET /users/{user_id}/renewals.ics с токеном, по которой календарные приложения
+// @Description подписываются на календарь без заголовков аутентификации. Токен даёт только чтение календаря
+// @Description этого пользователя; все ссылки отзываются сменой CALENDAR_TOKEN_SECRET.
+// @Tags Calendar
+// @Produce json
+// @Param user_id path string true "ID пользователя (UUID)"
+// @Success 200 {object} models.CalendarFeed
+// @Failure 400 {object} utils.Problem
+// @Failure 403 {object} utils.Problem "Нет доступа к данным другого пользователя"
+// @Failure 404 {object} utils.Problem "Ссылки на календарь не настроены"
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /users/{user_id}/calendar-token [get]
+func (h *CalendarHandler) GetCalendarToken(w http.ResponseWriter, r *http.Request, userID string) {
+	w.Header().Set("Content-Type", "application/json")
+
+	token, err := h.Auth.CalendarToken(r.Context(), userID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	feed := url.URL{
+		Scheme:   requestScheme(r),
+		Host:     r.Host,
+		Path:     "/users/" + userID + "/" + renewalsFeedName,
+		RawQuery: url.Values{"token": {token}}.Encode(),
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	json.NewEncoder(w).Encode(models.CalendarFeed{URL: feed.String(), Token: token})
+}
+
+// requestScheme возвращает схему, по которой клиент обратился к серверу (с учётом обратного прокси)
+func requestScheme(r *http.Request) string {
+	if proto := r.Header.Get("X-Forwarded-Proto"); proto == "https" || proto == "http" {
+		return proto
+	}
+	if r.TLS != nil {
+		return "https"
+	}
+	return "http"
+}
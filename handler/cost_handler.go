@@ -0,0 +1,216 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+	"usersubs/logger"
+	"usersubs/models"
+	"usersubs/service"
+	"usersubs/utils"
+
+	"go.uber.org/zap"
+)
+
+// parsePeriod разбирает обязательные параметры периода start_date и end_date (YYYY-MM-DD или MM-YYYY).
+// end_date в формате MM-YYYY включает месяц целиком.
+// При ошибке пишет ответ 400 и возвращает false.
+func parsePeriod(w http.ResponseWriter, r *http.Request) (time.Time, time.Time, bool) {
+	query := r.URL.Query()
+	startStr := query.Get("start_date")
+	endStr := query.Get("end_date")
+	if startStr == "" || endStr == "" {
+		logger.L().Warn("start_date, end_date обязательны")
+		problem := utils.NewProblem(http.StatusBadRequest, utils.CodeValidation, utils.MsgPeriodRequired)
+		if startStr == "" {
+			problem.WithField("start_date", utils.MsgRequiredParam)
+		}
+		if endStr == "" {
+			problem.WithField("end_date", utils.MsgRequiredParam)
+		}
+		utils.WriteProblem(w, r, problem)
+		return time.Time{}, time.Time{}, false
+	}
+
+	startDate, err := utils.ParseDate(startStr)
+	if err != nil {
+		logger.L().Error("Неверный формат start_date", zap.Error(err))
+		utils.InvalidField(w, r, "start_date", utils.MsgInvalidDate, "start_date")
+		return time.Time{}, time.Time{}, false
+	}
+	endDate, err := utils.ParseEndDate(endStr)
+	if err != nil {
+		logger.L().Error("Неверный формат end_date", zap.Error(err))
+		utils.InvalidField(w, r, "end_date", utils.MsgInvalidDate, "end_date")
+		return time.Time{}, time.Time{}, false
+	}
+
+	if endDate.Before(startDate) {
+		logger.L().Warn("Дата окончания не может быть раньше даты начала", zap.Time("start", startDate), zap.Time("end", endDate))
+		utils.Error(w, r, http.StatusBadRequest, utils.CodeBadRequest, utils.MsgEndBeforeStart)
+		return time.Time{}, time.Time{}, false
+	}
+	// Стоимость считается по месяцам, поэтому длина периода ограничена
+	if months := (endDate.Year()-startDate.Year())*12 + int(endDate.Month()-startDate.Month()) + 1; months > service.MaxCostPeriodMonths {
+		logger.L().Warn("Слишком длинный период", zap.Time("start", startDate), zap.Time("end", endDate))
+		utils.InvalidField(w, r, "end_date", utils.MsgPeriodTooLong, service.MaxCostPeriodMonths)
+		return time.Time{}, time.Time{}, false
+	}
+	return startDate, endDate, true
+}
+
+// parseCostQuery разбирает общие параметры подсчёта стоимости: период, режим и валюту.
+// При ошибке пишет ответ 400 и возвращает false.
+func parseCostQuery(w http.ResponseWriter, r *http.Request) (models.CostQuery, bool) {
+	query := r.URL.Query()
+	startDate, endDate, ok := parsePeriod(w, r)
+	if !ok {
+		return models.CostQuery{}, false
+	}
+	q := models.CostQuery{
+		UserID:      query.Get("user_id"),
+		ServiceName: query.Get("service_name"),
+		From:        startDate,
+		To:          endDate,
+		Mode:        query.Get("mode"),
+		Currency:    strings.ToUpper(query.Get("currency")),
+	}
+
+	switch q.Mode {
+	case "":
+		q.Mode = models.CostModeCharges
+	case models.CostModeCharges, models.CostModeMonthlyEquivalent:
+	default:
+		utils.InvalidField(w, r, "mode", utils.MsgAllowedValues, "mode", "charges, monthly_equivalent")
+		return models.CostQuery{}, false
+	}
+
+	switch q.Proration = query.Get("proration"); q.Proration {
+	case "":
+		q.Proration = models.ProrationNone
+	case models.ProrationNone, models.ProrationDaily:
+	default:
+		utils.InvalidField(w, r, "proration", utils.MsgAllowedValues, "proration", "none, daily")
+		return models.CostQuery{}, false
+	}
+	if q.Proration == models.ProrationDaily && q.Mode != models.CostModeCharges {
+		utils.Error(w, r, http.StatusBadRequest, utils.CodeBadRequest, utils.MsgProrationCharges)
+		return models.CostQuery{}, false
+	}
+
+	if q.IncludeDeleted, ok = parseIncludeDeleted(w, r); !ok {
+		return models.CostQuery{}, false
+	}
+
+	if q.Currency == "" {
+		q.Currency = models.BaseCurrency
+	}
+	if !models.IsValidCurrency(q.Currency) {
+		utils.InvalidField(w, r, "currency", utils.MsgInvalidCurrency, "currency")
+		return models.CostQuery{}, false
+	}
+	return q, true
+}
+
+// parseBreakdownQuery разбирает параметры помесячной разбивки: общие параметры стоимости и group_by.
+// При ошибке пишет ответ 400 и возвращает false.
+func parseBreakdownQuery(w http.ResponseWriter, r *http.Request) (models.CostQuery, bool) {
+	q, ok := parseCostQuery(w, r)
+	if !ok {
+		return models.CostQuery{}, false
+	}
+	q.GroupBy = r.URL.Query().Get("group_by")
+	switch q.GroupBy {
+	case "", models.GroupByServiceName, models.GroupByUserID:
+	default:
+		utils.InvalidField(w, r, "group_by", utils.MsgAllowedValues, "group_by", "service_name, user_id")
+		return models.CostQuery{}, false
+	}
+	return q, true
+}
+
+// @Summary Получение суммарной стоимости подписок
+// @Description Возвращает суммарную стоимость подписок пользователя за указанный период с фильтрацией по названию сервиса.
+// @Description В режиме charges учитываются фактические списания, попавшие в период (годовая подписка - один раз в месяце списания),
+// @Description в режиме monthly_equivalent - цена, приведённая к месяцу, за каждый активный месяц.
+// @Description Стоимость в других валютах пересчитывается в currency по курсу каждого месяца.
+// @Description С proration=daily неполные периоды списания учитываются пропорционально числу активных дней.
+// @Tags Subscriptions
+// @Accept  json
+// @Produce  json
+// @Param user_id query string false "ID пользователя (UUID)"
+// @Param service_name query string false "Название сервиса (опционально)"
+// @Param start_date query string true "Дата начала периода (в формате YYYY-MM-DD или MM-YYYY)"
+// @Param end_date query string true "Дата окончания периода включительно (в формате YYYY-MM-DD или MM-YYYY); период не длиннее 120 месяцев"
+// @Param mode query string false "Режим подсчёта (по умолчанию charges)" Enums(charges, monthly_equivalent)
+// @Param proration query string false "Пропорциональный учёт неполных периодов по дням (по умолчанию none)" Enums(none, daily)
+// @Param currency query string false "Валюта результата (по умолчанию RUB)"
+// @Param include_deleted query bool false "Учитывать мягко удалённые подписки"
+// @Success 200 {object} models.TotalCost "Суммарная стоимость, например {\"total_cost\": 900, \"currency\": \"RUB\"}"
+// @Failure 400 {object} utils.Problem "Ошибка валидации"
+// @Failure 422 {object} utils.Problem "Нет курса валюты на один из месяцев"
+// @Failure 500 {object} utils.Problem "Ошибка сервера"
+// @Failure 403 {object} utils.Problem "Нет доступа к данным другого пользователя"
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /subscriptions/total-cost [get]
+func (h *SubscriptionHandler) GetTotalCost(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if !requireScope(w, r, models.ScopeSubscriptionsRead) {
+		return
+	}
+
+	q, ok := parseCostQuery(w, r)
+	if !ok {
+		return
+	}
+
+	total, err := h.Service.GetTotalCost(r.Context(), q)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	json.NewEncoder(w).Encode(total)
+}
+
+// @Summary Помесячная разбивка стоимости подписок
+// @Description Возвращает стоимость подписок по каждому месяцу периода, опционально с группировкой по сервису или пользователю
+// @Tags Subscriptions
+// @Produce json
+// @Param user_id query string false "ID пользователя (UUID)"
+// @Param service_name query string false "Название сервиса (опционально)"
+// @Param start_date query string true "Дата начала периода (в формате YYYY-MM-DD или MM-YYYY)"
+// @Param end_date query string true "Дата окончания периода включительно (в формате YYYY-MM-DD или MM-YYYY); период не длиннее 120 месяцев"
+// @Param group_by query string false "Группировка внутри месяца" Enums(service_name, user_id)
+// @Param mode query string false "Режим подсчёта (по умолчанию charges)" Enums(charges, monthly_equivalent)
+// @Param proration query string false "Пропорциональный учёт неполных периодов по дням (по умолчанию none)" Enums(none, daily)
+// @Param currency query string false "Валюта результата (по умолчанию RUB)"
+// @Param include_deleted query bool false "Учитывать мягко удалённые подписки"
+// @Success 200 {object} models.CostBreakdown
+// @Failure 400 {object} utils.Problem "Ошибка валидации"
+// @Failure 422 {object} utils.Problem "Нет курса валюты на один из месяцев"
+// @Failure 500 {object} utils.Problem "Ошибка сервера"
+// @Failure 403 {object} utils.Problem "Нет доступа к данным другого пользователя"
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /subscriptions/cost-breakdown [get]
+func (h *SubscriptionHandler) GetCostBreakdown(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if !requireScope(w, r, models.ScopeSubscriptionsRead) {
+		return
+	}
+
+	q, ok := parseBreakdownQuery(w, r)
+	if !ok {
+		return
+	}
+
+	breakdown, err := h.Service.GetCostBreakdown(r.Context(), q)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	json.NewEncoder(w).Encode(breakdown)
+}
@@ -0,0 +1,79 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"usersubs/logger"
+	"usersubs/repository"
+	"usersubs/service"
+	"usersubs/utils"
+	"usersubs/validation"
+
+	"go.uber.org/zap"
+)
+
+// problemFor переводит ошибку сервиса или хранилища в ответ problem+json; nil - внутренняя ошибка
+func problemFor(err error) *utils.Problem {
+	var missingRate *service.MissingRateError
+	var invalid validation.Errors
+	switch {
+	case errors.As(err, &invalid):
+		return utils.NewProblem(http.StatusBadRequest, utils.CodeValidation, utils.MsgInvalidFields).WithFieldErrors(invalid...)
+	case errors.Is(err, service.ErrForbidden):
+		return utils.NewProblem(http.StatusForbidden, utils.CodeForbidden, utils.MsgForbidden)
+	case errors.Is(err, repository.ErrNotFound):
+		// Сервис может уточнить, что именно не найдено (например, API-ключ)
+		return utils.NewProblemMessage(http.StatusNotFound, utils.CodeNotFound, errorMessage(err, utils.MsgRecordNotFound))
+	case errors.Is(err, repository.ErrDuplicate):
+		return utils.NewProblem(http.StatusConflict, utils.CodeDuplicate, utils.MsgDuplicate)
+	case errors.Is(err, repository.ErrNotDeleted):
+		return utils.NewProblem(http.StatusConflict, utils.CodeConflict, utils.MsgNotDeleted)
+	case errors.Is(err, service.ErrPreconditionFailed):
+		return utils.NewProblem(http.StatusPreconditionFailed, utils.CodePreconditionFailed, utils.MsgPreconditionFailed)
+	case errors.Is(err, service.ErrInvalidTransition):
+		return utils.NewProblemMessage(http.StatusConflict, utils.CodeInvalidTransition, errorMessage(err, "title."+utils.CodeInvalidTransition))
+	case errors.Is(err, service.ErrIdempotencyMismatch):
+		return utils.NewProblem(http.StatusUnprocessableEntity, utils.CodeIdempotencyMismatch, utils.MsgIdempotencyReused)
+	case errors.Is(err, service.ErrIdempotencyInProgress):
+		return utils.NewProblem(http.StatusConflict, utils.CodeIdempotencyInProgress, utils.MsgIdempotencyPending)
+	case errors.Is(err, service.ErrInvalidCursor):
+		return utils.NewProblem(http.StatusBadRequest, utils.CodeValidation, utils.MsgInvalidCursor)
+	case errors.Is(err, service.ErrInvalidIdempotencyKey):
+		return utils.NewProblem(http.StatusBadRequest, utils.CodeValidation, utils.MsgIdempotencyKey)
+	case errors.Is(err, service.ErrCalendarDisabled):
+		return utils.NewProblem(http.StatusNotFound, utils.CodeNotFound, utils.MsgCalendarDisabled)
+	case errors.Is(err, service.ErrBatchAborted):
+		return utils.NewProblemMessage(http.StatusFailedDependency, utils.CodeBatchAborted, errorMessage(err, "title."+utils.CodeBatchAborted))
+	case errors.As(err, &missingRate):
+		return utils.NewProblem(http.StatusUnprocessableEntity, utils.CodeExchangeRateMissing, utils.MsgMissingRate, missingRate.Currency, missingRate.Month.Format("01-2006"))
+	case errors.Is(err, service.ErrInvalidLifecycleDate),
+		errors.Is(err, service.ErrInvalidPriceChange),
+		errors.Is(err, service.ErrInvalidAPIKey),
+		errors.Is(err, service.ErrInvalidRatesFile),
+		errors.Is(err, service.ErrInvalidImportFile),
+		errors.Is(err, service.ErrInvalidImportRow):
+		return utils.NewProblemMessage(http.StatusBadRequest, utils.CodeValidation, errorMessage(err, "title."+utils.CodeValidation))
+	}
+	return utils.DatabaseProblem(err)
+}
+
+// writeError пишет ответ на ошибку сервиса или хранилища. Текст внутренних ошибок в ответ не попадает.
+func writeError(w http.ResponseWriter, r *http.Request, err error) {
+	p := problemFor(err)
+	if p == nil {
+		logger.L().Error("Внутренняя ошибка", zap.String("path", r.URL.Path), zap.Error(err))
+		utils.InternalServerError(w, r)
+		return
+	}
+	logger.L().Warn("Запрос отклонён", zap.String("path", r.URL.Path), zap.String("code", p.Code), zap.Error(err))
+	utils.WriteProblem(w, r, p)
+}
+
+// errorMessage возвращает локализуемое сообщение ошибки; без него - сообщение каталога fallback.
+// Текст самой ошибки в ответ не попадает: он не переведён и может содержать подробности реализации.
+func errorMessage(err error, fallback string) utils.Message {
+	if msg, ok := utils.MessageOf(err); ok {
+		return msg
+	}
+	return utils.NewMessage(fallback)
+}
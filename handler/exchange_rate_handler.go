@@ -0,0 +1,125 @@
+package handler
+
+import (
+	"encoding/json"
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+	"usersubs/logger"
+	"usersubs/models"
+	"usersubs/service"
+	"usersubs/utils"
+
+	"go.uber.org/zap"
+)
+
+// Максимальный размер загружаемого файла курсов
+const maxRatesFileSize = 10 << 20
+
+type ExchangeRateHandler struct {
+	Service *service.ExchangeRateService
+}
+
+func NewExchangeRateHandler(s *service.ExchangeRateService) *ExchangeRateHandler {
+	return &ExchangeRateHandler{Service: s}
+}
+
+func (h *ExchangeRateHandler) HandleExchangeRates(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	switch r.Method {
+	case http.MethodGet:
+		h.ListRates(w, r)
+	case http.MethodPost:
+		h.ImportRates(w, r)
+	default:
+		utils.MethodNotAllowed(w, r)
+	}
+}
+
+// @Summary Загрузить курсы валют
+// @Description Загружает таблицу курсов валют к рублю из CSV (колонки date,currency,rate[,nominal]) или XML в формате ЦБ РФ.
+// @Description Формат определяется параметром format или заголовком Content-Type. Курс на ту же дату перезаписывается.
+// @Tags Exchange rates
+// @Accept plain
+// @Produce json
+// @Param format query string false "Формат файла" Enums(csv, cbr)
+// @Param file body string true "Содержимое файла курсов"
+// @Success 200 {object} models.RatesImportResult
+// @Failure 400 {object} utils.Problem
+// @Failure 500 {object} utils.Problem
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /admin/exchange-rates [post]
+func (h *ExchangeRateHandler) ImportRates(w http.ResponseWriter, r *http.Request) {
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = ratesFormatFromContentType(r.Header.Get("Content-Type"))
+	}
+	if format == "" {
+		utils.InvalidField(w, r, "format", utils.MsgRatesFormat)
+		return
+	}
+
+	body := http.MaxBytesReader(w, r.Body, maxRatesFileSize)
+	result, err := h.Service.Import(r.Context(), format, body)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	logger.L().Info("Загружены курсы валют", zap.Int("count", result.Imported), zap.String("format", format))
+	json.NewEncoder(w).Encode(result)
+}
+
+// ratesFormatFromContentType определяет формат файла курсов по Content-Type
+func ratesFormatFromContentType(contentType string) string {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch {
+	case mediaType == "text/csv":
+		return service.RatesFormatCSV
+	case strings.HasSuffix(mediaType, "/xml"):
+		return service.RatesFormatCBR
+	}
+	return ""
+}
+
+// @Summary Получить курсы валюты
+// @Description Возвращает курсы валюты к рублю, действующие не позже указанной даты
+// @Tags Exchange rates
+// @Produce json
+// @Param currency query string true "Код валюты ISO 4217"
+// @Param until query string false "Дата YYYY-MM-DD (по умолчанию сегодня)"
+// @Success 200 {array} models.ExchangeRate
+// @Failure 400 {object} utils.Problem
+// @Failure 500 {object} utils.Problem
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /admin/exchange-rates [get]
+func (h *ExchangeRateHandler) ListRates(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	currency := strings.ToUpper(query.Get("currency"))
+	if !models.IsCurrencyCode(currency) {
+		utils.InvalidField(w, r, "currency", utils.MsgCurrencyCode, "currency")
+		return
+	}
+	until := time.Now().UTC()
+	if untilStr := query.Get("until"); untilStr != "" {
+		t, err := time.Parse("2006-01-02", untilStr)
+		if err != nil {
+			utils.InvalidField(w, r, "until", utils.MsgInvalidDay, "until")
+			return
+		}
+		until = t
+	}
+
+	rates, err := h.Service.ListRates(r.Context(), currency, until)
+	if err != nil {
+		logger.L().Error("Не удалось получить курсы", zap.Error(err))
+		utils.InternalServerError(w, r)
+		return
+	}
+	json.NewEncoder(w).Encode(rates)
+}
@@ -0,0 +1,231 @@
+package handler
+
+import (
+	"maps"
+	"mime"
+	"net/http"
+	"slices"
+	"time"
+	"usersubs/export"
+	"usersubs/logger"
+	"usersubs/models"
+	"usersubs/utils"
+
+	"go.uber.org/zap"
+)
+
+// exportFormat выбирает формат выгрузки: параметр format, иначе заголовок Accept (по умолчанию CSV).
+// При ошибке пишет ответ 400 или 406 и возвращает false.
+func exportFormat(w http.ResponseWriter, r *http.Request) (string, bool) {
+	if format := r.URL.Query().Get("format"); format != "" {
+		if !export.IsValidFormat(format) {
+			utils.InvalidField(w, r, "format", utils.MsgAllowedValues, "format", "csv, xlsx, json")
+			return "", false
+		}
+		return format, true
+	}
+	format := export.Negotiate(r.Header.Get("Accept"))
+	if format == "" {
+		utils.Error(w, r, http.StatusNotAcceptable, utils.CodeNotAcceptable, utils.MsgExportFormat)
+		return "", false
+	}
+	return format, true
+}
+
+// exportResponse - ответ-выгрузка. Заголовки и первая строка пишутся при первой строке данных:
+// до этого ошибку ещё можно вернуть обычным ответом problem+json.
+type exportResponse struct {
+	w        http.ResponseWriter
+	format   string
+	filename string // Имя файла без расширения
+	columns  []string
+	out      export.Writer
+}
+
+func (e *exportResponse) start() error {
+	if e.out != nil {
+		return nil
+	}
+	header := e.w.Header()
+	header.Set("Content-Type", export.ContentType(e.format))
+	header.Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": e.filename + "." + e.format}))
+	header.Add("Vary", "Accept")
+	out, err := export.NewWriter(e.format, e.w, e.columns)
+	e.out = out
+	return err
+}
+
+func (e *exportResponse) row(values ...any) error {
+	if err := e.start(); err != nil {
+		return err
+	}
+	return e.out.WriteRow(values...)
+}
+
+// finish завершает выгрузку; err - ошибка получения данных.
+// После начала ответа статус уже не изменить: выгрузка обрывается, и ошибка только записывается в лог.
+func (e *exportResponse) finish(r *http.Request, err error) {
+	if err != nil && e.out == nil {
+		writeError(e.w, r, err)
+		return
+	}
+	if err == nil {
+		if err = e.start(); err == nil {
+			err = e.out.Close()
+		}
+	}
+	if err != nil {
+		logger.L().Error("Выгрузка прервана", zap.String("path", r.URL.Path), zap.Error(err))
+	}
+}
+
+// exportDate форматирует дату для выгрузки; nil - пустая ячейка
+func exportDate(t *time.Time) any {
+	if t == nil {
+		return nil
+	}
+	return t.Format("2006-01-02")
+}
+
+// @Summary Выгрузить подписки
+// @Description Выгружает подписки по тем же фильтрам и сортировке, что и GET /subscriptions, без разбиения на страницы.
+// @Description Формат задаётся параметром format или заголовком Accept (по умолчанию CSV). Строки передаются потоком.
+// @Tags Subscriptions
+// @Produce text/csv
+// @Produce json
+// @Produce application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Param format query string false "Формат файла" Enums(csv, xlsx, json)
+// @Param user_id query string false "ID пользователя (UUID)"
+// @Param service_name query string false "Название сервиса (точное совпадение)"
+// @Param service_name_contains query string false "Подстрока названия сервиса (без учёта регистра)"
+// @Param from query string false "Подписка активна с даты (YYYY-MM-DD или MM-YYYY)"
+// @Param to query string false "Подписка активна по дату включительно (YYYY-MM-DD или MM-YYYY - до конца месяца)"
+// @Param price_min query string false "Минимальная цена, например 99.90"
+// @Param price_max query string false "Максимальная цена, например 999.00"
+// @Param sort query string false "Поле сортировки" Enums(id, price, start_date, service_name)
+// @Param order query string false "Направление сортировки" Enums(asc, desc)
+// @Param include_deleted query bool false "Включать мягко удалённые подписки (только администратор)"
+// @Success 200 {file} file "Файл выгрузки"
+// @Header 200 {string} Content-Disposition "attachment; filename=subscriptions-YYYY-MM-DD.csv"
+// @Failure 400 {object} utils.Problem
+// @Failure 403 {object} utils.Problem "Нет доступа к данным другого пользователя"
+// @Failure 406 {object} utils.Problem "Accept не допускает ни один из форматов выгрузки"
+// @Failure 500 {object} utils.Problem
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /subscriptions/export [get]
+func (h *SubscriptionHandler) ExportSubscriptions(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		utils.MethodNotAllowed(w, r)
+		return
+	}
+	if !requireScope(w, r, models.ScopeSubscriptionsRead) {
+		return
+	}
+	format, ok := exportFormat(w, r)
+	if !ok {
+		return
+	}
+	filter, ok := parseSubscriptionFilter(w, r)
+	if !ok {
+		return
+	}
+
+	resp := &exportResponse{
+		w:        w,
+		format:   format,
+		filename: "subscriptions-" + time.Now().UTC().Format("2006-01-02"),
+		columns: []string{"id", "service_name", "price", "currency", "billing_period", "user_id",
+			"start_date", "end_date", "status", "version", "deleted_at"},
+	}
+	err := h.Service.ExportSubscriptions(r.Context(), filter, func(sub models.Subscription) error {
+		return resp.row(sub.ID, sub.ServiceName, sub.Price, sub.Currency, sub.BillingPeriod, sub.UserID,
+			exportDate(&sub.StartDate), exportDate(sub.EndDate), sub.Status, sub.Version, exportDate(sub.DeletedAt))
+	})
+	resp.finish(r, err)
+}
+
+// @Summary Выгрузить помесячную разбивку стоимости
+// @Description Выгружает разбивку стоимости с теми же параметрами, что и GET /subscriptions/cost-breakdown:
+// @Description строка на месяц (или на месяц и значение group_by) и итоговая строка total.
+// @Description Формат задаётся параметром format или заголовком Accept (по умолчанию CSV).
+// @Tags Subscriptions
+// @Produce text/csv
+// @Produce json
+// @Produce application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Param format query string false "Формат файла" Enums(csv, xlsx, json)
+// @Param user_id query string false "ID пользователя (UUID)"
+// @Param service_name query string false "Название сервиса (опционально)"
+// @Param start_date query string true "Дата начала периода (в формате YYYY-MM-DD или MM-YYYY)"
+// @Param end_date query string true "Дата окончания периода включительно (в формате YYYY-MM-DD или MM-YYYY)"
+// @Param group_by query string false "Группировка внутри месяца" Enums(service_name, user_id)
+// @Param mode query string false "Режим подсчёта (по умолчанию charges)" Enums(charges, monthly_equivalent)
+// @Param proration query string false "Пропорциональный учёт неполных периодов по дням (по умолчанию none)" Enums(none, daily)
+// @Param currency query string false "Валюта результата (по умолчанию RUB)"
+// @Param include_deleted query bool false "Учитывать мягко удалённые подписки"
+// @Success 200 {file} file "Файл выгрузки"
+// @Header 200 {string} Content-Disposition "attachment; filename=cost-breakdown-YYYY-MM-DD-YYYY-MM-DD.csv"
+// @Failure 400 {object} utils.Problem "Ошибка валидации"
+// @Failure 403 {object} utils.Problem "Нет доступа к данным другого пользователя"
+// @Failure 406 {object} utils.Problem "Accept не допускает ни один из форматов выгрузки"
+// @Failure 422 {object} utils.Problem "Нет курса валюты на один из месяцев"
+// @Failure 500 {object} utils.Problem "Ошибка сервера"
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /subscriptions/cost-breakdown/export [get]
+func (h *SubscriptionHandler) ExportCostBreakdown(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		utils.MethodNotAllowed(w, r)
+		return
+	}
+	if !requireScope(w, r, models.ScopeSubscriptionsRead) {
+		return
+	}
+	format, ok := exportFormat(w, r)
+	if !ok {
+		return
+	}
+	q, ok := parseBreakdownQuery(w, r)
+	if !ok {
+		return
+	}
+
+	breakdown, err := h.Service.GetCostBreakdown(r.Context(), q)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	resp := &exportResponse{
+		w:        w,
+		format:   format,
+		filename: "cost-breakdown-" + q.From.Format("2006-01-02") + "-" + q.To.Format("2006-01-02"),
+		columns:  []string{"month", "total", "currency"},
+	}
+	if q.GroupBy != "" {
+		resp.columns = []string{"month", q.GroupBy, "total", "currency"}
+	}
+	err = writeCostRows(resp, breakdown)
+	resp.finish(r, err)
+}
+
+// writeCostRows пишет строки разбивки: по месяцу или по месяцу и группе, затем итог
+func writeCostRows(resp *exportResponse, breakdown models.CostBreakdown) error {
+	for _, month := range breakdown.Months {
+		if breakdown.GroupBy == "" {
+			if err := resp.row(month.Month, month.Total, breakdown.Currency); err != nil {
+				return err
+			}
+			continue
+		}
+		for _, group := range slices.Sorted(maps.Keys(month.Groups)) {
+			if err := resp.row(month.Month, group, month.Groups[group], breakdown.Currency); err != nil {
+				return err
+			}
+		}
+	}
+	if breakdown.GroupBy == "" {
+		return resp.row("total", breakdown.Total, breakdown.Currency)
+	}
+	return resp.row("total", nil, breakdown.Total, breakdown.Currency)
+}
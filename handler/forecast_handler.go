@@ -0,0 +1,117 @@
+package handler
+
+import (
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"usersubs/models"
+	"usersubs/service"
+	"usersubs/utils"
+)
+
+// Длина прогноза по умолчанию
+const defaultForecastMonths = 12
+
+// Годовой рост цен в процентах: неотрицательное число с дробной частью через точку
+var percentPattern = regexp.MustCompile(`^\d{1,3}(\.\d{1,4})?$`)
+
+// parsePercent разбирает годовой рост цен в процентах (от 0 до 100) в долю: "7.5" -> 0.075
+func parsePercent(s string) (*big.Rat, bool) {
+	if !percentPattern.MatchString(s) {
+		return nil, false
+	}
+	rate, ok := new(big.Rat).SetString(s)
+	if !ok || rate.Cmp(big.NewRat(100, 1)) > 0 {
+		return nil, false
+	}
+	return rate.Quo(rate, big.NewRat(100, 1)), true
+}
+
+// @Summary Прогноз расходов на подписки
+// @Description Прогнозирует помесячные расходы на months месяцев начиная с текущего: списания по периоду подписки
+// @Description с учётом end_date, пауз и запланированных изменений цены. inflation задаёт годовой рост цен в процентах
+// @Description для всех сервисов, service_inflation=<service_name>:<процент> (можно повторять) - для отдельного сервиса.
+// @Description Рост применяется к цене, действующей на дату списания, за каждый полный год от начала прогноза
+// @Description (или от месяца изменения цены, если оно позже). Возвращаются суммы по месяцам, накопленный итог и общий итог.
+// @Tags Subscriptions
+// @Produce json
+// @Param user_id query string false "ID пользователя (UUID)"
+// @Param service_name query string false "Название сервиса (опционально)"
+// @Param months query int false "Число месяцев прогноза (по умолчанию 12, не больше 120)"
+// @Param currency query string false "Валюта результата (по умолчанию RUB)"
+// @Param inflation query string false "Годовой рост цен всех сервисов в процентах, например 7.5"
+// @Param service_inflation query []string false "Годовой рост цен сервиса: <service_name>:<процент>" collectionFormat(multi)
+// @Success 200 {object} models.Forecast
+// @Failure 400 {object} utils.Problem "Ошибка валидации"
+// @Failure 403 {object} utils.Problem "Нет доступа к данным другого пользователя"
+// @Failure 422 {object} utils.Problem "Нет курса валюты"
+// @Failure 500 {object} utils.Problem "Ошибка сервера"
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /subscriptions/forecast [get]
+func (h *SubscriptionHandler) GetForecast(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if r.Method != http.MethodGet {
+		utils.MethodNotAllowed(w, r)
+		return
+	}
+	if !requireScope(w, r, models.ScopeSubscriptionsRead) {
+		return
+	}
+
+	query := r.URL.Query()
+	q := models.ForecastQuery{
+		UserID:      query.Get("user_id"),
+		ServiceName: query.Get("service_name"),
+		Months:      defaultForecastMonths,
+		Currency:    strings.ToUpper(query.Get("currency")),
+		Inflation:   make(map[string]*big.Rat),
+	}
+	if s := query.Get("months"); s != "" {
+		months, err := strconv.Atoi(s)
+		if err != nil || months < 1 || months > service.MaxForecastMonths {
+			utils.InvalidField(w, r, "months", utils.MsgIntegerRange, "months", 1, service.MaxForecastMonths)
+			return
+		}
+		q.Months = months
+	}
+	if q.Currency == "" {
+		q.Currency = models.BaseCurrency
+	}
+	if !models.IsValidCurrency(q.Currency) {
+		utils.InvalidField(w, r, "currency", utils.MsgInvalidCurrency, "currency")
+		return
+	}
+	if s := query.Get("inflation"); s != "" {
+		rate, ok := parsePercent(s)
+		if !ok {
+			utils.InvalidField(w, r, "inflation", utils.MsgInvalidPercent, "inflation")
+			return
+		}
+		q.DefaultInflation = rate
+	}
+	for _, s := range query["service_inflation"] {
+		// Название сервиса может содержать двоеточие, поэтому процент - после последнего
+		i := strings.LastIndex(s, ":")
+		if i <= 0 {
+			utils.InvalidField(w, r, "service_inflation", utils.MsgServiceInflation)
+			return
+		}
+		rate, ok := parsePercent(strings.TrimSpace(s[i+1:]))
+		if !ok {
+			utils.InvalidField(w, r, "service_inflation", utils.MsgInvalidPercent, "service_inflation")
+			return
+		}
+		q.Inflation[strings.ToLower(strings.TrimSpace(s[:i]))] = rate
+	}
+
+	forecast, err := h.Service.GetForecast(r.Context(), q)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	json.NewEncoder(w).Encode(forecast)
+}
@@ -0,0 +1,106 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"usersubs/logger"
+	"usersubs/service"
+	"usersubs/utils"
+
+	"go.uber.org/zap"
+)
+
+const (
+	idempotencyKeyHeader = "Idempotency-Key"
+	// Заголовок ответа, повторённого по Idempotency-Key
+	idempotentReplayedHeader = "Idempotent-Replayed"
+	// Максимальный размер тела запроса с Idempotency-Key
+	maxIdempotentBodySize = 1 << 20
+)
+
+// responseRecorder передаёт ответ клиенту и одновременно запоминает его для сохранения
+type responseRecorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (rec *responseRecorder) WriteHeader(status int) {
+	if rec.status == 0 {
+		rec.status = status
+	}
+	rec.ResponseWriter.WriteHeader(status)
+}
+
+func (rec *responseRecorder) Write(b []byte) (int, error) {
+	if rec.status == 0 {
+		rec.status = http.StatusOK
+	}
+	rec.body.Write(b)
+	return rec.ResponseWriter.Write(b)
+}
+
+// idempotent выполняет next не более одного раза для каждого значения заголовка Idempotency-Key
+// (в пределах пользователя и TTL); повторы получают сохранённый ответ. Без заголовка next вызывается как есть.
+func (h *SubscriptionHandler) idempotent(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(idempotencyKeyHeader)
+		if key == "" || h.Idempotency == nil {
+			next(w, r)
+			return
+		}
+
+		body, err := io.ReadAll(io.LimitReader(r.Body, maxIdempotentBodySize+1))
+		if err != nil || len(body) > maxIdempotentBodySize {
+			utils.Error(w, r, http.StatusBadRequest, utils.CodeBadRequest, utils.MsgBadBody)
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		stored, replay, err := h.Idempotency.Begin(r.Context(), key, service.HashRequest(body))
+		switch {
+		case errors.Is(err, service.ErrInvalidIdempotencyKey):
+			utils.InvalidField(w, r, idempotencyKeyHeader, utils.MsgIdempotencyKey)
+			return
+		case err != nil:
+			if errors.Is(err, service.ErrIdempotencyInProgress) {
+				w.Header().Set("Retry-After", "1")
+			}
+			writeError(w, r, err)
+			return
+		case replay:
+			logger.L().Info("Повтор запроса по Idempotency-Key", zap.String("key", key))
+			if stored.ContentType != "" {
+				w.Header().Set("Content-Type", stored.ContentType)
+			}
+			w.Header().Set(idempotentReplayedHeader, "true")
+			w.WriteHeader(stored.StatusCode)
+			w.Write(stored.Body)
+			return
+		}
+
+		// Если обработчик упал, ключ освобождается: иначе повторы получали бы 409 до истечения TTL
+		defer func() {
+			if p := recover(); p != nil {
+				if err := h.Idempotency.Release(context.WithoutCancel(r.Context()), stored); err != nil {
+					logger.L().Error("Не удалось освободить Idempotency-Key", zap.String("key", key), zap.Error(err))
+				}
+				panic(p)
+			}
+		}()
+
+		rec := &responseRecorder{ResponseWriter: w}
+		next(rec, r)
+		if rec.status == 0 {
+			rec.status = http.StatusOK
+		}
+		// Ответ уже отправлен клиенту, поэтому ошибка сохранения только логируется
+		err = h.Idempotency.Complete(r.Context(), stored, rec.status, w.Header().Get("Content-Type"), rec.body.Bytes())
+		if err != nil {
+			logger.L().Error("Не удалось сохранить ответ для Idempotency-Key", zap.String("key", key), zap.Error(err))
+		}
+	}
+}
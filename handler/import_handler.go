@@ -0,0 +1,212 @@
+package handler
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"mime"
+	"net/http"
+	"strconv"
+	"usersubs/logger"
+	"usersubs/models"
+	"usersubs/utils"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// Максимальный размер файла импорта подписок
+	maxImportFileSize = 100 << 20
+	// Максимальное число строк в отчёте импорта
+	maxImportResults = 10000
+	// Статус строки CSV-отчёта с ошибкой, прервавшей импорт
+	importAborted = "aborted"
+)
+
+func (h *SubscriptionHandler) HandleImport(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != http.MethodPost {
+		utils.MethodNotAllowed(w, r)
+		return
+	}
+	if requireScope(w, r, models.ScopeSubscriptionsWrite) {
+		h.ImportSubscriptions(w, r)
+	}
+}
+
+// @Summary Импортировать подписки из файла
+// @Description Загружает подписки из CSV (заголовок service_name,price,currency,billing_period,user_id,start_date,end_date)
+// @Description или JSON Lines (по объекту подписки в строке). Файл читается построчно, каждая строка проверяется и сохраняется отдельно.
+// @Description Формат определяется параметром format или заголовком Content-Type. dry_run=true только проверяет файл.
+// @Description on_conflict задаёт поведение при совпадении (user_id, service_name, start_date) с существующей подпиской:
+// @Description skip - пропустить строку, overwrite - заменить подписку, fail - считать строку ошибкой.
+// @Description Ответ - отчёт-вложение с итогом каждой строки (created, updated, skipped, failed) и ошибками строк;
+// @Description report=csv возвращает его CSV-файлом, итоги - в заголовках X-Import-*. Если импорт прерван
+// @Description после сохранения части строк, отчёт содержит эти строки, а error - ошибку с её статусом ответа.
+// @Tags Subscriptions
+// @Accept plain
+// @Produce json
+// @Produce text/csv
+// @Param format query string false "Формат файла" Enums(csv, jsonl)
+// @Param dry_run query bool false "Только проверить файл, ничего не сохраняя"
+// @Param on_conflict query string false "Поведение при совпадении с существующей подпиской (по умолчанию skip)" Enums(skip, overwrite, fail)
+// @Param report query string false "Формат ответа (по умолчанию json)" Enums(json, csv)
+// @Param file body string true "Содержимое файла"
+// @Success 200 {object} models.ImportResult
+// @Failure 400 {object} utils.Problem
+// @Failure 403 {object} utils.Problem "Нет доступа к данным другого пользователя"
+// @Failure 500 {object} utils.Problem
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /subscriptions/import [post]
+func (h *SubscriptionHandler) ImportSubscriptions(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	opts := models.ImportOptions{Format: query.Get("format"), OnConflict: query.Get("on_conflict")}
+	if opts.Format == "" {
+		opts.Format = importFormatFromContentType(r.Header.Get("Content-Type"))
+	}
+	switch opts.Format {
+	case models.ImportFormatCSV, models.ImportFormatJSONL:
+	case "":
+		utils.InvalidField(w, r, "format", utils.MsgImportFormat)
+		return
+	default:
+		utils.InvalidField(w, r, "format", utils.MsgAllowedValues, "format", "csv, jsonl")
+		return
+	}
+	if s := query.Get("dry_run"); s != "" {
+		dryRun, err := strconv.ParseBool(s)
+		if err != nil {
+			utils.InvalidField(w, r, "dry_run", utils.MsgInvalidBool, "dry_run")
+			return
+		}
+		opts.DryRun = dryRun
+	}
+	switch opts.OnConflict {
+	case "":
+		opts.OnConflict = models.ConflictSkip
+	case models.ConflictSkip, models.ConflictOverwrite, models.ConflictFail:
+	default:
+		utils.InvalidField(w, r, "on_conflict", utils.MsgAllowedValues, "on_conflict", "skip, overwrite, fail")
+		return
+	}
+	reportFormat := query.Get("report")
+	if reportFormat != "" && reportFormat != "json" && reportFormat != "csv" {
+		utils.InvalidField(w, r, "report", utils.MsgAllowedValues, "report", "json, csv")
+		return
+	}
+
+	// Ошибки строк переводятся на язык клиента сразу: в отчёт попадают только тексты
+	lang := utils.Language(r)
+	results := []models.ImportRowResult{}
+	truncated := false
+	report := func(row models.ImportRowResult, err error) error {
+		if err != nil {
+			p := problemFor(err)
+			if p == nil {
+				// Ошибка сервера, а не строки: продолжать импорт нет смысла
+				return err
+			}
+			p.Localize(lang)
+			row.Errors = []models.ImportRowError{{Code: p.Code, Message: p.Detail}}
+			if len(p.Errors) > 0 {
+				row.Errors = row.Errors[:0]
+				for _, fe := range p.Errors {
+					row.Errors = append(row.Errors, models.ImportRowError{Field: fe.Field, Code: p.Code, Message: fe.Message})
+				}
+			}
+		}
+		if len(results) >= maxImportResults {
+			truncated = true
+			return nil
+		}
+		results = append(results, row)
+		return nil
+	}
+
+	body := http.MaxBytesReader(w, r.Body, maxImportFileSize)
+	result, err := h.Service.Import(r.Context(), body, opts, report)
+	status := http.StatusOK
+	if err != nil {
+		if result.Rows == 0 {
+			writeError(w, r, err)
+			return
+		}
+		// Часть строк уже сохранена: клиент получает их итоги вместе с ошибкой, прервавшей импорт
+		result.Error = problemFor(err)
+		if result.Error == nil {
+			logger.L().Error("Импорт подписок прерван", zap.Int("rows", result.Rows), zap.Error(err))
+			result.Error = utils.NewProblem(http.StatusInternalServerError, utils.CodeInternal, "")
+		}
+		result.Error.Localize(lang)
+		result.Error.Instance = r.URL.Path
+		status = result.Error.Status
+	}
+	result.Results, result.Truncated = results, truncated
+	logger.L().Info("Импорт подписок завершён",
+		zap.String("format", opts.Format), zap.Bool("dry_run", opts.DryRun), zap.Int("rows", result.Rows),
+		zap.Int("created", result.Created), zap.Int("updated", result.Updated), zap.Int("skipped", result.Skipped),
+		zap.Int("failed", result.Failed), zap.Bool("aborted", err != nil))
+
+	w.Header().Set("Content-Language", lang)
+	w.Header().Add("Vary", "Accept-Language")
+	if reportFormat == "csv" {
+		writeImportReport(w, status, result)
+		return
+	}
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": "import-report.json"}))
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(result)
+}
+
+// writeImportReport пишет итоги строк импорта в CSV; итоги импорта - в заголовках X-Import-*.
+// Ошибка, прервавшая импорт, - последняя строка отчёта со статусом aborted.
+func writeImportReport(w http.ResponseWriter, status int, result models.ImportResult) {
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": "import-report.csv"}))
+	w.Header().Set("X-Import-Dry-Run", strconv.FormatBool(result.DryRun))
+	w.Header().Set("X-Import-Rows", strconv.Itoa(result.Rows))
+	w.Header().Set("X-Import-Created", strconv.Itoa(result.Created))
+	w.Header().Set("X-Import-Updated", strconv.Itoa(result.Updated))
+	w.Header().Set("X-Import-Skipped", strconv.Itoa(result.Skipped))
+	w.Header().Set("X-Import-Failed", strconv.Itoa(result.Failed))
+	if result.Truncated {
+		w.Header().Set("X-Import-Truncated", "true")
+	}
+	w.WriteHeader(status)
+
+	out := csv.NewWriter(w)
+	out.Write([]string{"line", "status", "subscription_id", "field", "code", "message"})
+	for _, row := range result.Results {
+		id := ""
+		if row.SubscriptionID != 0 {
+			id = strconv.Itoa(row.SubscriptionID)
+		}
+		if len(row.Errors) == 0 {
+			out.Write([]string{strconv.Itoa(row.Line), row.Status, id, "", "", ""})
+		}
+		for _, e := range row.Errors {
+			out.Write([]string{strconv.Itoa(row.Line), row.Status, id, e.Field, e.Code, e.Message})
+		}
+	}
+	if result.Error != nil {
+		out.Write([]string{"", importAborted, "", "", result.Error.Code, result.Error.Detail})
+	}
+	out.Flush()
+	if err := out.Error(); err != nil {
+		logger.L().Error("Не удалось записать отчёт импорта", zap.Error(err))
+	}
+}
+
+// importFormatFromContentType определяет формат файла импорта по Content-Type
+func importFormatFromContentType(contentType string) string {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch mediaType {
+	case "text/csv":
+		return models.ImportFormatCSV
+	case "application/x-ndjson", "application/jsonl", "application/x-jsonlines":
+		return models.ImportFormatJSONL
+	}
+	return ""
+}
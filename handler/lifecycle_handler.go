@@ -0,0 +1,169 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"time"
+	"usersubs/logger"
+	"usersubs/models"
+	"usersubs/utils"
+
+	"go.uber.org/zap"
+)
+
+// handleSubscriptionAction обрабатывает запросы вида /subscriptions/{id}/{action}
+func (h *SubscriptionHandler) handleSubscriptionAction(w http.ResponseWriter, r *http.Request, id int, action string) {
+	switch action {
+	case "prices":
+		h.handlePrices(w, r, id)
+		return
+	case "restore":
+		if r.Method != http.MethodPost {
+			utils.MethodNotAllowed(w, r)
+			return
+		}
+		if requireScope(w, r, models.ScopeSubscriptionsWrite) {
+			h.RestoreSubscription(w, r, id)
+		}
+		return
+	case "history":
+		if r.Method != http.MethodGet {
+			utils.MethodNotAllowed(w, r)
+			return
+		}
+		if requireScope(w, r, models.ScopeSubscriptionsRead) {
+			h.GetSubscriptionHistory(w, r, id)
+		}
+		return
+	}
+
+	var transition func(w http.ResponseWriter, r *http.Request, id int)
+	switch action {
+	case "pause":
+		transition = h.PauseSubscription
+	case "resume":
+		transition = h.ResumeSubscription
+	case "cancel":
+		transition = h.CancelSubscription
+	default:
+		utils.NotFound(w, r)
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		utils.MethodNotAllowed(w, r)
+		return
+	}
+	if requireScope(w, r, models.ScopeSubscriptionsWrite) {
+		transition(w, r, id)
+	}
+}
+
+// @Summary Приостановить подписку
+// @Description Приостанавливает активную подписку с указанной даты (по умолчанию сегодня). Списания на время паузы не учитываются в стоимости.
+// @Tags Lifecycle
+// @Accept json
+// @Produce json
+// @Param id path int true "ID подписки"
+// @Param body body models.LifecycleInput false "Дата начала паузы"
+// @Success 200 {object} models.Subscription
+// @Failure 400 {object} utils.Problem
+// @Failure 404 {object} utils.Problem
+// @Failure 409 {object} utils.Problem "Подписка не активна"
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /subscriptions/{id}/pause [post]
+func (h *SubscriptionHandler) PauseSubscription(w http.ResponseWriter, r *http.Request, id int) {
+	h.applyTransition(w, r, id, h.Service.PauseSubscription)
+}
+
+// @Summary Возобновить подписку
+// @Description Возобновляет приостановленную подписку с указанной даты (по умолчанию сегодня)
+// @Tags Lifecycle
+// @Accept json
+// @Produce json
+// @Param id path int true "ID подписки"
+// @Param body body models.LifecycleInput false "Первый активный день после паузы"
+// @Success 200 {object} models.Subscription
+// @Failure 400 {object} utils.Problem
+// @Failure 404 {object} utils.Problem
+// @Failure 409 {object} utils.Problem "Подписка не приостановлена"
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /subscriptions/{id}/resume [post]
+func (h *SubscriptionHandler) ResumeSubscription(w http.ResponseWriter, r *http.Request, id int) {
+	h.applyTransition(w, r, id, h.Service.ResumeSubscription)
+}
+
+// @Summary Отменить подписку
+// @Description Отменяет подписку: указанная дата (по умолчанию сегодня) становится последним днём действия
+// @Tags Lifecycle
+// @Accept json
+// @Produce json
+// @Param id path int true "ID подписки"
+// @Param body body models.LifecycleInput false "Последний день действия"
+// @Success 200 {object} models.Subscription
+// @Failure 400 {object} utils.Problem
+// @Failure 404 {object} utils.Problem
+// @Failure 409 {object} utils.Problem "Подписка уже отменена"
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /subscriptions/{id}/cancel [post]
+func (h *SubscriptionHandler) CancelSubscription(w http.ResponseWriter, r *http.Request, id int) {
+	h.applyTransition(w, r, id, h.Service.CancelSubscription)
+}
+
+// applyTransition разбирает дату действия из тела запроса и выполняет переход состояния
+func (h *SubscriptionHandler) applyTransition(w http.ResponseWriter, r *http.Request, id int,
+	transition func(ctx context.Context, id int, on time.Time) (models.Subscription, error)) {
+	var input models.LifecycleInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil && err != io.EOF {
+		logger.L().Error("Неправильное тело запроса", zap.Error(err))
+		utils.Error(w, r, http.StatusBadRequest, utils.CodeBadRequest, utils.MsgBadBody)
+		return
+	}
+
+	now := time.Now().UTC()
+	on := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	if input.Date != "" {
+		t, err := time.Parse("2006-01-02", input.Date)
+		if err != nil {
+			utils.InvalidField(w, r, "date", utils.MsgInvalidDay, "date")
+			return
+		}
+		on = t
+	}
+
+	sub, err := transition(r.Context(), id, on)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	logger.L().Info("Состояние подписки изменено", zap.Int("Id", id), zap.String("status", sub.Status))
+	writeSubscription(w, sub)
+}
+
+// @Summary Восстановить удалённую подписку
+// @Description Снимает пометку удаления с мягко удалённой подписки
+// @Tags Lifecycle
+// @Produce json
+// @Param id path int true "ID подписки"
+// @Success 200 {object} models.Subscription
+// @Failure 404 {object} utils.Problem
+// @Failure 409 {object} utils.Problem "Подписка не удалена или уже существует неудалённая с теми же user_id, service_name и start_date"
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /subscriptions/{id}/restore [post]
+func (h *SubscriptionHandler) RestoreSubscription(w http.ResponseWriter, r *http.Request, id int) {
+	sub, err := h.Service.RestoreSubscription(r.Context(), id)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	logger.L().Info("Подписка восстановлена", zap.Int("Id", id))
+	writeSubscription(w, sub)
+}
@@ -0,0 +1,75 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"maps"
+	"slices"
+	"usersubs/models"
+	"usersubs/utils"
+	"usersubs/validation"
+)
+
+// Тип тела JSON Merge Patch (RFC 7396)
+const mergePatchContentType = "application/merge-patch+json"
+
+// parseMergePatch превращает тело merge patch в изменение подписки.
+// Отсутствующее поле не меняется, null допустим только у end_date (снимает дату окончания).
+// Значения проверяются правилами validation; ошибка - validation.Errors со всеми нарушениями.
+func parseMergePatch(fields map[string]json.RawMessage) (models.UpdateSubscription, error) {
+	var input models.UpdateSubscriptionInput
+	var errs validation.Errors
+	clearEndDate := false
+
+	for _, name := range slices.Sorted(maps.Keys(fields)) {
+		raw := fields[name]
+		if string(raw) == "null" {
+			if name == "end_date" {
+				clearEndDate = true
+			} else {
+				errs.Add(name, utils.MsgFieldNotRemovable)
+			}
+			continue
+		}
+
+		var target **string
+		switch name {
+		case "service_name":
+			target = &input.ServiceName
+		case "currency":
+			target = &input.Currency
+		case "billing_period":
+			target = &input.BillingPeriod
+		case "user_id":
+			target = &input.UserID
+		case "start_date":
+			target = &input.StartDate
+		case "end_date":
+			target = &input.EndDate
+		case "price":
+			var v models.Money
+			if err := json.Unmarshal(raw, &v); err != nil {
+				errs.Add(name, utils.MsgInvalidMoney, name)
+				continue
+			}
+			input.Price = &v
+			continue
+		default:
+			errs.Add(name, utils.MsgUnknownField)
+			continue
+		}
+		var v string
+		if err := json.Unmarshal(raw, &v); err != nil {
+			errs.Add(name, utils.MsgExpectedString)
+			continue
+		}
+		*target = &v
+	}
+
+	update, err := validation.Update(input, clearEndDate)
+	var invalid validation.Errors
+	if errors.As(err, &invalid) {
+		errs = append(errs, invalid...)
+	}
+	return update, errs.Err()
+}
@@ -0,0 +1,77 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"slices"
+	"testing"
+	"usersubs/models"
+	"usersubs/validation"
+)
+
+// changedFields возвращает поля, которые меняет update; end_date со сбросом - "end_date=null"
+func changedFields(update models.UpdateSubscription) []string {
+	var fields []string
+	add := func(set bool, name string) {
+		if set {
+			fields = append(fields, name)
+		}
+	}
+	add(update.ServiceName != nil, "service_name")
+	add(update.Price != nil, "price")
+	add(update.Currency != nil, "currency")
+	add(update.BillingPeriod != nil, "billing_period")
+	add(update.UserID != nil, "user_id")
+	add(update.StartDate != nil, "start_date")
+	if update.EndDate != nil {
+		if *update.EndDate == nil {
+			fields = append(fields, "end_date=null")
+		} else {
+			fields = append(fields, "end_date="+(*update.EndDate).Format("2006-01-02"))
+		}
+	}
+	return fields
+}
+
+func TestParseMergePatch(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		wantFields []string // Изменяемые поля
+		wantErrors []string // Поля с ошибками
+	}{
+		{name: "empty patch", body: `{}`},
+		{name: "null clears end_date", body: `{"end_date": null}`, wantFields: []string{"end_date=null"}},
+		{name: "end_date month", body: `{"end_date": "02-2026"}`, wantFields: []string{"end_date=2026-02-28"}},
+		{name: "absent fields are kept", body: `{"price": "349.90", "service_name": "Okko"}`, wantFields: []string{"service_name", "price"}},
+		{name: "null price", body: `{"price": null}`, wantErrors: []string{"price"}},
+		{name: "null required fields", body: `{"service_name": null, "user_id": null, "end_date": null}`, wantErrors: []string{"service_name", "user_id"}},
+		{name: "unknown field", body: `{"status": "active"}`, wantErrors: []string{"status"}},
+		{name: "wrong type", body: `{"currency": 840}`, wantErrors: []string{"currency"}},
+		{name: "invalid value", body: `{"price": "-1"}`, wantErrors: []string{"price"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var fields map[string]json.RawMessage
+			if err := json.Unmarshal([]byte(tt.body), &fields); err != nil {
+				t.Fatal(err)
+			}
+			update, err := parseMergePatch(fields)
+
+			var invalid validation.Errors
+			if !errors.As(err, &invalid) && err != nil {
+				t.Fatalf("parseMergePatch error = %v, want validation.Errors", err)
+			}
+			var gotErrors []string
+			for _, fe := range invalid {
+				gotErrors = append(gotErrors, fe.Field)
+			}
+			if !slices.Equal(gotErrors, tt.wantErrors) {
+				t.Fatalf("error fields = %v, want %v", gotErrors, tt.wantErrors)
+			}
+			if err == nil && !slices.Equal(changedFields(update), tt.wantFields) {
+				t.Errorf("changed fields = %v, want %v", changedFields(update), tt.wantFields)
+			}
+		})
+	}
+}
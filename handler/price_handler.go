@@ -0,0 +1,93 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"usersubs/logger"
+	"usersubs/models"
+	"usersubs/utils"
+	"usersubs/validation"
+
+	"go.uber.org/zap"
+)
+
+// handlePrices обрабатывает запросы к /subscriptions/{id}/prices
+func (h *SubscriptionHandler) handlePrices(w http.ResponseWriter, r *http.Request, id int) {
+	switch r.Method {
+	case http.MethodGet:
+		if requireScope(w, r, models.ScopeSubscriptionsRead) {
+			h.ListPriceHistory(w, r, id)
+		}
+	case http.MethodPost:
+		if requireScope(w, r, models.ScopeSubscriptionsWrite) {
+			h.AddPriceChange(w, r, id)
+		}
+	default:
+		utils.MethodNotAllowed(w, r)
+	}
+}
+
+// @Summary История цен подписки
+// @Description Возвращает цены подписки с месяцами, с которых они действуют, в порядке возрастания
+// @Tags Prices
+// @Produce json
+// @Param id path int true "ID подписки"
+// @Success 200 {array} models.PriceChange
+// @Failure 404 {object} utils.Problem
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /subscriptions/{id}/prices [get]
+func (h *SubscriptionHandler) ListPriceHistory(w http.ResponseWriter, r *http.Request, id int) {
+	w.Header().Set("Content-Type", "application/json")
+
+	history, err := h.Service.ListPriceHistory(r.Context(), id)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	json.NewEncoder(w).Encode(history)
+}
+
+// @Summary Изменить цену подписки
+// @Description Устанавливает цену, действующую с указанного месяца (в том числе будущего). Стоимость за предыдущие месяцы
+// @Description считается по прежней цене. Повторное изменение в том же месяце заменяет цену этого месяца.
+// @Tags Prices
+// @Accept json
+// @Produce json
+// @Param id path int true "ID подписки"
+// @Param body body models.PriceChangeInput true "Новая цена и месяц начала действия"
+// @Success 200 {object} models.Subscription
+// @Failure 400 {object} utils.Problem
+// @Failure 404 {object} utils.Problem
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /subscriptions/{id}/prices [post]
+func (h *SubscriptionHandler) AddPriceChange(w http.ResponseWriter, r *http.Request, id int) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var input models.PriceChangeInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		logger.L().Error("Неправильное тело запроса", zap.Error(err))
+		if errors.Is(err, models.ErrInvalidMoney) {
+			utils.InvalidField(w, r, "price", utils.MsgInvalidMoney, "price")
+			return
+		}
+		utils.Error(w, r, http.StatusBadRequest, utils.CodeBadRequest, utils.MsgBadBody)
+		return
+	}
+	month, err := validation.PriceChange(input)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	sub, err := h.Service.AddPriceChange(r.Context(), id, month, input.Price)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	logger.L().Info("Цена подписки изменена", zap.Int("Id", id), zap.Time("effective_month", month))
+	writeSubscription(w, sub)
+}
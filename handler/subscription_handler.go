@@ -2,8 +2,8 @@ package handler
 
 import (
 	"encoding/json"
-	"fmt"
-	"log"
+	"errors"
+	"mime"
 	"net/http"
 	"strconv"
 	"strings"
@@ -12,16 +12,18 @@ import (
 	"usersubs/models"
 	"usersubs/service"
 	"usersubs/utils"
+	"usersubs/validation"
 
 	"go.uber.org/zap"
 )
 
 type SubscriptionHandler struct {
-	Service *service.SubscriptionsService
+	Service     *service.SubscriptionsService
+	Idempotency *service.IdempotencyService
 }
 
-func NewSubscriptionHandler(s *service.SubscriptionsService) *SubscriptionHandler {
-	return &SubscriptionHandler{Service: s}
+func NewSubscriptionHandler(s *service.SubscriptionsService, idempotency *service.IdempotencyService) *SubscriptionHandler {
+	return &SubscriptionHandler{Service: s, Idempotency: idempotency}
 }
 
 func (h *SubscriptionHandler) HandleSubscriptions(w http.ResponseWriter, r *http.Request) {
@@ -30,10 +32,14 @@ func (h *SubscriptionHandler) HandleSubscriptions(w http.ResponseWriter, r *http
 	switch {
 
 	case r.Method == http.MethodGet:
-		h.ListSubscriptions(w, r)
+		if requireScope(w, r, models.ScopeSubscriptionsRead) {
+			h.ListSubscriptions(w, r)
+		}
 
 	case r.Method == http.MethodPost:
-		h.CreateSubscription(w, r)
+		if requireScope(w, r, models.ScopeSubscriptionsWrite) {
+			h.idempotent(h.CreateSubscription)(w, r)
+		}
 
 	default:
 		utils.MethodNotAllowed(w, r)
@@ -43,28 +49,42 @@ func (h *SubscriptionHandler) HandleSubscriptions(w http.ResponseWriter, r *http
 func (h *SubscriptionHandler) HandleSubscriptionsByID(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
-	// Получаем id из пути
-	idStr := strings.TrimPrefix(r.URL.Path, "/subscriptions/")
-	if idStr == "" || strings.Contains(idStr, "/") {
+	// Получаем id и необязательное действие из пути: /subscriptions/{id}[/{action}]
+	idStr, action, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/subscriptions/"), "/")
+	if idStr == "" || strings.Contains(action, "/") {
 		logger.L().Warn("неверное id в URL")
 		utils.NotFound(w, r)
 		return
 	}
 	id, err := strconv.Atoi(idStr)
 	if err != nil {
-		http.Error(w, `{"error":"Неправильный ID записи"}`, http.StatusBadRequest)
+		utils.InvalidField(w, r, "id", utils.MsgInvalidRecordID)
+		return
+	}
+
+	if action != "" {
+		h.handleSubscriptionAction(w, r, id, action)
 		return
 	}
 
 	switch {
 
 	case r.Method == http.MethodGet:
-		h.GetSubscription(w, r, id)
-		return
+		if requireScope(w, r, models.ScopeSubscriptionsRead) {
+			h.GetSubscription(w, r, id)
+		}
 	case r.Method == http.MethodPut:
-		h.UpdateSubscription(w, r, id)
+		if requireScope(w, r, models.ScopeSubscriptionsWrite) {
+			h.UpdateSubscription(w, r, id)
+		}
+	case r.Method == http.MethodPatch:
+		if requireScope(w, r, models.ScopeSubscriptionsWrite) {
+			h.PatchSubscription(w, r, id)
+		}
 	case r.Method == http.MethodDelete:
-		h.DeleteSubscription(w, r, id)
+		if requireScope(w, r, models.ScopeSubscriptionsWrite) {
+			h.DeleteSubscription(w, r, id)
+		}
 
 	default:
 		utils.MethodNotAllowed(w, r)
@@ -76,263 +96,349 @@ func (h *SubscriptionHandler) HandleSubscriptionsByID(w http.ResponseWriter, r *
 // @Tags Subscriptions
 // @Produce json
 // @Param id path int true "ID подписки"
+// @Param include_deleted query bool false "Возвращать мягко удалённую подписку"
 // @Success 200 {object} models.Subscription
-// @Failure 404 {object} map[string]string
+// @Header 200 {string} ETag "Версия записи для If-Match"
+// @Failure 400 {object} utils.Problem
+// @Failure 404 {object} utils.Problem
+// @Failure 403 {object} utils.Problem "Нет доступа к данным другого пользователя"
+// @Security ApiKeyAuth
+// @Security BearerAuth
 // @Router /subscriptions/{id} [get]
 func (h *SubscriptionHandler) GetSubscription(w http.ResponseWriter, r *http.Request, id int) {
+	includeDeleted, ok := parseIncludeDeleted(w, r)
+	if !ok {
+		return
+	}
 
-	sub, found := h.Service.GetSubscription(id)
-	if !found {
-		logger.L().Warn("Подписка не найдена", zap.Int("subscription_id", id))
-		http.Error(w, `{"error":"Запись не найдена"}`, http.StatusNotFound)
+	sub, err := h.Service.GetSubscription(r.Context(), id, includeDeleted)
+	if err != nil {
+		writeError(w, r, err)
 		return
 	}
-	json.NewEncoder(w).Encode(sub)
+	writeSubscription(w, sub)
 }
 
-// @Summary Получить все подписки
-// @Description Возвращает список всех подписок
+// @Summary Получить список подписок
+// @Description Возвращает страницу подписок с фильтрацией, сортировкой и курсорной пагинацией
 // @Tags Subscriptions
 // @Produce json
-// @Success 200 {array} models.Subscription
-// @Failure 500 {object} map[string]string
+// @Param user_id query string false "ID пользователя (UUID)"
+// @Param service_name query string false "Название сервиса (точное совпадение)"
+// @Param service_name_contains query string false "Подстрока названия сервиса (без учёта регистра)"
+// @Param from query string false "Подписка активна с даты (YYYY-MM-DD или MM-YYYY)"
+// @Param to query string false "Подписка активна по дату включительно (YYYY-MM-DD или MM-YYYY - до конца месяца)"
+// @Param price_min query string false "Минимальная цена, действующая сегодня, например 99.90"
+// @Param price_max query string false "Максимальная цена, действующая сегодня, например 999.00"
+// @Param sort query string false "Поле сортировки; price - цена, действующая сегодня" Enums(id, price, start_date, service_name)
+// @Param order query string false "Направление сортировки" Enums(asc, desc)
+// @Param limit query int false "Размер страницы (по умолчанию 50, максимум 500)"
+// @Param include_deleted query bool false "Включать мягко удалённые подписки"
+// @Param cursor query string false "Курсор следующей страницы (next_cursor из предыдущего ответа)"
+// @Success 200 {object} models.SubscriptionPage
+// @Failure 400 {object} utils.Problem
+// @Failure 500 {object} utils.Problem
+// @Failure 403 {object} utils.Problem "Нет доступа к данным другого пользователя"
+// @Security ApiKeyAuth
+// @Security BearerAuth
 // @Router /subscriptions [get]
 func (h *SubscriptionHandler) ListSubscriptions(w http.ResponseWriter, r *http.Request) {
-	subs, err := h.Service.ListSubscriptions()
+	query := r.URL.Query()
+	filter, ok := parseSubscriptionFilter(w, r)
+	if !ok {
+		return
+	}
+
+	page, err := h.Service.ListSubscriptions(r.Context(), filter, query.Get("cursor"))
+	if errors.Is(err, service.ErrInvalidCursor) {
+		logger.L().Warn("Некорректный курсор", zap.String("cursor", query.Get("cursor")))
+		utils.InvalidField(w, r, "cursor", utils.MsgInvalidCursor)
+		return
+	}
 	if err != nil {
-		logger.L().Error("Не удалось получить записи", zap.Error(err))
-		utils.InternalServerError(w, r)
+		writeError(w, r, err)
 		return
 	}
-	json.NewEncoder(w).Encode(subs)
+	json.NewEncoder(w).Encode(page)
+}
+
+// parseSubscriptionFilter разбирает параметры выборки подписок (фильтры, сортировку, limit).
+// При ошибке пишет ответ 400 и возвращает false.
+func parseSubscriptionFilter(w http.ResponseWriter, r *http.Request) (models.SubscriptionFilter, bool) {
+	query := r.URL.Query()
+
+	filter := models.SubscriptionFilter{
+		UserID:              query.Get("user_id"),
+		ServiceName:         query.Get("service_name"),
+		ServiceNameContains: query.Get("service_name_contains"),
+		SortBy:              query.Get("sort"),
+	}
+
+	var ok bool
+	if filter.IncludeDeleted, ok = parseIncludeDeleted(w, r); !ok {
+		return models.SubscriptionFilter{}, false
+	}
+
+	var err error
+	if filter.ActiveFrom, err = optionalDate(query.Get("from"), utils.ParseDate); err != nil {
+		logger.L().Warn("Неверный формат from", zap.Error(err))
+		utils.InvalidField(w, r, "from", utils.MsgInvalidDate, "from")
+		return models.SubscriptionFilter{}, false
+	}
+	if filter.ActiveTo, err = optionalDate(query.Get("to"), utils.ParseEndDate); err != nil {
+		logger.L().Warn("Неверный формат to", zap.Error(err))
+		utils.InvalidField(w, r, "to", utils.MsgInvalidDate, "to")
+		return models.SubscriptionFilter{}, false
+	}
+	if filter.PriceMin, err = optionalMoney(query.Get("price_min")); err != nil {
+		utils.InvalidField(w, r, "price_min", utils.MsgInvalidMoney, "price_min")
+		return models.SubscriptionFilter{}, false
+	}
+	if filter.PriceMax, err = optionalMoney(query.Get("price_max")); err != nil {
+		utils.InvalidField(w, r, "price_max", utils.MsgInvalidMoney, "price_max")
+		return models.SubscriptionFilter{}, false
+	}
+	if limitStr := query.Get("limit"); limitStr != "" {
+		filter.Limit, err = strconv.Atoi(limitStr)
+		if err != nil || filter.Limit <= 0 {
+			utils.InvalidField(w, r, "limit", utils.MsgPositiveInteger, "limit")
+			return models.SubscriptionFilter{}, false
+		}
+	}
+
+	switch filter.SortBy {
+	case "", models.SortByID, models.SortByPrice, models.SortByStartDate, models.SortByServiceName:
+	default:
+		utils.InvalidField(w, r, "sort", utils.MsgAllowedValues, "sort", "id, price, start_date, service_name")
+		return models.SubscriptionFilter{}, false
+	}
+	switch query.Get("order") {
+	case "", "asc":
+	case "desc":
+		filter.SortDesc = true
+	default:
+		utils.InvalidField(w, r, "order", utils.MsgAllowedValues, "order", "asc, desc")
+		return models.SubscriptionFilter{}, false
+	}
+	return filter, true
+}
+
+// optionalDate разбирает необязательный параметр-дату функцией parse
+func optionalDate(s string, parse func(string) (time.Time, error)) (*time.Time, error) {
+	if s == "" {
+		return nil, nil
+	}
+	t, err := parse(s)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// writeSubscription пишет подписку с заголовком ETag её версии
+func writeSubscription(w http.ResponseWriter, sub models.Subscription) {
+	w.Header().Set("ETag", utils.ETag(sub.Version))
+	json.NewEncoder(w).Encode(sub)
+}
+
+// parseIncludeDeleted разбирает флаг include_deleted. При ошибке пишет ответ 400 и возвращает false.
+func parseIncludeDeleted(w http.ResponseWriter, r *http.Request) (bool, bool) {
+	query := r.URL.Query()
+	s := query.Get("include_deleted")
+	if s == "" {
+		return false, true
+	}
+	includeDeleted, err := strconv.ParseBool(s)
+	if err != nil {
+		utils.InvalidField(w, r, "include_deleted", utils.MsgInvalidBool, "include_deleted")
+		return false, false
+	}
+	return includeDeleted, true
+}
+
+// optionalMoney разбирает необязательный параметр-сумму
+func optionalMoney(s string) (*models.Money, error) {
+	if s == "" {
+		return nil, nil
+	}
+	m, err := models.ParseMoney(s)
+	if err != nil {
+		return nil, err
+	}
+	return &m, nil
 }
 
 // @Summary Создать новую подписку
-// @Description Добавляет новую подписку в базу данных
+// @Description Добавляет новую подписку в базу данных. Для ключа пользователя user_id можно не указывать.
+// @Description С заголовком Idempotency-Key повтор запроса возвращает сохранённый ответ первого (заголовок Idempotent-Replayed: true).
 // @Tags Subscriptions
 // @Accept json
 // @Produce json
 // @Param subscription body models.SubscriptionInput true "Данные подписки"
+// @Param Idempotency-Key header string false "Ключ идемпотентности (до 255 символов)"
 // @Success 200 {object} map[string]int
-// @Failure 500 {object} map[string]string
+// @Failure 409 {object} utils.Problem "Подписка уже существует или запрос с тем же Idempotency-Key ещё выполняется"
+// @Failure 422 {object} utils.Problem "Idempotency-Key уже использован с другим телом запроса"
+// @Failure 500 {object} utils.Problem
+// @Failure 403 {object} utils.Problem "Нет доступа к данным другого пользователя"
+// @Security ApiKeyAuth
+// @Security BearerAuth
 // @Router /subscriptions [post]
 func (h *SubscriptionHandler) CreateSubscription(w http.ResponseWriter, r *http.Request) {
-
-	var input models.SubscriptionInput
-	err := json.NewDecoder(r.Body).Decode(&input)
-	if err != nil {
-		logger.L().Error("Неправильное тело запроса", zap.Error(err))
-		http.Error(w, `{"error":"Неправильное тело запроса"}`, http.StatusBadRequest)
+	sub, ok := decodeSubscriptionInput(w, r)
+	if !ok {
 		return
 	}
-
-	if input.ServiceName == "" || input.UserID == "" || input.StartDate == "" {
-		logger.L().Warn("Отсутствуют обязательные поля")
-		http.Error(w, `{"error":"Отсутствуют обязательные поля"}`, http.StatusBadRequest)
-		return
-	}
-
-	startDate, err := utils.ParseMonthYear(input.StartDate)
+	id, err := h.Service.CreateSubscription(r.Context(), sub)
 	if err != nil {
-		logger.L().Error("Неверный формат start_date", zap.Error(err))
-		http.Error(w, `{"error":"Неверный формат start_date (MM-YYYY)"}`, http.StatusBadRequest)
+		writeError(w, r, err)
 		return
 	}
 
-	var endDate *time.Time
-	if input.EndDate != nil && *input.EndDate != "" {
-		t, err := utils.ParseMonthYear(*input.EndDate)
-		if err != nil {
-			logger.L().Error("Неверный формат end_date", zap.Error(err))
-			http.Error(w, `{"error":"Неверный формат end_date (MM-YYYY)"}`, http.StatusBadRequest)
-			return
-		}
-		endDate = &t
-	}
-	if endDate != nil && endDate.Before(startDate) {
-		logger.L().Warn("Дата окончания не может быть раньше даты начала")
-		http.Error(w, `{"error":"Дата окончания не может быть раньше даты начала"}`, http.StatusBadRequest)
-		return
+	response := map[string]int{"Id": id}
+	json.NewEncoder(w).Encode(response)
+}
+
+// decodeSubscriptionInput читает из тела запроса подписку целиком (для POST и PUT) и проверяет её правилами validation.
+// Ключу пользователя user_id подставляется автоматически. При ошибке пишет ответ и возвращает false.
+func decodeSubscriptionInput(w http.ResponseWriter, r *http.Request) (models.Subscription, bool) {
+	var input models.SubscriptionInput
+	err := json.NewDecoder(r.Body).Decode(&input)
+	if errors.Is(err, models.ErrInvalidMoney) {
+		logger.L().Warn("Некорректная цена", zap.Error(err))
+		utils.InvalidField(w, r, "price", utils.MsgInvalidMoney, "price")
+		return models.Subscription{}, false
 	}
-	if input.Price < 0 {
-		logger.L().Warn("Цена не может быть отрицательной")
-		http.Error(w, `{"error":"Цена не может быть отрицательной"}`, http.StatusBadRequest)
-		return
+	if err != nil {
+		logger.L().Error("Неправильное тело запроса", zap.Error(err))
+		utils.Error(w, r, http.StatusBadRequest, utils.CodeBadRequest, utils.MsgBadBody)
+		return models.Subscription{}, false
 	}
 
-	sub := models.Subscription{
-		ServiceName: input.ServiceName,
-		Price:       input.Price,
-		UserID:      input.UserID,
-		StartDate:   startDate,
-		EndDate:     endDate,
+	// Ключу пользователя user_id подставляется автоматически
+	if input.UserID, err = service.ScopeUserID(r.Context(), input.UserID); err != nil {
+		writeForbidden(w, r)
+		return models.Subscription{}, false
 	}
-	log.Println(sub)
 
-	id, err := h.Service.CreateSubscription(sub)
+	sub, err := validation.Subscription(input)
 	if err != nil {
-		logger.L().Error("Не удалось создать запись", zap.Error(err))
-		http.Error(w, fmt.Sprintf(`{"error":"Не удалось создать запись: %s"}`, err.Error()), http.StatusInternalServerError)
-		return
+		writeError(w, r, err)
+		return models.Subscription{}, false
 	}
-
-	response := map[string]int{"Id": id}
-	json.NewEncoder(w).Encode(response)
+	return sub, true
 }
 
-// @Summary Обновить подписку
-// @Description Обновляет поля подписки по ID (только переданные и непустые поля)
+// @Summary Заменить подписку
+// @Description Полностью заменяет поля подписки по ID: тело - как при создании, все обязательные поля нужны.
+// @Description Необязательные поля, которых нет в теле, получают значения по умолчанию (end_date - без даты окончания).
+// @Description Новая цена действует с текущего месяца, прошлые месяцы считаются по прежней цене.
 // @Tags Subscriptions
 // @Accept json
 // @Produce json
 // @Param id path int true "ID подписки"
-// @Param subscription body models.UpdateSubscription true "Обновлённые поля подписки"
+// @Param subscription body models.SubscriptionInput true "Новые данные подписки"
+// @Param If-Match header string false "ETag из предыдущего ответа; при несовпадении версии - 412"
 // @Success 200 {object} models.Subscription
-// @Failure 500 {object} map[string]string
+// @Header 200 {string} ETag "Новая версия записи"
+// @Failure 400 {object} utils.Problem
+// @Failure 403 {object} utils.Problem "Нет доступа к данным другого пользователя"
+// @Failure 404 {object} utils.Problem
+// @Failure 409 {object} utils.Problem
+// @Failure 412 {object} utils.Problem "Запись изменена другим запросом"
+// @Failure 500 {object} utils.Problem
+// @Security ApiKeyAuth
+// @Security BearerAuth
 // @Router /subscriptions/{id} [put]
 func (h *SubscriptionHandler) UpdateSubscription(w http.ResponseWriter, r *http.Request, id int) {
-
-	var input models.UpdateSubscriptionInput
-	err := json.NewDecoder(r.Body).Decode(&input)
-	if err != nil {
-		logger.L().Error("Неправильное тело запроса", zap.Error(err))
-		http.Error(w, `{"error":"Неправильное тело запроса"}`, http.StatusBadRequest)
+	sub, ok := decodeSubscriptionInput(w, r)
+	if !ok {
 		return
 	}
 
-	var startDate *time.Time
-	if input.StartDate != nil && *input.StartDate != "" {
-		t, err := utils.ParseMonthYear(*input.StartDate)
-		if err != nil {
-			logger.L().Error("Неверный формат start_date", zap.Error(err))
-			http.Error(w, `{"error":"Неверный формат start_date (MM-YYYY)"}`, http.StatusBadRequest)
-			return
-		}
-		startDate = &t
-	}
+	// Полная замена - изменение всех полей, включая сброс end_date
+	updated, err := h.Service.UpdateSubscription(r.Context(), models.FullUpdate(sub), id, utils.ParseIfMatch(r.Header.Get("If-Match")))
+	h.writeUpdateResult(w, r, updated, err)
+}
 
-	var endDate *time.Time
-	if input.EndDate != nil && *input.EndDate != "" {
-		t, err := utils.ParseMonthYear(*input.EndDate)
-		if err != nil {
-			logger.L().Error("Неверный формат end_date", zap.Error(err))
-			http.Error(w, `{"error":"Неверный формат end_date (MM-YYYY)"}`, http.StatusBadRequest)
-			return
-		}
-		endDate = &t
+// @Summary Частично изменить подписку
+// @Description Изменяет подписку по правилам JSON Merge Patch (RFC 7396): отсутствующие поля не меняются,
+// @Description null у end_date снимает дату окончания, нулевая цена допустима. Остальные поля удалить (null) нельзя.
+// @Tags Subscriptions
+// @Accept json
+// @Produce json
+// @Param id path int true "ID подписки"
+// @Param patch body models.UpdateSubscriptionInput true "Изменяемые поля"
+// @Param If-Match header string false "ETag из предыдущего ответа; при несовпадении версии - 412"
+// @Success 200 {object} models.Subscription
+// @Header 200 {string} ETag "Новая версия записи"
+// @Failure 400 {object} utils.Problem
+// @Failure 403 {object} utils.Problem "Нет доступа к данным другого пользователя"
+// @Failure 404 {object} utils.Problem
+// @Failure 409 {object} utils.Problem
+// @Failure 412 {object} utils.Problem "Запись изменена другим запросом"
+// @Failure 415 {object} utils.Problem
+// @Failure 500 {object} utils.Problem
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /subscriptions/{id} [patch]
+func (h *SubscriptionHandler) PatchSubscription(w http.ResponseWriter, r *http.Request, id int) {
+	if ct, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); ct != "" && ct != mergePatchContentType && ct != "application/json" {
+		utils.Error(w, r, http.StatusUnsupportedMediaType, utils.CodeUnsupportedMediaType, utils.MsgMergePatchOnly)
+		return
 	}
-	if endDate != nil && endDate.Before(*startDate) {
-		logger.L().Warn("Дата окончания не может быть раньше даты начала", zap.Time("start", *startDate), zap.Time("end", *endDate))
-		http.Error(w, `{"error":"Дата окончания не может быть раньше даты начала"}`, http.StatusBadRequest)
+
+	var fields map[string]json.RawMessage
+	if err := json.NewDecoder(r.Body).Decode(&fields); err != nil || fields == nil {
+		logger.L().Warn("Неправильное тело запроса", zap.Error(err))
+		utils.Error(w, r, http.StatusBadRequest, utils.CodeBadRequest, utils.MsgBodyNotObject)
 		return
 	}
-	if *input.Price < 0 {
-		logger.L().Warn("Цена не может быть отрицательной")
-		http.Error(w, `{"error":"Цена не может быть отрицательной"}`, http.StatusBadRequest)
+	patch, err := parseMergePatch(fields)
+	if err != nil {
+		writeError(w, r, err)
 		return
 	}
 
-	sub := models.UpdateSubscription{
-		ServiceName: input.ServiceName,
-		Price:       input.Price,
-		UserID:      input.UserID,
-		StartDate:   startDate,
-		EndDate:     &endDate,
-	}
+	updated, err := h.Service.UpdateSubscription(r.Context(), patch, id, utils.ParseIfMatch(r.Header.Get("If-Match")))
+	h.writeUpdateResult(w, r, updated, err)
+}
 
-	updatedSub, err := h.Service.UpdateSubscription(sub, id)
+// writeUpdateResult пишет ответ на изменение подписки (PUT или PATCH)
+func (h *SubscriptionHandler) writeUpdateResult(w http.ResponseWriter, r *http.Request, updated models.Subscription, err error) {
 	if err != nil {
-		logger.L().Error("Не удалось обновить запись", zap.Error(err))
-		http.Error(w, fmt.Sprintf(`{"error":"Не удалось обновить запись: %s"}`, err.Error()), http.StatusInternalServerError)
+		writeError(w, r, err)
 		return
 	}
-
-	json.NewEncoder(w).Encode(updatedSub)
+	writeSubscription(w, updated)
 }
 
 // @Summary Удалить подписку
-// @Description Удаляет подписку по ID
+// @Description Мягко удаляет подписку по ID: запись скрывается из выборок и подсчёта стоимости
+// @Description и может быть восстановлена до окончательной очистки по истечении срока хранения
 // @Tags Subscriptions
 // @Produce json
 // @Param id path int true "ID подписки"
+// @Param If-Match header string false "ETag из предыдущего ответа; при несовпадении версии - 412"
 // @Success 204 "Подписка удалена"
-// @Failure 404 {object} map[string]string
-// @Failure 500 {object} map[string]string
+// @Failure 412 {object} utils.Problem "Запись изменена другим запросом"
+// @Failure 404 {object} utils.Problem
+// @Failure 500 {object} utils.Problem
+// @Security ApiKeyAuth
+// @Security BearerAuth
 // @Router /subscriptions/{id} [delete]
 func (h *SubscriptionHandler) DeleteSubscription(w http.ResponseWriter, r *http.Request, id int) {
 
-	rowsDeleted, err := h.Service.DeleteSubscription(id)
+	rowsDeleted, err := h.Service.DeleteSubscription(r.Context(), id, utils.ParseIfMatch(r.Header.Get("If-Match")))
 	if err != nil {
-		logger.L().Error("Не удалось удалить запись", zap.Error(err))
-		http.Error(w, `{"error":"Не удалось удалить запись"}`, http.StatusInternalServerError)
+		writeError(w, r, err)
 		return
 	}
 	if rowsDeleted == 0 {
 		logger.L().Warn("Запись не найдена", zap.Int("Id", id))
-		http.Error(w, `{"error":"Запись не найдена"}`, http.StatusNotFound)
+		utils.Error(w, r, http.StatusNotFound, utils.CodeNotFound, utils.MsgRecordNotFound)
 		return
 	}
 
 	w.WriteHeader(http.StatusNoContent)
 }
-
-// @Summary Получение суммарной стоимости подписок
-// @Description Возвращает суммарную стоимость подписок пользователя за указанный период с фильтрацией по названию сервиса (счет по месяцам)
-// @Tags Subscriptions
-// @Accept  json
-// @Produce  json
-// @Param user_id query string false "ID пользователя (UUID)"
-// @Param service_name query string false "Название сервиса (опционально)"
-// @Param start_date query string true "Дата начала периода (в формате MM-YYYY) (опционально)"
-// @Param end_date query string true "Дата окончания периода (в формате MM-YYYY) (опционально)"
-// @Success 200 {object} map[string]int "Суммарная стоимость, например {\"total_cost\": 900}"
-// @Failure 400 {object} map[string]string "Ошибка валидации"
-// @Failure 500 {object} map[string]string "Ошибка сервера"
-// @Router /subscriptions/total-cost [get]
-func (h *SubscriptionHandler) GetTotalCost(w http.ResponseWriter, r *http.Request) {
-	query := r.URL.Query()
-
-	userID := query.Get("user_id")
-	serviceName := query.Get("service_name")
-	startStr := query.Get("start_date")
-	endStr := query.Get("end_date")
-	if startStr == "" || endStr == "" {
-		logger.L().Warn("start_date, end_date обязательны")
-		http.Error(w, `{"error":"start_date, end_date обязательны"}`, http.StatusBadRequest)
-		return
-	}
-
-	var startDate, endDate time.Time
-	var err error
-
-	startDate, err = utils.ParseMonthYear(startStr)
-	if err != nil {
-		logger.L().Error("Неверный формат start_date", zap.Error(err))
-		http.Error(w, `{"error":"Неверный формат start_date (MM-YYYY)"}`, http.StatusBadRequest)
-		return
-	}
-	endDate, err = utils.ParseMonthYear(endStr)
-	if err != nil {
-		logger.L().Error("Неверный формат end_date", zap.Error(err))
-		http.Error(w, `{"error":"Неверный формат end_date (MM-YYYY)"}`, http.StatusBadRequest)
-		return
-	}
-
-	if endDate.Before(startDate) {
-		logger.L().Warn("Дата окончания не может быть раньше даты начала", zap.Time("start", startDate), zap.Time("end", endDate))
-		http.Error(w, `{"error":"Дата окончания не может быть раньше даты начала"}`, http.StatusBadRequest)
-		return
-	}
-
-	// log.Println(userID, serviceName, startDate, endDate)
-	total, err := h.Service.GetTotalCost(userID, serviceName, startDate, endDate)
-	if err != nil {
-		logger.L().Error("Ошибка при подсчете стоимости", zap.Error(err))
-		http.Error(w, fmt.Sprintf(`{"error":"Ошибка при подсчете стоимости: %s"}`, err.Error()), http.StatusInternalServerError)
-		return
-	}
-
-	resp := map[string]int{"total_cost": total}
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(resp)
-}
@@ -0,0 +1,62 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"usersubs/models"
+	"usersubs/service"
+	"usersubs/utils"
+)
+
+// Длина окна предстоящих списаний по умолчанию
+const defaultUpcomingDays = 30
+
+// @Summary Предстоящие списания и окончания подписок
+// @Description Возвращает списания, которые придутся на ближайшие days дней начиная с сегодняшнего:
+// @Description даты считаются от start_date с периодом списания подписки, списания после end_date и во время пауз не учитываются,
+// @Description сумма - цена, действующая на дату списания. В expiring - подписки, end_date которых попадает в то же окно.
+// @Tags Subscriptions
+// @Produce json
+// @Param user_id query string false "ID пользователя (UUID)"
+// @Param service_name query string false "Название сервиса (опционально)"
+// @Param days query int false "Длина окна в днях, включая сегодняшний (по умолчанию 30, не больше 366)"
+// @Success 200 {object} models.Upcoming
+// @Failure 400 {object} utils.Problem "Ошибка валидации"
+// @Failure 403 {object} utils.Problem "Нет доступа к данным другого пользователя"
+// @Failure 500 {object} utils.Problem "Ошибка сервера"
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /subscriptions/upcoming [get]
+func (h *SubscriptionHandler) GetUpcoming(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if r.Method != http.MethodGet {
+		utils.MethodNotAllowed(w, r)
+		return
+	}
+	if !requireScope(w, r, models.ScopeSubscriptionsRead) {
+		return
+	}
+
+	query := r.URL.Query()
+	q := models.UpcomingQuery{
+		UserID:      query.Get("user_id"),
+		ServiceName: query.Get("service_name"),
+		Days:        defaultUpcomingDays,
+	}
+	if s := query.Get("days"); s != "" {
+		days, err := strconv.Atoi(s)
+		if err != nil || days < 1 || days > service.MaxUpcomingDays {
+			utils.InvalidField(w, r, "days", utils.MsgIntegerRange, "days", 1, service.MaxUpcomingDays)
+			return
+		}
+		q.Days = days
+	}
+
+	upcoming, err := h.Service.GetUpcoming(r.Context(), q)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	json.NewEncoder(w).Encode(upcoming)
+}
@@ -0,0 +1,111 @@
+// Package ical формирует календари iCalendar (RFC 5545) с событиями на весь день
+package ical
+
+import (
+	"bufio"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// ContentType - тип содержимого календаря
+const ContentType = "text/calendar; charset=utf-8"
+
+// Максимальная длина строки содержимого в октетах без CRLF (RFC 5545, 3.1)
+const maxLineOctets = 75
+
+// Event - событие на весь день, при необходимости повторяющееся
+type Event struct {
+	UID         string
+	Stamp       time.Time   // Момент формирования события (DTSTAMP)
+	Sequence    int         // Номер редакции события; растёт при изменении
+	Start       time.Time   // Дата первого повторения
+	RRule       string      // Правило повторения без префикса RRULE:; пусто - событие не повторяется
+	ExDates     []time.Time // Даты повторений, которые исключаются
+	Summary     string
+	Description string
+}
+
+// Writer пишет календарь потоком: заголовок - при создании, события - по одному, окончание - в Close
+type Writer struct {
+	out *bufio.Writer
+}
+
+// NewWriter начинает календарь; name - отображаемое название (X-WR-CALNAME), пусто - не указывается
+func NewWriter(w io.Writer, prodID, name string) *Writer {
+	cw := &Writer{out: bufio.NewWriter(w)}
+	cw.line("BEGIN:VCALENDAR")
+	cw.line("VERSION:2.0")
+	cw.line("PRODID:" + prodID)
+	cw.line("CALSCALE:GREGORIAN")
+	cw.line("METHOD:PUBLISH")
+	if name != "" {
+		cw.line("X-WR-CALNAME:" + EscapeText(name))
+	}
+	return cw
+}
+
+// WriteEvent пишет событие VEVENT
+func (cw *Writer) WriteEvent(e Event) error {
+	cw.line("BEGIN:VEVENT")
+	cw.line("UID:" + e.UID)
+	cw.line("DTSTAMP:" + e.Stamp.UTC().Format("20060102T150405Z"))
+	cw.line("SEQUENCE:" + strconv.Itoa(e.Sequence))
+	cw.line("DTSTART;VALUE=DATE:" + FormatDate(e.Start))
+	cw.line("DTEND;VALUE=DATE:" + FormatDate(e.Start.AddDate(0, 0, 1)))
+	if e.RRule != "" {
+		cw.line("RRULE:" + e.RRule)
+	}
+	if len(e.ExDates) > 0 {
+		dates := make([]string, len(e.ExDates))
+		for i, d := range e.ExDates {
+			dates[i] = FormatDate(d)
+		}
+		cw.line("EXDATE;VALUE=DATE:" + strings.Join(dates, ","))
+	}
+	cw.line("SUMMARY:" + EscapeText(e.Summary))
+	if e.Description != "" {
+		cw.line("DESCRIPTION:" + EscapeText(e.Description))
+	}
+	cw.line("TRANSP:TRANSPARENT")
+	return cw.line("END:VEVENT")
+}
+
+// Close дописывает окончание календаря
+func (cw *Writer) Close() error {
+	cw.line("END:VCALENDAR")
+	return cw.out.Flush()
+}
+
+// line пишет строку содержимого с CRLF, перенося её по 75 октетов (RFC 5545, 3.1).
+// Перенос не разрывает символы UTF-8; строка продолжения начинается с пробела.
+func (cw *Writer) line(s string) error {
+	limit := maxLineOctets
+	for len(s) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(s[cut]) {
+			cut--
+		}
+		cw.out.WriteString(s[:cut])
+		cw.out.WriteString("\r\n ")
+		s = s[cut:]
+		limit = maxLineOctets - 1 // Пробел в начале строки продолжения
+	}
+	cw.out.WriteString(s)
+	_, err := cw.out.WriteString("\r\n")
+	return err
+}
+
+// FormatDate возвращает дату в формате DATE (YYYYMMDD), в том числе для UNTIL правила повторения
+func FormatDate(t time.Time) string {
+	return t.Format("20060102")
+}
+
+var textEscaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`, "\r", `\n`)
+
+// EscapeText экранирует значение типа TEXT (RFC 5545, 3.3.11)
+func EscapeText(s string) string {
+	return textEscaper.Replace(s)
+}
@@ -0,0 +1,114 @@
+package ical
+
+import (
+	"bufio"
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+func TestEscapeText(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"Netflix", "Netflix"},
+		{"299.90 RUB; monthly, auto", `299.90 RUB\; monthly\, auto`},
+		{`C:\path`, `C:\\path`},
+		{"line1\nline2\r\nline3", `line1\nline2\nline3`},
+	}
+	for _, tt := range tests {
+		if got := EscapeText(tt.in); got != tt.want {
+			t.Errorf("EscapeText(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestLineFolding(t *testing.T) {
+	tests := []struct {
+		name, value string
+	}{
+		{"short", "Netflix"},
+		{"exactly one line", strings.Repeat("a", maxLineOctets-len("SUMMARY:"))},
+		{"ascii", strings.Repeat("abcdefghij", 20)},
+		{"cyrillic", strings.Repeat("Подписка продлевается ", 10)},
+		{"emoji", strings.Repeat("🎬", 50)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			cw := &Writer{out: bufio.NewWriter(&buf)}
+			cw.line("SUMMARY:" + tt.value)
+			cw.out.Flush()
+
+			out := buf.String()
+			if !strings.HasSuffix(out, "\r\n") {
+				t.Fatalf("line does not end with CRLF: %q", out)
+			}
+			lines := strings.Split(strings.TrimSuffix(out, "\r\n"), "\r\n")
+			for i, l := range lines {
+				if len(l) > maxLineOctets {
+					t.Errorf("line %d has %d octets", i, len(l))
+				}
+				if !utf8.ValidString(l) {
+					t.Errorf("line %d splits a UTF-8 character: %q", i, l)
+				}
+				if i > 0 && !strings.HasPrefix(l, " ") {
+					t.Errorf("continuation line %d does not start with a space", i)
+				}
+			}
+			// Развёртка (RFC 5545, 3.1) восстанавливает исходную строку
+			if unfolded := strings.ReplaceAll(strings.TrimSuffix(out, "\r\n"), "\r\n ", ""); unfolded != "SUMMARY:"+tt.value {
+				t.Errorf("unfolded = %q", unfolded)
+			}
+		})
+	}
+}
+
+func TestWriteEvent(t *testing.T) {
+	var buf bytes.Buffer
+	cw := NewWriter(&buf, "-//test//RU", "Продления")
+	err := cw.WriteEvent(Event{
+		UID:         "subscription-1@test",
+		Stamp:       time.Date(2026, 10, 17, 12, 30, 0, 0, time.FixedZone("MSK", 3*3600)),
+		Sequence:    2,
+		Start:       time.Date(2026, 1, 31, 0, 0, 0, 0, time.UTC),
+		RRule:       "FREQ=MONTHLY;BYMONTHDAY=28,29,30,31;BYSETPOS=-1",
+		ExDates:     []time.Time{time.Date(2026, 3, 31, 0, 0, 0, 0, time.UTC), time.Date(2026, 4, 30, 0, 0, 0, 0, time.UTC)},
+		Summary:     "Okko",
+		Description: "299.90 RUB, monthly",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := strings.Join([]string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"PRODID:-//test//RU",
+		"CALSCALE:GREGORIAN",
+		"METHOD:PUBLISH",
+		"X-WR-CALNAME:Продления",
+		"BEGIN:VEVENT",
+		"UID:subscription-1@test",
+		"DTSTAMP:20261017T093000Z",
+		"SEQUENCE:2",
+		"DTSTART;VALUE=DATE:20260131",
+		"DTEND;VALUE=DATE:20260201",
+		"RRULE:FREQ=MONTHLY;BYMONTHDAY=28,29,30,31;BYSETPOS=-1",
+		"EXDATE;VALUE=DATE:20260331,20260430",
+		"SUMMARY:Okko",
+		`DESCRIPTION:299.90 RUB\, monthly`,
+		"TRANSP:TRANSPARENT",
+		"END:VEVENT",
+		"END:VCALENDAR",
+		"",
+	}, "\r\n")
+	if got := buf.String(); got != want {
+		t.Errorf("calendar =\n%s\nwant\n%s", got, want)
+	}
+}
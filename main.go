@@ -1,14 +1,18 @@
 package main
 
 import (
+	"context"
 	"net/http"
 	"os"
+	"time"
 	"usersubs/database"
 	_ "usersubs/docs"
 	"usersubs/handler"
 	"usersubs/logger"
+	"usersubs/repository"
 	"usersubs/service"
 
+	"github.com/joho/godotenv"
 	httpSwagger "github.com/swaggo/http-swagger"
 	"go.uber.org/zap"
 )
@@ -17,6 +21,13 @@ import (
 // @version 1.0
 // @description Сервер аггрегации данных об онлайн подписках пользователей.
 // @host localhost:8080
+// @securityDefinitions.apikey ApiKeyAuth
+// @in header
+// @name X-API-Key
+// @securityDefinitions.apikey BearerAuth
+// @in header
+// @name Authorization
+// @description JWT в формате "Bearer <token>"
 
 func main() {
 	err := logger.Init(false)
@@ -27,32 +38,123 @@ func main() {
 
 	logger.L().Info("Запуск сервера")
 
-	db, err := db.ConnectDB()
-	if err != nil {
-		logger.L().Fatal("Не удалось подключиться к БД", zap.Error(err))
+	// Переменные окружения из .env (файл необязателен при запуске без docker-compose)
+	if err := godotenv.Load(); err != nil {
+		logger.L().Warn("Файл .env не загружен", zap.Error(err))
+	}
+
+	// Хранилище: postgres (по умолчанию) или memory - для локального запуска и демо
+	var repo repository.Storage
+	switch storage := os.Getenv("STORAGE"); storage {
+	case "", "postgres":
+		db, err := db.ConnectDB()
+		if err != nil {
+			logger.L().Fatal("Не удалось подключиться к БД", zap.Error(err))
+		}
+		defer db.Close()
+
+		logger.L().Info("Сервер подключился к PostgreSQL")
+		repo = repository.NewPostgresRepository(db)
+	case "memory":
+		logger.L().Warn("Используется хранилище в памяти, данные не сохраняются между запусками")
+		repo = repository.NewMemoryRepository()
+	default:
+		logger.L().Fatal("Неизвестный тип хранилища", zap.String("STORAGE", storage))
+	}
+
+	subService := service.NewSubscriptionsService(repo, repo, repo)
+	idempotencyService := service.NewIdempotencyService(repo, durationEnv("IDEMPOTENCY_TTL", 24*time.Hour))
+	subHandler := handler.NewSubscriptionHandler(subService, idempotencyService)
+
+	// Фоновая очистка мягко удалённых подписок
+	retention := durationEnv("SOFT_DELETE_RETENTION", 30*24*time.Hour)
+	purgeInterval := durationEnv("PURGE_INTERVAL", time.Hour)
+	if purgeInterval == 0 {
+		logger.L().Fatal("PURGE_INTERVAL должен быть больше нуля")
+	}
+	if retention > 0 {
+		go subService.RunPurge(context.Background(), retention, purgeInterval)
 	}
-	defer db.Close()
+	go idempotencyService.RunCleanup(context.Background(), purgeInterval)
+	rateHandler := handler.NewExchangeRateHandler(service.NewExchangeRateService(repo))
 
-	logger.L().Info("Сервер подключился к PostgreSQL")
-	
-	subService := service.NewSubscriptionsService(db)
-	subHandler := handler.NewSubscriptionHandler(subService)
+	// ADMIN_API_KEY - ключ администратора для первоначальной выдачи ключей через /admin/api-keys
+	adminKey := os.Getenv("ADMIN_API_KEY")
+	if adminKey == "" {
+		logger.L().Warn("ADMIN_API_KEY не задан: административный доступ только по ключам из хранилища")
+	}
+	// CALENDAR_TOKEN_SECRET - секрет подписи ссылок на календарь продлений; смена секрета отзывает все ссылки
+	calendarKey := os.Getenv("CALENDAR_TOKEN_SECRET")
+	if calendarKey == "" {
+		logger.L().Info("CALENDAR_TOKEN_SECRET не задан: календарь продлений доступен только с учётными данными")
+	}
+	authService := service.NewAuthService(repo, adminKey, tokenVerifier(), []byte(calendarKey))
+	keyHandler := handler.NewAPIKeyHandler(authService)
+	calendarHandler := handler.NewCalendarHandler(subService, authService)
 
 	mux := http.NewServeMux()
 	mux.Handle("/swagger/", httpSwagger.WrapHandler)
-	
+
 	mux.HandleFunc("/subscriptions", subHandler.HandleSubscriptions)
 	mux.HandleFunc("/subscriptions/", subHandler.HandleSubscriptionsByID)
 	mux.HandleFunc("/subscriptions/total-cost", subHandler.GetTotalCost)
+	mux.HandleFunc("/subscriptions/cost-breakdown", subHandler.GetCostBreakdown)
+	mux.HandleFunc("/subscriptions/batch", subHandler.HandleBatch)
+	mux.HandleFunc("/subscriptions/import", subHandler.HandleImport)
+	mux.HandleFunc("/subscriptions/export", subHandler.ExportSubscriptions)
+	mux.HandleFunc("/subscriptions/cost-breakdown/export", subHandler.ExportCostBreakdown)
+	mux.HandleFunc("/subscriptions/upcoming", subHandler.GetUpcoming)
+	mux.HandleFunc("/subscriptions/forecast", subHandler.GetForecast)
+	mux.HandleFunc("/users/", calendarHandler.HandleUsers)
 
+	mux.HandleFunc("/admin/exchange-rates", handler.RequireAdmin(rateHandler.HandleExchangeRates))
+	mux.HandleFunc("/admin/audit", handler.RequireAdmin(subHandler.ListAudit))
+	mux.HandleFunc("/admin/api-keys", handler.RequireAdmin(keyHandler.HandleAPIKeys))
+	mux.HandleFunc("/admin/api-keys/", handler.RequireAdmin(keyHandler.HandleAPIKeys))
 
 	port := os.Getenv("PORT")
 	if port == "" {
 		port = "8080"
 	}
 	logger.L().Info("Запуск сервера", zap.String("port", port))
-	err = http.ListenAndServe(":"+port, mux)
+	err = http.ListenAndServe(":"+port, handler.Authenticate(authService, mux))
 	if err != nil {
 		logger.L().Fatal("Ошибка запуска сервера", zap.Error(err))
 	}
 }
+
+// durationEnv читает длительность (например, 720h) из переменной окружения; при отсутствии - def
+func durationEnv(name string, def time.Duration) time.Duration {
+	s := os.Getenv(name)
+	if s == "" {
+		return def
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d < 0 {
+		logger.L().Fatal("Неверная длительность в переменной окружения", zap.String(name, s))
+	}
+	return d
+}
+
+// tokenVerifier настраивает проверку JWT: ключи RS256/ES256 из файла JWKS (JWT_JWKS_FILE)
+// и/или секрет HS256 (JWT_HS256_SECRET). Возвращает nil, если ни то, ни другое не задано.
+func tokenVerifier() *service.TokenVerifier {
+	cfg := service.TokenConfig{
+		HMACKey:   []byte(os.Getenv("JWT_HS256_SECRET")),
+		Issuer:    os.Getenv("JWT_ISSUER"),
+		Audience:  os.Getenv("JWT_AUDIENCE"),
+		UserClaim: os.Getenv("JWT_USER_CLAIM"),
+	}
+	if path := os.Getenv("JWT_JWKS_FILE"); path != "" {
+		jwks, err := service.LoadJWKSFile(path)
+		if err != nil {
+			logger.L().Fatal("Не удалось загрузить JWKS", zap.String("path", path), zap.Error(err))
+		}
+		cfg.JWKS = jwks
+	}
+	if cfg.JWKS == nil && len(cfg.HMACKey) == 0 {
+		logger.L().Info("JWT не настроен: доступ только по API-ключам")
+		return nil
+	}
+	return service.NewTokenVerifier(cfg)
+}
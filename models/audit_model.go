@@ -0,0 +1,38 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Операции журнала изменений
+const (
+	AuditCreate  = "create"
+	AuditUpdate  = "update"
+	AuditDelete  = "delete"
+	AuditRestore = "restore"
+	AuditPurge   = "purge" // Окончательное удаление фоновой очисткой
+)
+
+// AuditEntry - запись журнала изменений подписки.
+// Before пуст у создания, After - у удаления.
+type AuditEntry struct {
+	ID             int64           `json:"id"`
+	SubscriptionID int             `json:"subscription_id"`
+	UserID         string          `json:"user_id"` // Владелец подписки
+	Actor          string          `json:"actor"`   // Кто выполнил изменение
+	Operation      string          `json:"operation" enums:"create,update,delete,restore,purge"`
+	ChangedAt      time.Time       `json:"changed_at"`
+	Before         json.RawMessage `json:"before" swaggertype:"object"`
+	After          json.RawMessage `json:"after" swaggertype:"object"`
+}
+
+// AuditFilter - параметры выборки журнала. Записи возвращаются от новых к старым.
+type AuditFilter struct {
+	SubscriptionID int
+	UserID         string
+	From           *time.Time // changed_at >= From
+	To             *time.Time // changed_at < To
+	BeforeID       int64      // Только записи с ID меньше указанного (постраничный просмотр)
+	Limit          int
+}
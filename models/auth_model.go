@@ -0,0 +1,67 @@
+package models
+
+import "time"
+
+// Роли API-ключей
+const (
+	RoleAdmin = "admin" // Доступ ко всем подпискам и административным методам
+	RoleUser  = "user"  // Доступ только к подпискам своего user_id
+)
+
+// Области доступа (scope) JWT
+const (
+	ScopeSubscriptionsRead  = "subscriptions:read"
+	ScopeSubscriptionsWrite = "subscriptions:write"
+	ScopeAdmin              = "admin" // Роль администратора
+)
+
+// Principal - аутентифицированный клиент запроса
+type Principal struct {
+	Subject string // Идентификатор для журнала изменений, например api_key:12
+	Role    string
+	UserID  string // Для RoleUser - пользователь, к подпискам которого есть доступ
+	// Scopes - области доступа токена; nil - без ограничений (API-ключи)
+	Scopes []string
+}
+
+// IsAdmin проверяет, что клиенту доступны все подписки
+func (p Principal) IsAdmin() bool {
+	return p.Role == RoleAdmin
+}
+
+// HasScope проверяет, что клиенту разрешена область доступа scope
+func (p Principal) HasScope(scope string) bool {
+	if p.Scopes == nil {
+		return true
+	}
+	for _, s := range p.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// APIKey - API-ключ без секрета; в хранилище лежит только хеш ключа
+type APIKey struct {
+	ID        int        `json:"id"`
+	Name      string     `json:"name"`
+	Prefix    string     `json:"prefix"` // Начало ключа для опознания в списке
+	Role      string     `json:"role" enums:"admin,user"`
+	UserID    string     `json:"user_id,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+}
+
+// APIKeyInput - тело запроса на создание ключа. Для роли user обязателен user_id, для admin он не указывается.
+type APIKeyInput struct {
+	Name   string `json:"name" example:"mobile-app"`
+	Role   string `json:"role" enums:"admin,user" example:"user"`
+	UserID string `json:"user_id,omitempty" example:"60601fee-2bf1-4721-ae6f-7636e79a0cba"`
+}
+
+// CreatedAPIKey - созданный ключ; секрет Key возвращается только один раз
+type CreatedAPIKey struct {
+	APIKey
+	Key string `json:"key"`
+}
@@ -0,0 +1,65 @@
+package models
+
+import (
+	"encoding/json"
+	"usersubs/utils"
+)
+
+// Операции пакетного запроса
+const (
+	BatchCreate = "create"
+	BatchUpdate = "update"
+	BatchDelete = "delete"
+)
+
+// Режимы пакетного запроса
+const (
+	// Все операции в одной транзакции: ошибка любой операции отменяет весь пакет
+	BatchAtomic = "atomic"
+	// Операции выполняются независимо: ошибка одной не мешает остальным
+	BatchBestEffort = "best_effort"
+)
+
+// BatchInput - тело POST /subscriptions/batch
+type BatchInput struct {
+	Mode       string                `json:"mode" example:"atomic"` // atomic (по умолчанию) или best_effort
+	Operations []BatchOperationInput `json:"operations"`
+}
+
+// BatchOperationInput - одна операция пакета
+type BatchOperationInput struct {
+	Op      string          `json:"op" example:"create"`                 // create, update или delete
+	ID      int             `json:"id,omitempty"`                        // ID подписки для update и delete
+	Version *int            `json:"version,omitempty"`                   // Ожидаемая версия записи (аналог If-Match)
+	Data    json.RawMessage `json:"data,omitempty" swaggertype:"object"` // create - данные подписки, update - merge patch
+}
+
+// BatchOperation - разобранная операция пакета
+type BatchOperation struct {
+	Op           string
+	ID           int
+	IfMatch      []int              // nil - без проверки версии
+	Subscription Subscription       // Данные новой подписки для create
+	Update       UpdateSubscription // Изменение для update
+	Err          error              // Ошибка разбора: операция не выполняется
+}
+
+// BatchResult - итог одной операции пакета
+type BatchResult struct {
+	Index        int            `json:"index"`                  // Позиция операции в запросе
+	Op           string         `json:"op" example:"create"`    // Операция
+	Status       int            `json:"status" example:"200"`   // HTTP-статус, который получила бы операция отдельным запросом
+	ID           int            `json:"id,omitempty"`           // ID созданной, изменённой или удалённой подписки
+	Subscription *Subscription  `json:"subscription,omitempty"` // Подписка после update
+	Error        *utils.Problem `json:"error,omitempty"`        // Ошибка операции
+
+	Err error `json:"-"` // Ошибка выполнения; по ней заполняются Status и Error
+}
+
+// BatchResponse - ответ на пакетный запрос
+type BatchResponse struct {
+	Mode      string        `json:"mode" example:"atomic"`
+	Succeeded int           `json:"succeeded"` // Число выполненных операций
+	Failed    int           `json:"failed"`    // Число операций с ошибкой или отменённых
+	Results   []BatchResult `json:"results"`
+}
@@ -0,0 +1,8 @@
+package models
+
+// CalendarFeed - секретная ссылка на календарь продлений пользователя.
+// Ссылка открывается без учётных данных, поэтому её нужно хранить как пароль.
+type CalendarFeed struct {
+	URL   string `json:"url" example:"https://example.com/users/60601fee-2bf1-4721-ae6f-7636e79a0cba/renewals.ics?token=cal_..."`
+	Token string `json:"token" example:"cal_..."`
+}
@@ -0,0 +1,61 @@
+package models
+
+import "time"
+
+// Допустимые значения группировки помесячной разбивки
+const (
+	GroupByServiceName = "service_name"
+	GroupByUserID      = "user_id"
+)
+
+// Режимы подсчёта стоимости
+const (
+	// Фактические списания, попавшие в период (годовая подписка учитывается в месяце списания)
+	CostModeCharges = "charges"
+	// Цена, приведённая к месяцу, умноженная на число активных месяцев
+	CostModeMonthlyEquivalent = "monthly_equivalent"
+)
+
+// CostQuery - параметры подсчёта стоимости за период
+type CostQuery struct {
+	UserID      string
+	ServiceName string    // Подстрока названия сервиса
+	From        time.Time // Первый день периода
+	To          time.Time // Последний день периода (включительно)
+	Mode        string    // CostModeCharges или CostModeMonthlyEquivalent
+	Proration   string    // ProrationNone или ProrationDaily (только для CostModeCharges)
+	Currency    string    // Валюта результата
+	GroupBy     string    // Только для разбивки: пусто, GroupByServiceName или GroupByUserID
+	// IncludeDeleted - учитывать мягко удалённые подписки
+	IncludeDeleted bool
+}
+
+// TotalCost - суммарная стоимость за период
+type TotalCost struct {
+	TotalCost Money  `json:"total_cost" swaggertype:"string" example:"900.00"`
+	Currency  string `json:"currency"`
+}
+
+// Режимы пропорционального учёта
+const (
+	// Списание учитывается целиком в месяце, на который приходится дата списания
+	ProrationNone = "none"
+	// Стоимость периода распределяется по дням: учитываются только дни, когда подписка активна и попадает в период
+	ProrationDaily = "daily"
+)
+
+// MonthlyCost - стоимость подписок за один месяц.
+// Суммы округляются независимо, поэтому в режиме monthly_equivalent их сумма может отличаться от Total из-за округления.
+type MonthlyCost struct {
+	Month  string           `json:"month"`                                        // Месяц в формате MM-YYYY
+	Total  Money            `json:"total" swaggertype:"string" example:"900.00"`  // Сумма за месяц
+	Groups map[string]Money `json:"groups,omitempty" swaggertype:"object,string"` // Сумма по значениям group_by
+}
+
+// CostBreakdown - помесячная разбивка стоимости за период
+type CostBreakdown struct {
+	GroupBy  string        `json:"group_by,omitempty"`
+	Currency string        `json:"currency"`
+	Months   []MonthlyCost `json:"months"`
+	Total    Money         `json:"total" swaggertype:"string" example:"900.00"` // Совпадает с total_cost за тот же период и режим
+}
@@ -0,0 +1,47 @@
+package models
+
+import (
+	"regexp"
+	"time"
+)
+
+// BaseCurrency - валюта, к которой привязаны курсы (как у ЦБ РФ)
+const BaseCurrency = "RUB"
+
+var currencyCodeRe = regexp.MustCompile(`^[A-Z]{3}$`)
+
+// Валюты ISO 4217, у которых не два знака после запятой: Money хранит сумму в сотых долях,
+// поэтому цены и итоги в этих валютах не поддерживаются
+var nonDecimalCurrencies = map[string]bool{
+	// Без дробной части
+	"BIF": true, "CLP": true, "DJF": true, "GNF": true, "ISK": true, "JPY": true, "KMF": true, "KRW": true,
+	"PYG": true, "RWF": true, "UGX": true, "UYI": true, "VND": true, "VUV": true, "XAF": true, "XOF": true, "XPF": true,
+	// Три и четыре знака после запятой
+	"BHD": true, "IQD": true, "JOD": true, "KWD": true, "LYD": true, "OMR": true, "TND": true, "CLF": true, "UYW": true,
+	// Драгоценные металлы и расчётные единицы без минимальной единицы
+	"XAG": true, "XAU": true, "XBA": true, "XBB": true, "XBC": true, "XBD": true, "XDR": true, "XPD": true,
+	"XPT": true, "XSU": true, "XTS": true, "XUA": true, "XXX": true,
+}
+
+// IsCurrencyCode проверяет формат кода валюты ISO 4217
+func IsCurrencyCode(code string) bool {
+	return currencyCodeRe.MatchString(code)
+}
+
+// IsValidCurrency проверяет, что код валюты ISO 4217 допустим для цен и итогов:
+// у валюты два знака после запятой, как у Money
+func IsValidCurrency(code string) bool {
+	return IsCurrencyCode(code) && !nonDecimalCurrencies[code]
+}
+
+// ExchangeRate - курс валюты на дату: сколько рублей стоит одна единица валюты
+type ExchangeRate struct {
+	Currency string    `json:"currency"` // Код валюты ISO 4217
+	Date     time.Time `json:"date"`     // Дата, с которой действует курс
+	Rate     string    `json:"rate"`     // Рублей за единицу валюты, десятичная строка
+}
+
+// RatesImportResult - результат загрузки курсов
+type RatesImportResult struct {
+	Imported int `json:"imported"` // Количество загруженных (добавленных или обновлённых) курсов
+}
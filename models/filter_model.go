@@ -0,0 +1,46 @@
+package models
+
+import "time"
+
+// Поля, по которым допускается сортировка списка подписок
+const (
+	SortByID          = "id"
+	SortByPrice       = "price"
+	SortByStartDate   = "start_date"
+	SortByServiceName = "service_name"
+)
+
+// SubscriptionFilter - параметры выборки для GET /subscriptions
+type SubscriptionFilter struct {
+	UserID              string
+	ServiceName         string     // Точное совпадение
+	ServiceNameContains string     // Поиск по подстроке без учёта регистра
+	ActiveFrom          *time.Time // Подписка активна хотя бы в одном месяце периода [ActiveFrom, ActiveTo]
+	ActiveTo            *time.Time
+	PriceMin            *Money
+	PriceMax            *Money
+	// PriceDate - дата, на которую берётся цена для PriceMin, PriceMax и SortByPrice (с учётом истории цен);
+	// нулевая - хранимая цена
+	PriceDate      time.Time
+	SortBy         string
+	SortDesc       bool
+	After          *Cursor // Позиция, после которой начинается страница
+	Limit          int
+	IncludeDeleted bool // Включать мягко удалённые записи
+}
+
+// Cursor - позиция в отсортированном списке: значение поля сортировки и ID последней записи страницы
+type Cursor struct {
+	SortBy      string    `json:"s"`
+	Desc        bool      `json:"d,omitempty"`
+	ID          int       `json:"id"`
+	Price       Money     `json:"p,omitempty"`
+	StartDate   time.Time `json:"sd,omitempty"`
+	ServiceName string    `json:"sn,omitempty"`
+}
+
+// SubscriptionPage - страница списка подписок
+type SubscriptionPage struct {
+	Items      []Subscription `json:"items"`
+	NextCursor string         `json:"next_cursor,omitempty"` // Пусто, если страница последняя
+}
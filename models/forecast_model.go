@@ -0,0 +1,40 @@
+package models
+
+import (
+	"math/big"
+	"strings"
+)
+
+// ForecastQuery - параметры прогноза расходов на подписки
+type ForecastQuery struct {
+	UserID      string
+	ServiceName string // Подстрока названия сервиса
+	Months      int    // Число месяцев прогноза, начиная с текущего
+	Currency    string // Валюта результата
+	// Inflation - годовой рост цен по сервисам (0.05 = 5%); ключ - название сервиса в нижнем регистре
+	Inflation map[string]*big.Rat
+	// DefaultInflation - годовой рост цен сервисов, которых нет в Inflation; nil - цены не растут
+	DefaultInflation *big.Rat
+}
+
+// InflationFor возвращает годовой рост цен сервиса; nil - цены не растут
+func (q ForecastQuery) InflationFor(serviceName string) *big.Rat {
+	if rate, ok := q.Inflation[strings.ToLower(serviceName)]; ok {
+		return rate
+	}
+	return q.DefaultInflation
+}
+
+// ForecastMonth - прогноз расходов за один месяц
+type ForecastMonth struct {
+	Month      string `json:"month" example:"11-2026"`                           // Месяц в формате MM-YYYY
+	Total      Money  `json:"total" swaggertype:"string" example:"900.00"`       // Списания за месяц
+	Cumulative Money  `json:"cumulative" swaggertype:"string" example:"1800.00"` // Списания с начала прогноза по этот месяц включительно
+}
+
+// Forecast - помесячный прогноз расходов на подписки
+type Forecast struct {
+	Currency string          `json:"currency"`
+	Months   []ForecastMonth `json:"months"`
+	Total    Money           `json:"total" swaggertype:"string" example:"10800.00"` // Сумма за все месяцы прогноза
+}
@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+// IdempotencyRecord - запрос с заголовком Idempotency-Key и сохранённый ответ на него
+type IdempotencyRecord struct {
+	Key         string
+	Owner       string // Пользователь (или клиент) запроса: один ключ у разных пользователей не конфликтует
+	RequestHash string // Хеш тела запроса: повтор ключа с другим телом отклоняется
+	StatusCode  int    // 0 - первый запрос ещё выполняется
+	ContentType string
+	Body        []byte
+	ExpiresAt   time.Time
+}
+
+// Completed проверяет, что ответ на запрос сохранён
+func (r IdempotencyRecord) Completed() bool {
+	return r.StatusCode != 0
+}
@@ -0,0 +1,61 @@
+package models
+
+import "usersubs/utils"
+
+// Форматы файла импорта подписок
+const (
+	ImportFormatCSV   = "csv"   // Заголовок и колонки как у полей SubscriptionInput
+	ImportFormatJSONL = "jsonl" // JSON Lines: по одному объекту SubscriptionInput в строке
+)
+
+// Поведение при совпадении строки импорта с существующей подпиской по (user_id, service_name, start_date)
+const (
+	ConflictSkip      = "skip"      // Оставить существующую подписку
+	ConflictOverwrite = "overwrite" // Заменить существующую подписку данными строки
+	ConflictFail      = "fail"      // Считать строку ошибкой
+)
+
+// Итог обработки строки импорта
+const (
+	ImportCreated = "created"
+	ImportUpdated = "updated"
+	ImportSkipped = "skipped"
+	ImportFailed  = "failed"
+)
+
+// ImportOptions - параметры импорта подписок
+type ImportOptions struct {
+	Format     string
+	DryRun     bool   // Только проверить файл, ничего не сохраняя
+	OnConflict string // ConflictSkip, ConflictOverwrite или ConflictFail
+}
+
+// ImportResult - итог импорта подписок
+type ImportResult struct {
+	DryRun  bool `json:"dry_run"`
+	Rows    int  `json:"rows"`    // Число обработанных строк данных
+	Created int  `json:"created"` // Создано подписок (при dry_run - было бы создано)
+	Updated int  `json:"updated"` // Заменено существующих подписок
+	Skipped int  `json:"skipped"` // Пропущено из-за совпадения с существующей подпиской
+	Failed  int  `json:"failed"`  // Строк с ошибками
+	// Results - итог каждой строки в порядке файла, включая уже сохранённые
+	Results   []ImportRowResult `json:"results"`
+	Truncated bool              `json:"truncated,omitempty"` // В results попали не все строки
+	// Error - импорт прерван после строки Rows; строки до неё уже сохранены и перечислены в results
+	Error *utils.Problem `json:"error,omitempty"`
+}
+
+// ImportRowResult - итог строки импорта
+type ImportRowResult struct {
+	Line           int              `json:"line" example:"3"` // Номер строки файла, начиная с 1
+	Status         string           `json:"status" enums:"created,updated,skipped,failed"`
+	SubscriptionID int              `json:"subscription_id,omitempty"` // Созданная или заменённая подписка (не при dry_run)
+	Errors         []ImportRowError `json:"errors,omitempty"`          // Ошибки строки со статусом failed
+}
+
+// ImportRowError - ошибка строки импорта; строка с несколькими некорректными полями даёт несколько ошибок
+type ImportRowError struct {
+	Field   string `json:"field,omitempty" example:"price"`  // Поле строки; пусто - ошибка всей строки
+	Code    string `json:"code" example:"validation_failed"` // Машиночитаемый код ошибки
+	Message string `json:"message"`
+}
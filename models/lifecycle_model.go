@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+// Состояния подписки
+const (
+	StatusActive    = "active"
+	StatusPaused    = "paused"
+	StatusCancelled = "cancelled"
+)
+
+// Pause - интервал приостановки подписки [PausedOn, ResumedOn)
+type Pause struct {
+	ID        int        `json:"id"`
+	PausedOn  time.Time  `json:"paused_on"`  // Первый день паузы
+	ResumedOn *time.Time `json:"resumed_on"` // Первый активный день после паузы; null - пауза ещё длится
+}
+
+// Covers проверяет, что день приходится на паузу
+func (p Pause) Covers(day time.Time) bool {
+	return !day.Before(p.PausedOn) && (p.ResumedOn == nil || day.Before(*p.ResumedOn))
+}
+
+// LifecycleInput - тело запросов pause/resume/cancel
+type LifecycleInput struct {
+	Date string `json:"date" example:"2025-07-15"` // YYYY-MM-DD, по умолчанию сегодня
+}
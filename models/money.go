@@ -0,0 +1,88 @@
+package models
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Money - денежная сумма в минимальных единицах валюты (копейках, центах).
+// В JSON передаётся десятичной строкой с двумя знаками после точки: "299.90".
+type Money int64
+
+var ErrInvalidMoney = errors.New("некорректная сумма")
+
+// ParseMoney разбирает десятичную запись суммы: "299", "299.9", "299.90", "-5.5".
+// В качестве разделителя допускается запятая. Больше двух знаков после разделителя - ошибка.
+func ParseMoney(s string) (Money, error) {
+	s = strings.TrimSpace(s)
+	negative := strings.HasPrefix(s, "-")
+	digits := strings.TrimPrefix(s, "-")
+
+	whole, frac, hasFrac := strings.Cut(strings.Replace(digits, ",", ".", 1), ".")
+	if whole == "" || (hasFrac && frac == "") || len(frac) > 2 || !isDigits(whole) || !isDigits(frac) {
+		return 0, fmt.Errorf("%w %q: ожидается число с не более чем двумя знаками после точки", ErrInvalidMoney, s)
+	}
+	for len(frac) < 2 {
+		frac += "0"
+	}
+
+	units, err := strconv.ParseInt(whole, 10, 64)
+	if err != nil || units > (math.MaxInt64-99)/100 {
+		return 0, fmt.Errorf("%w %q: слишком большое значение", ErrInvalidMoney, s)
+	}
+	cents, _ := strconv.ParseInt(frac, 10, 64)
+
+	amount := Money(units*100 + cents)
+	if negative {
+		amount = -amount
+	}
+	return amount, nil
+}
+
+func isDigits(s string) bool {
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// String возвращает десятичную запись суммы: 29990 -> "299.90"
+func (m Money) String() string {
+	sign := ""
+	v := int64(m)
+	if v < 0 {
+		sign = "-"
+		v = -v
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, v/100, v%100)
+}
+
+func (m Money) MarshalJSON() ([]byte, error) {
+	return json.Marshal(m.String())
+}
+
+// UnmarshalJSON принимает как строку "299.90", так и число 299.90 (без преобразования во float)
+func (m *Money) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(data, []byte("null")) {
+		return nil
+	}
+	s := string(data)
+	if len(data) > 0 && data[0] == '"' {
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+	}
+	amount, err := ParseMoney(s)
+	if err != nil {
+		return err
+	}
+	*m = amount
+	return nil
+}
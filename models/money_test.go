@@ -0,0 +1,89 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestParseMoney(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Money
+		wantErr bool
+	}{
+		{in: "299", want: 29900},
+		{in: "299.9", want: 29990},
+		{in: "299.90", want: 29990},
+		{in: "299,90", want: 29990},
+		{in: " 0.05 ", want: 5},
+		{in: "-5.5", want: -550},
+		{in: "1.234", wantErr: true},
+		{in: "1.", wantErr: true},
+		{in: ".5", wantErr: true},
+		{in: "1e3", wantErr: true},
+		{in: "", wantErr: true},
+		{in: "92233720368547758.07", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseMoney(tt.in)
+		if tt.wantErr {
+			if !errors.Is(err, ErrInvalidMoney) {
+				t.Errorf("ParseMoney(%q) error = %v, want ErrInvalidMoney", tt.in, err)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("ParseMoney(%q) = %d, %v, want %d", tt.in, got, err, tt.want)
+		}
+	}
+}
+
+func TestMoneyJSON(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Money
+		out  string
+	}{
+		{`"299.90"`, 29990, `"299.90"`},
+		{`299.9`, 29990, `"299.90"`},
+		{`"-0.05"`, -5, `"-0.05"`},
+		{`0`, 0, `"0.00"`},
+	}
+	for _, tt := range tests {
+		var m Money
+		if err := json.Unmarshal([]byte(tt.in), &m); err != nil || m != tt.want {
+			t.Errorf("Unmarshal(%s) = %d, %v, want %d", tt.in, m, err, tt.want)
+			continue
+		}
+		if out, _ := json.Marshal(m); string(out) != tt.out {
+			t.Errorf("Marshal(%d) = %s, want %s", m, out, tt.out)
+		}
+	}
+}
+
+func TestIsValidCurrency(t *testing.T) {
+	tests := []struct {
+		code          string
+		valid, format bool
+	}{
+		{"RUB", true, true},
+		{"USD", true, true},
+		{"EUR", true, true},
+		{"JPY", false, true},
+		{"KRW", false, true},
+		{"KWD", false, true},
+		{"XAU", false, true},
+		{"usd", false, false},
+		{"US", false, false},
+		{"RUBL", false, false},
+	}
+	for _, tt := range tests {
+		if got := IsValidCurrency(tt.code); got != tt.valid {
+			t.Errorf("IsValidCurrency(%q) = %v, want %v", tt.code, got, tt.valid)
+		}
+		if got := IsCurrencyCode(tt.code); got != tt.format {
+			t.Errorf("IsCurrencyCode(%q) = %v, want %v", tt.code, got, tt.format)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package models
+
+import (
+	"sort"
+	"time"
+)
+
+// PriceChange - цена подписки, действующая с первого дня EffectiveMonth до следующего изменения
+type PriceChange struct {
+	ID             int       `json:"id"`
+	EffectiveMonth time.Time `json:"effective_month"`                             // Первое число месяца
+	Price          Money     `json:"price" swaggertype:"string" example:"299.90"` // Цена за период списания
+}
+
+// PriceChangeInput - тело запроса на изменение цены
+type PriceChangeInput struct {
+	Price          Money  `json:"price" swaggertype:"string" example:"349.90"`
+	EffectiveMonth string `json:"effective_month" example:"09-2025"` // MM-YYYY
+}
+
+// PriceAt возвращает цену, действующую в указанный день.
+// До первого изменения действует первая цена истории; без истории - Price.
+func (s Subscription) PriceAt(day time.Time) Money {
+	if len(s.PriceHistory) == 0 {
+		return s.Price
+	}
+	price := s.PriceHistory[0].Price
+	for _, change := range s.PriceHistory {
+		if change.EffectiveMonth.After(day) {
+			break
+		}
+		price = change.Price
+	}
+	return price
+}
+
+// SetPriceChange добавляет изменение цены или заменяет цену изменения с тем же месяцем.
+// История остаётся упорядоченной по EffectiveMonth.
+func (s *Subscription) SetPriceChange(month time.Time, price Money) {
+	for i := range s.PriceHistory {
+		if s.PriceHistory[i].EffectiveMonth.Equal(month) {
+			s.PriceHistory[i].Price = price
+			return
+		}
+	}
+	s.PriceHistory = append(s.PriceHistory, PriceChange{EffectiveMonth: month, Price: price})
+	sort.Slice(s.PriceHistory, func(i, j int) bool {
+		return s.PriceHistory[i].EffectiveMonth.Before(s.PriceHistory[j].EffectiveMonth)
+	})
+}
@@ -2,35 +2,81 @@ package models
 
 import "time"
 
+// Периоды списания оплаты
+const (
+	BillingWeekly    = "weekly"
+	BillingMonthly   = "monthly"
+	BillingQuarterly = "quarterly"
+	BillingYearly    = "yearly"
+)
+
+// IsValidBillingPeriod проверяет, что период списания поддерживается
+func IsValidBillingPeriod(period string) bool {
+	switch period {
+	case BillingWeekly, BillingMonthly, BillingQuarterly, BillingYearly:
+		return true
+	}
+	return false
+}
+
 type Subscription struct {
-	ID          int        //Primary key
-	ServiceName string     `json:"service_name"` // Название сервиса
-	Price       int        `json:"price"`        // Стоимость в рублях
-	UserID      string     `json:"user_id"`      // UUID пользователя
-	StartDate   time.Time  `json:"start_date"`   // Дата начала
-	EndDate     *time.Time `json:"end_date"`     // Опционально: дата окончания
+	ID            int           //Primary key
+	ServiceName   string        `json:"service_name"`                                // Название сервиса
+	Price         Money         `json:"price" swaggertype:"string" example:"299.90"` // Действующая стоимость за один период списания в валюте currency
+	Currency      string        `json:"currency"`                                    // Код валюты ISO 4217 с двумя знаками после запятой, например RUB, USD, EUR
+	BillingPeriod string        `json:"billing_period"`                              // Период списания: weekly, monthly, quarterly, yearly
+	UserID        string        `json:"user_id"`                                     // UUID пользователя
+	StartDate     time.Time     `json:"start_date"`                                  // Дата начала (дата первого списания)
+	EndDate       *time.Time    `json:"end_date"`                                    // Опционально: дата окончания (последний день действия)
+	Status        string        `json:"status"`                                      // Состояние: active, paused, cancelled
+	Pauses        []Pause       `json:"pauses,omitempty"`                            // Интервалы приостановки по возрастанию даты
+	PriceHistory  []PriceChange `json:"price_history,omitempty"`                     // Изменения цены по возрастанию месяца
+	DeletedAt     *time.Time    `json:"deleted_at,omitempty"`                        // Момент мягкого удаления
+	Version       int           `json:"version"`                                     // Увеличивается при каждом изменении, передаётся в ETag
 }
 
 type SubscriptionInput struct {
-	ServiceName string  `json:"service_name"`
-	Price       int     `json:"price"`
-	UserID      string  `json:"user_id"`
-	StartDate   string  `json:"start_date"` // временно строка до парсинга
-	EndDate     *string `json:"end_date"`   // аналогично
+	ServiceName   string  `json:"service_name"`
+	Price         Money   `json:"price" swaggertype:"string" example:"299.90"`
+	Currency      string  `json:"currency"`       // по умолчанию RUB
+	BillingPeriod string  `json:"billing_period"` // по умолчанию monthly
+	UserID        string  `json:"user_id"`
+	StartDate     string  `json:"start_date"` // временно строка до парсинга
+	EndDate       *string `json:"end_date"`   // аналогично
 }
 
 type UpdateSubscription struct {
-	ServiceName *string     `json:"service_name"`
-	Price       *int        `json:"price"`
-	UserID      *string     `json:"user_id"`
-	StartDate   *time.Time  `json:"start_date"`
-	EndDate     **time.Time `json:"end_date"`
+	ServiceName   *string     `json:"service_name"`
+	Price         *Money      `json:"price" swaggertype:"string" example:"299.90"`
+	Currency      *string     `json:"currency"`
+	BillingPeriod *string     `json:"billing_period"`
+	UserID        *string     `json:"user_id"`
+	StartDate     *time.Time  `json:"start_date"`
+	EndDate       **time.Time `json:"end_date"`
+}
+
+// FullUpdate возвращает изменение, заменяющее все поля подписки значениями sub, включая сброс end_date
+func FullUpdate(sub Subscription) UpdateSubscription {
+	endDate := sub.EndDate
+	return UpdateSubscription{
+		ServiceName:   &sub.ServiceName,
+		Price:         &sub.Price,
+		Currency:      &sub.Currency,
+		BillingPeriod: &sub.BillingPeriod,
+		UserID:        &sub.UserID,
+		StartDate:     &sub.StartDate,
+		EndDate:       &endDate,
+	}
 }
 
+// UpdateSubscriptionInput - тело PATCH (JSON Merge Patch): указываются только изменяемые поля,
+// end_date: null снимает дату окончания
 type UpdateSubscriptionInput struct {
-	ServiceName *string `json:"service_name,omitempty"`
-	Price       *int    `json:"price,omitempty"`
-	UserID      *string `json:"user_id,omitempty"`
-	StartDate   *string `json:"start_date,omitempty"`
-	EndDate     *string `json:"end_date,omitempty"`
+	ServiceName   *string `json:"service_name,omitempty"`
+	Price         *Money  `json:"price,omitempty" swaggertype:"string" example:"299.90"`
+	Currency      *string `json:"currency,omitempty"`
+	BillingPeriod *string `json:"billing_period,omitempty"`
+	UserID        *string `json:"user_id,omitempty"`
+	StartDate     *string `json:"start_date,omitempty"`
+	EndDate       *string `json:"end_date,omitempty"`
 }
@@ -0,0 +1,39 @@
+package models
+
+// UpcomingQuery - параметры запроса предстоящих списаний
+type UpcomingQuery struct {
+	UserID      string
+	ServiceName string // Подстрока названия сервиса
+	Days        int    // Длина окна в днях, начиная с сегодняшнего дня
+}
+
+// UpcomingCharge - предстоящее списание по подписке
+type UpcomingCharge struct {
+	Date           string `json:"date" example:"2026-11-01"` // Дата списания YYYY-MM-DD
+	SubscriptionID int    `json:"subscription_id"`
+	ServiceName    string `json:"service_name"`
+	UserID         string `json:"user_id"`
+	Amount         Money  `json:"amount" swaggertype:"string" example:"299.90"` // Цена, действующая на дату списания
+	Currency       string `json:"currency"`
+	BillingPeriod  string `json:"billing_period"`
+}
+
+// ExpiringSubscription - подписка, end_date которой попадает в окно
+type ExpiringSubscription struct {
+	SubscriptionID int    `json:"subscription_id"`
+	ServiceName    string `json:"service_name"`
+	UserID         string `json:"user_id"`
+	EndDate        string `json:"end_date" example:"2026-11-30"` // Последний день действия YYYY-MM-DD
+	Status         string `json:"status"`
+	Price          Money  `json:"price" swaggertype:"string" example:"299.90"` // Цена, действующая на end_date
+	Currency       string `json:"currency"`
+}
+
+// Upcoming - предстоящие списания и окончания подписок в окне [From, To]
+type Upcoming struct {
+	From     string                 `json:"from" example:"2026-10-17"`          // Первый день окна (сегодня)
+	To       string                 `json:"to" example:"2026-11-15"`            // Последний день окна включительно
+	Charges  []UpcomingCharge       `json:"charges"`                            // По возрастанию даты
+	Totals   map[string]Money       `json:"totals" swaggertype:"object,string"` // Сумма списаний по валютам
+	Expiring []ExpiringSubscription `json:"expiring"`                           // По возрастанию end_date
+}
@@ -0,0 +1,44 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"usersubs/models"
+)
+
+// Инициатор изменений, если он не указан в контексте
+const anonymousActor = "anonymous"
+
+type actorKey struct{}
+
+// WithActor возвращает контекст с инициатором изменений, который попадёт в журнал
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// ActorFromContext возвращает инициатора изменений из контекста
+func ActorFromContext(ctx context.Context) string {
+	if actor, ok := ctx.Value(actorKey{}).(string); ok && actor != "" {
+		return actor
+	}
+	return anonymousActor
+}
+
+// newAuditEntry готовит запись журнала; before == nil для создания, after == nil для удаления
+func newAuditEntry(ctx context.Context, operation string, before, after *models.Subscription) (models.AuditEntry, error) {
+	entry := models.AuditEntry{Actor: ActorFromContext(ctx), Operation: operation}
+	var err error
+	if before != nil {
+		entry.SubscriptionID, entry.UserID = before.ID, before.UserID
+		if entry.Before, err = json.Marshal(before); err != nil {
+			return models.AuditEntry{}, err
+		}
+	}
+	if after != nil {
+		entry.SubscriptionID, entry.UserID = after.ID, after.UserID
+		if entry.After, err = json.Marshal(after); err != nil {
+			return models.AuditEntry{}, err
+		}
+	}
+	return entry, nil
+}
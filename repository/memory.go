@@ -0,0 +1,344 @@
+package repository
+
+import (
+	"cmp"
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"usersubs/models"
+)
+
+// MemoryRepository хранит подписки в памяти процесса.
+// Данные теряются при перезапуске - подходит для локального запуска и демо без PostgreSQL.
+type MemoryRepository struct {
+	mu          sync.RWMutex
+	subs        map[int]models.Subscription
+	nextID      int
+	nextPauseID int
+	nextPriceID int
+	rates       map[string]map[time.Time]models.ExchangeRate // валюта -> дата -> курс
+	audit       []models.AuditEntry
+	nextAuditID int64
+	apiKeys     map[int]memoryAPIKey
+	nextKeyID   int
+	idempotency map[idempotencyID]models.IdempotencyRecord
+}
+
+func NewMemoryRepository() *MemoryRepository {
+	return &MemoryRepository{
+		subs:        make(map[int]models.Subscription),
+		nextID:      1,
+		nextPauseID: 1,
+		nextPriceID: 1,
+		nextAuditID: 1,
+		apiKeys:     make(map[int]memoryAPIKey),
+		nextKeyID:   1,
+		idempotency: make(map[idempotencyID]models.IdempotencyRecord),
+		rates:       make(map[string]map[time.Time]models.ExchangeRate),
+	}
+}
+
+// copySubscription возвращает глубокую копию записи, чтобы изменения копии не затрагивали оригинал
+func copySubscription(sub models.Subscription) models.Subscription {
+	if sub.EndDate != nil {
+		endDate := *sub.EndDate
+		sub.EndDate = &endDate
+	}
+	if sub.DeletedAt != nil {
+		deletedAt := *sub.DeletedAt
+		sub.DeletedAt = &deletedAt
+	}
+	if sub.Pauses != nil {
+		pauses := make([]models.Pause, len(sub.Pauses))
+		for i, pause := range sub.Pauses {
+			if pause.ResumedOn != nil {
+				resumedOn := *pause.ResumedOn
+				pause.ResumedOn = &resumedOn
+			}
+			pauses[i] = pause
+		}
+		sub.Pauses = pauses
+	}
+	if sub.PriceHistory != nil {
+		sub.PriceHistory = append([]models.PriceChange(nil), sub.PriceHistory...)
+	}
+	return sub
+}
+
+// hasDuplicate проверяет аналог уникального индекса (user_id, service_name, start_date) среди неудалённых записей;
+// запись exceptID не учитывается
+func (r *MemoryRepository) hasDuplicate(sub models.Subscription, exceptID int) bool {
+	for id, existing := range r.subs {
+		if id != exceptID && existing.DeletedAt == nil &&
+			strings.EqualFold(existing.UserID, sub.UserID) &&
+			existing.ServiceName == sub.ServiceName &&
+			existing.StartDate.Equal(sub.StartDate) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *MemoryRepository) Create(ctx context.Context, sub models.Subscription) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.hasDuplicate(sub, 0) {
+		return 0, ErrDuplicate
+	}
+	sub.ID = r.nextID
+	sub.Version = 1
+	r.nextID++
+	r.assignDetailIDs(&sub)
+	entry, err := newAuditEntry(ctx, models.AuditCreate, nil, &sub)
+	if err != nil {
+		return 0, err
+	}
+	r.subs[sub.ID] = copySubscription(sub)
+	r.appendAudit(entry)
+
+	return sub.ID, nil
+}
+
+func (r *MemoryRepository) Get(ctx context.Context, id int, includeDeleted bool) (models.Subscription, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	sub, ok := r.subs[id]
+	if !ok || (sub.DeletedAt != nil && !includeDeleted) {
+		return models.Subscription{}, ErrNotFound
+	}
+	return copySubscription(sub), nil
+}
+
+func (r *MemoryRepository) List(ctx context.Context, filter models.SubscriptionFilter) ([]models.Subscription, error) {
+	contains := strings.ToLower(filter.ServiceNameContains)
+	// price - цена для фильтра и сортировки, как в PostgreSQL
+	price := func(sub models.Subscription) models.Money {
+		if filter.PriceDate.IsZero() {
+			return sub.Price
+		}
+		return sub.PriceAt(filter.PriceDate)
+	}
+	subs := r.filter(func(sub models.Subscription) bool {
+		switch {
+		case sub.DeletedAt != nil && !filter.IncludeDeleted,
+			filter.UserID != "" && !strings.EqualFold(sub.UserID, filter.UserID),
+			filter.ServiceName != "" && sub.ServiceName != filter.ServiceName,
+			contains != "" && !strings.Contains(strings.ToLower(sub.ServiceName), contains),
+			filter.ActiveFrom != nil && sub.EndDate != nil && sub.EndDate.Before(*filter.ActiveFrom),
+			filter.ActiveTo != nil && sub.StartDate.After(*filter.ActiveTo),
+			filter.PriceMin != nil && price(sub) < *filter.PriceMin,
+			filter.PriceMax != nil && price(sub) > *filter.PriceMax:
+			return false
+		}
+		return true
+	})
+
+	// less сравнивает записи по полю сортировки, при равенстве - по ID
+	less := func(a, b models.Subscription) bool {
+		if c := compareBySort(a, b, filter.SortBy, price); c != 0 {
+			return c < 0
+		}
+		return a.ID < b.ID
+	}
+	if filter.SortDesc {
+		asc := less
+		less = func(a, b models.Subscription) bool { return asc(b, a) }
+	}
+	sort.Slice(subs, func(i, j int) bool { return less(subs[i], subs[j]) })
+
+	if c := filter.After; c != nil {
+		// У записи из курсора нет истории цен, поэтому PriceAt вернёт цену курсора
+		last := models.Subscription{ID: c.ID, Price: c.Price, StartDate: c.StartDate, ServiceName: c.ServiceName}
+		start := sort.Search(len(subs), func(i int) bool { return less(last, subs[i]) })
+		subs = subs[start:]
+	}
+	if filter.Limit > 0 && len(subs) > filter.Limit {
+		subs = subs[:filter.Limit]
+	}
+	return subs, nil
+}
+
+// compareBySort сравнивает две записи по полю сортировки; цена берётся функцией price
+func compareBySort(a, b models.Subscription, sortBy string, price func(models.Subscription) models.Money) int {
+	switch sortBy {
+	case models.SortByPrice:
+		return cmp.Compare(price(a), price(b))
+	case models.SortByStartDate:
+		return a.StartDate.Compare(b.StartDate)
+	case models.SortByServiceName:
+		return strings.Compare(a.ServiceName, b.ServiceName)
+	}
+	return 0
+}
+
+func (r *MemoryRepository) Update(ctx context.Context, id int, apply func(sub *models.Subscription) error) (models.Subscription, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	existing, ok := r.subs[id]
+	if !ok || existing.DeletedAt != nil {
+		return models.Subscription{}, ErrNotFound
+	}
+	updated := copySubscription(existing)
+	if err := apply(&updated); err != nil {
+		return models.Subscription{}, err
+	}
+	updated.ID = id
+	updated.Version = existing.Version + 1
+	if r.hasDuplicate(updated, id) {
+		return models.Subscription{}, ErrDuplicate
+	}
+	r.assignDetailIDs(&updated)
+	entry, err := newAuditEntry(ctx, models.AuditUpdate, &existing, &updated)
+	if err != nil {
+		return models.Subscription{}, err
+	}
+	r.subs[id] = copySubscription(updated)
+	r.appendAudit(entry)
+
+	return updated, nil
+}
+
+// assignDetailIDs присваивает ID новым паузам и изменениям цены (аналог SERIAL)
+func (r *MemoryRepository) assignDetailIDs(sub *models.Subscription) {
+	for i := range sub.Pauses {
+		if sub.Pauses[i].ID == 0 {
+			sub.Pauses[i].ID = r.nextPauseID
+			r.nextPauseID++
+		}
+	}
+	for i := range sub.PriceHistory {
+		if sub.PriceHistory[i].ID == 0 {
+			sub.PriceHistory[i].ID = r.nextPriceID
+			r.nextPriceID++
+		}
+	}
+}
+
+func (r *MemoryRepository) Delete(ctx context.Context, id int, check func(sub models.Subscription) error) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	existing, ok := r.subs[id]
+	if !ok || existing.DeletedAt != nil {
+		return 0, nil
+	}
+	if err := check(copySubscription(existing)); err != nil {
+		return 0, err
+	}
+	entry, err := newAuditEntry(ctx, models.AuditDelete, &existing, nil)
+	if err != nil {
+		return 0, err
+	}
+	now := time.Now().UTC()
+	existing.DeletedAt = &now
+	existing.Version++
+	r.subs[id] = existing
+	r.appendAudit(entry)
+	return 1, nil
+}
+
+func (r *MemoryRepository) Restore(ctx context.Context, id int) (models.Subscription, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	existing, ok := r.subs[id]
+	if !ok {
+		return models.Subscription{}, ErrNotFound
+	}
+	if existing.DeletedAt == nil {
+		return models.Subscription{}, ErrNotDeleted
+	}
+	if r.hasDuplicate(existing, id) {
+		return models.Subscription{}, ErrDuplicate
+	}
+	restored := copySubscription(existing)
+	restored.DeletedAt = nil
+	restored.Version++
+	entry, err := newAuditEntry(ctx, models.AuditRestore, &existing, &restored)
+	if err != nil {
+		return models.Subscription{}, err
+	}
+	r.subs[id] = copySubscription(restored)
+	r.appendAudit(entry)
+	return restored, nil
+}
+
+func (r *MemoryRepository) Purge(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var purged int64
+	for id, sub := range r.subs {
+		if sub.DeletedAt != nil && sub.DeletedAt.Before(deletedBefore) {
+			delete(r.subs, id)
+			r.appendAudit(models.AuditEntry{
+				SubscriptionID: id,
+				UserID:         sub.UserID,
+				Actor:          ActorFromContext(ctx),
+				Operation:      models.AuditPurge,
+			})
+			purged++
+		}
+	}
+	return purged, nil
+}
+
+// InTx выполняет fn над копией подписок и сохраняет копию, только если fn завершилась без ошибки.
+// Хранилище заблокировано до конца fn: обращаться к нему можно только через repo.
+func (r *MemoryRepository) InTx(ctx context.Context, fn func(repo SubscriptionRepository) error) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	tx := &MemoryRepository{
+		subs:        make(map[int]models.Subscription, len(r.subs)),
+		nextID:      r.nextID,
+		nextPauseID: r.nextPauseID,
+		nextPriceID: r.nextPriceID,
+		nextAuditID: r.nextAuditID,
+	}
+	for id, sub := range r.subs {
+		tx.subs[id] = sub
+	}
+	if err := fn(tx); err != nil {
+		return err
+	}
+	r.subs = tx.subs
+	r.nextID, r.nextPauseID, r.nextPriceID = tx.nextID, tx.nextPauseID, tx.nextPriceID
+	r.nextAuditID = tx.nextAuditID
+	r.audit = append(r.audit, tx.audit...)
+	return nil
+}
+
+func (r *MemoryRepository) ListForPeriod(ctx context.Context, userID, serviceName string, from, to time.Time, includeDeleted bool) ([]models.Subscription, error) {
+	serviceName = strings.ToLower(serviceName)
+	return r.filter(func(sub models.Subscription) bool {
+		if (sub.DeletedAt != nil && !includeDeleted) || sub.StartDate.After(to) || (sub.EndDate != nil && sub.EndDate.Before(from)) {
+			return false
+		}
+		// Аналог ILIKE '%service_name%'
+		if serviceName != "" && !strings.Contains(strings.ToLower(sub.ServiceName), serviceName) {
+			return false
+		}
+		return userID == "" || strings.EqualFold(sub.UserID, userID)
+	}), nil
+}
+
+// filter возвращает копии подходящих записей, упорядоченные по ID
+func (r *MemoryRepository) filter(match func(models.Subscription) bool) []models.Subscription {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var subs []models.Subscription
+	for _, sub := range r.subs {
+		if match(sub) {
+			subs = append(subs, copySubscription(sub))
+		}
+	}
+	sort.Slice(subs, func(i, j int) bool { return subs[i].ID < subs[j].ID })
+	return subs
+}
@@ -0,0 +1,38 @@
+package repository
+
+import (
+	"context"
+	"time"
+	"usersubs/models"
+)
+
+// appendAudit добавляет запись в журнал; вызывается под r.mu вместе с изменением
+func (r *MemoryRepository) appendAudit(entry models.AuditEntry) {
+	entry.ID = r.nextAuditID
+	r.nextAuditID++
+	entry.ChangedAt = time.Now().UTC()
+	r.audit = append(r.audit, entry)
+}
+
+func (r *MemoryRepository) ListAudit(ctx context.Context, filter models.AuditFilter) ([]models.AuditEntry, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var entries []models.AuditEntry
+	for i := len(r.audit) - 1; i >= 0; i-- {
+		entry := r.audit[i]
+		switch {
+		case filter.SubscriptionID != 0 && entry.SubscriptionID != filter.SubscriptionID,
+			filter.UserID != "" && entry.UserID != filter.UserID,
+			filter.From != nil && entry.ChangedAt.Before(*filter.From),
+			filter.To != nil && !entry.ChangedAt.Before(*filter.To),
+			filter.BeforeID > 0 && entry.ID >= filter.BeforeID:
+			continue
+		}
+		entries = append(entries, entry)
+		if filter.Limit > 0 && len(entries) == filter.Limit {
+			break
+		}
+	}
+	return entries, nil
+}
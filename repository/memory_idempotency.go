@@ -0,0 +1,64 @@
+package repository
+
+import (
+	"context"
+	"time"
+	"usersubs/models"
+)
+
+// idempotencyID - ключ записи идемпотентности (аналог PRIMARY KEY (key, owner))
+type idempotencyID struct {
+	key, owner string
+}
+
+func (r *MemoryRepository) ReserveIdempotencyKey(ctx context.Context, rec models.IdempotencyRecord) (models.IdempotencyRecord, bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	id := idempotencyID{rec.Key, rec.Owner}
+	if existing, ok := r.idempotency[id]; ok && existing.ExpiresAt.After(time.Now()) {
+		existing.Body = append([]byte(nil), existing.Body...)
+		return existing, false, nil
+	}
+	rec.StatusCode, rec.Body = 0, nil
+	r.idempotency[id] = rec
+	return rec, true, nil
+}
+
+func (r *MemoryRepository) CompleteIdempotencyKey(ctx context.Context, rec models.IdempotencyRecord) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	id := idempotencyID{rec.Key, rec.Owner}
+	existing, ok := r.idempotency[id]
+	if !ok {
+		return nil
+	}
+	existing.StatusCode = rec.StatusCode
+	existing.ContentType = rec.ContentType
+	existing.Body = append([]byte(nil), rec.Body...)
+	r.idempotency[id] = existing
+	return nil
+}
+
+func (r *MemoryRepository) ReleaseIdempotencyKey(ctx context.Context, key, owner string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.idempotency, idempotencyID{key, owner})
+	return nil
+}
+
+func (r *MemoryRepository) DeleteExpiredIdempotencyKeys(ctx context.Context, now time.Time) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var deleted int64
+	for id, rec := range r.idempotency {
+		if !rec.ExpiresAt.After(now) {
+			delete(r.idempotency, id)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
@@ -0,0 +1,77 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"time"
+	"usersubs/models"
+)
+
+// memoryAPIKey - ключ вместе с хешем секрета
+type memoryAPIKey struct {
+	models.APIKey
+	hash string
+}
+
+func copyAPIKey(key models.APIKey) models.APIKey {
+	if key.RevokedAt != nil {
+		revokedAt := *key.RevokedAt
+		key.RevokedAt = &revokedAt
+	}
+	return key
+}
+
+func (r *MemoryRepository) CreateAPIKey(ctx context.Context, key models.APIKey, hash string) (models.APIKey, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, existing := range r.apiKeys {
+		if existing.hash == hash {
+			return models.APIKey{}, ErrDuplicate
+		}
+	}
+	key.ID = r.nextKeyID
+	r.nextKeyID++
+	key.CreatedAt = time.Now().UTC()
+	key.RevokedAt = nil
+	r.apiKeys[key.ID] = memoryAPIKey{APIKey: key, hash: hash}
+	return key, nil
+}
+
+func (r *MemoryRepository) FindAPIKey(ctx context.Context, hash string) (models.APIKey, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, key := range r.apiKeys {
+		if key.hash == hash && key.RevokedAt == nil {
+			return copyAPIKey(key.APIKey), nil
+		}
+	}
+	return models.APIKey{}, ErrNotFound
+}
+
+func (r *MemoryRepository) ListAPIKeys(ctx context.Context) ([]models.APIKey, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	keys := make([]models.APIKey, 0, len(r.apiKeys))
+	for _, key := range r.apiKeys {
+		keys = append(keys, copyAPIKey(key.APIKey))
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].ID < keys[j].ID })
+	return keys, nil
+}
+
+func (r *MemoryRepository) RevokeAPIKey(ctx context.Context, id int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key, ok := r.apiKeys[id]
+	if !ok || key.RevokedAt != nil {
+		return ErrNotFound
+	}
+	now := time.Now().UTC()
+	key.RevokedAt = &now
+	r.apiKeys[id] = key
+	return nil
+}
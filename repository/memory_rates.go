@@ -0,0 +1,37 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"time"
+	"usersubs/models"
+)
+
+func (r *MemoryRepository) SaveRates(ctx context.Context, rates []models.ExchangeRate) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, rate := range rates {
+		byDate := r.rates[rate.Currency]
+		if byDate == nil {
+			byDate = make(map[time.Time]models.ExchangeRate)
+			r.rates[rate.Currency] = byDate
+		}
+		byDate[rate.Date] = rate
+	}
+	return nil
+}
+
+func (r *MemoryRepository) ListRates(ctx context.Context, currency string, until time.Time) ([]models.ExchangeRate, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var rates []models.ExchangeRate
+	for date, rate := range r.rates[currency] {
+		if !date.After(until) {
+			rates = append(rates, rate)
+		}
+	}
+	sort.Slice(rates, func(i, j int) bool { return rates[i].Date.Before(rates[j].Date) })
+	return rates, nil
+}
@@ -0,0 +1,112 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+	"usersubs/models"
+)
+
+func month(s string) time.Time {
+	t, err := time.Parse("2006-01", s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+// TestListByScheduledPrice проверяет, что фильтр, сортировка и курсор по цене используют цену на PriceDate
+func TestListByScheduledPrice(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemoryRepository()
+	// Хранимая цена - цена на момент записи; у A и C после неё запланированы изменения
+	subs := []models.Subscription{
+		{ServiceName: "A", Price: 100, PriceHistory: []models.PriceChange{{EffectiveMonth: month("2026-01"), Price: 100}, {EffectiveMonth: month("2026-06"), Price: 500}}},
+		{ServiceName: "B", Price: 200, PriceHistory: []models.PriceChange{{EffectiveMonth: month("2026-01"), Price: 200}}},
+		{ServiceName: "C", Price: 300, PriceHistory: []models.PriceChange{{EffectiveMonth: month("2026-01"), Price: 300}, {EffectiveMonth: month("2026-06"), Price: 50}}},
+	}
+	for _, sub := range subs {
+		sub.UserID = "11111111-1111-1111-1111-111111111111"
+		sub.StartDate = month("2026-01")
+		if _, err := repo.Create(ctx, sub); err != nil {
+			t.Fatal(err)
+		}
+	}
+	names := func(subs []models.Subscription) []string {
+		var out []string
+		for _, sub := range subs {
+			out = append(out, sub.ServiceName)
+		}
+		return out
+	}
+	priceMin, priceMax := models.Money(150), models.Money(400)
+
+	tests := []struct {
+		name   string
+		filter models.SubscriptionFilter
+		want   []string
+	}{
+		{"stored price", models.SubscriptionFilter{SortBy: models.SortByPrice}, []string{"A", "B", "C"}},
+		{"before the change", models.SubscriptionFilter{SortBy: models.SortByPrice, PriceDate: month("2026-05")}, []string{"A", "B", "C"}},
+		{"after the change", models.SubscriptionFilter{SortBy: models.SortByPrice, PriceDate: month("2026-06")}, []string{"C", "B", "A"}},
+		{"descending", models.SubscriptionFilter{SortBy: models.SortByPrice, SortDesc: true, PriceDate: month("2026-06")}, []string{"A", "B", "C"}},
+		{"price range", models.SubscriptionFilter{PriceMin: &priceMin, PriceMax: &priceMax, PriceDate: month("2026-06")}, []string{"B"}},
+		{"page after a cursor", models.SubscriptionFilter{
+			SortBy: models.SortByPrice, PriceDate: month("2026-06"),
+			After: &models.Cursor{SortBy: models.SortByPrice, ID: 3, Price: 50},
+		}, []string{"B", "A"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := repo.List(ctx, tt.filter)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(names(got), tt.want) {
+				t.Errorf("List = %v, want %v", names(got), tt.want)
+			}
+		})
+	}
+}
+
+// TestUserIDCaseInsensitive проверяет, что user_id сравнивается без учёта регистра, как UUID в Postgres
+func TestUserIDCaseInsensitive(t *testing.T) {
+	const lower = "aaaaaaaa-1111-1111-1111-111111111111"
+	upper := strings.ToUpper(lower)
+	ctx := context.Background()
+	repo := NewMemoryRepository()
+	if _, err := repo.Create(ctx, models.Subscription{ServiceName: "Okko", UserID: upper, StartDate: month("2026-01")}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.Create(ctx, models.Subscription{ServiceName: "Okko", UserID: lower, StartDate: month("2026-01")}); !errors.Is(err, ErrDuplicate) {
+		t.Errorf("Create with other case error = %v, want ErrDuplicate", err)
+	}
+
+	tests := []struct {
+		name string
+		list func(userID string) ([]models.Subscription, error)
+	}{
+		{"List", func(userID string) ([]models.Subscription, error) {
+			return repo.List(ctx, models.SubscriptionFilter{UserID: userID})
+		}},
+		{"ListForPeriod", func(userID string) ([]models.Subscription, error) {
+			return repo.ListForPeriod(ctx, userID, "", month("2026-01"), month("2026-12"), false)
+		}},
+	}
+	for _, tt := range tests {
+		for _, userID := range []string{lower, upper} {
+			t.Run(tt.name+" "+userID, func(t *testing.T) {
+				got, err := tt.list(userID)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if len(got) != 1 {
+					t.Errorf("found %d subscriptions, want 1", len(got))
+				}
+			})
+		}
+	}
+}
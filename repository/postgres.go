@@ -0,0 +1,514 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"usersubs/models"
+
+	"github.com/lib/pq"
+)
+
+const subscriptionColumns = `id, service_name, price, currency, billing_period, user_id, start_date, end_date, status, deleted_at, version`
+
+type PostgresRepository struct {
+	DB *sql.DB
+	// tx - общая транзакция InTx; nil - каждая операция выполняется в своей транзакции
+	tx *sql.Tx
+}
+
+// queryer - общий интерфейс для *sql.DB и *sql.Tx
+type queryer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+func NewPostgresRepository(db *sql.DB) *PostgresRepository {
+	return &PostgresRepository{DB: db}
+}
+
+// conn возвращает общую транзакцию InTx или пул соединений
+func (r *PostgresRepository) conn() queryer {
+	if r.tx != nil {
+		return r.tx
+	}
+	return r.DB
+}
+
+// opTx - транзакция одной операции. Внутри InTx операция выполняется в общей транзакции:
+// Commit и Rollback операции её не завершают, это делает InTx.
+type opTx struct {
+	*sql.Tx
+	shared bool
+}
+
+func (t opTx) Commit() error {
+	if t.shared {
+		return nil
+	}
+	return t.Tx.Commit()
+}
+
+func (t opTx) Rollback() error {
+	if t.shared {
+		return nil
+	}
+	return t.Tx.Rollback()
+}
+
+// begin начинает транзакцию операции
+func (r *PostgresRepository) begin(ctx context.Context) (opTx, error) {
+	if r.tx != nil {
+		return opTx{Tx: r.tx, shared: true}, nil
+	}
+	tx, err := r.DB.BeginTx(ctx, nil)
+	return opTx{Tx: tx}, err
+}
+
+func (r *PostgresRepository) InTx(ctx context.Context, fn func(repo SubscriptionRepository) error) error {
+	if r.tx != nil {
+		return fn(r)
+	}
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := fn(&PostgresRepository{DB: r.DB, tx: tx}); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// rowScanner - общий интерфейс для *sql.Row и *sql.Rows
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanSubscription(row rowScanner) (models.Subscription, error) {
+	var sub models.Subscription
+	var endDate, deletedAt sql.NullTime
+
+	err := row.Scan(&sub.ID, &sub.ServiceName, &sub.Price, &sub.Currency, &sub.BillingPeriod, &sub.UserID, &sub.StartDate, &endDate, &sub.Status, &deletedAt, &sub.Version)
+	if err != nil {
+		return models.Subscription{}, err
+	}
+	//Добавляем дату окончания в struct, если существует
+	if endDate.Valid {
+		sub.EndDate = &endDate.Time
+	}
+	if deletedAt.Valid {
+		sub.DeletedAt = &deletedAt.Time
+	}
+	return sub, nil
+}
+
+// mapError переводит ошибки драйвера в ошибки репозитория
+func mapError(err error) error {
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrNotFound
+	}
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+		return ErrDuplicate
+	}
+	return err
+}
+
+func (r *PostgresRepository) Create(ctx context.Context, sub models.Subscription) (int, error) {
+	query := `
+		INSERT INTO subscriptions (service_name, price, currency, billing_period, user_id, start_date, end_date, status)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id
+	`
+	tx, err := r.begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var newID int
+	err = tx.QueryRowContext(ctx, query,
+		sub.ServiceName,
+		sub.Price,
+		sub.Currency,
+		sub.BillingPeriod,
+		sub.UserID,
+		sub.StartDate,
+		sub.EndDate,
+		sub.Status,
+	).Scan(&newID)
+
+	if err != nil {
+		return 0, mapError(err)
+	}
+	sub.ID, sub.Version = newID, 1
+	if sub.PriceHistory, err = savePriceHistory(ctx, tx, newID, sub.PriceHistory); err != nil {
+		return 0, err
+	}
+	entry, err := newAuditEntry(ctx, models.AuditCreate, nil, &sub)
+	if err != nil {
+		return 0, err
+	}
+	if err := writeAudit(ctx, tx, entry); err != nil {
+		return 0, err
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+
+	return newID, nil
+}
+
+func (r *PostgresRepository) Get(ctx context.Context, id int, includeDeleted bool) (models.Subscription, error) {
+	return r.get(ctx, r.conn(), id, includeDeleted)
+}
+
+func (r *PostgresRepository) get(ctx context.Context, q queryer, id int, includeDeleted bool) (models.Subscription, error) {
+	return r.selectOne(ctx, q, id, includeDeleted, "")
+}
+
+// getForUpdate читает запись в транзакции и блокирует её до конца транзакции
+func (r *PostgresRepository) getForUpdate(ctx context.Context, tx queryer, id int, includeDeleted bool) (models.Subscription, error) {
+	return r.selectOne(ctx, tx, id, includeDeleted, " FOR UPDATE")
+}
+
+func (r *PostgresRepository) selectOne(ctx context.Context, q queryer, id int, includeDeleted bool, lock string) (models.Subscription, error) {
+	query := `SELECT ` + subscriptionColumns + ` FROM subscriptions WHERE id = $1`
+	if !includeDeleted {
+		query += ` AND deleted_at IS NULL`
+	}
+	query += lock
+
+	sub, err := scanSubscription(q.QueryRowContext(ctx, query, id))
+	if err != nil {
+		return models.Subscription{}, mapError(err)
+	}
+	subs := []models.Subscription{sub}
+	if err := loadDetails(ctx, q, subs); err != nil {
+		return models.Subscription{}, err
+	}
+	return subs[0], nil
+}
+
+func (r *PostgresRepository) List(ctx context.Context, filter models.SubscriptionFilter) ([]models.Subscription, error) {
+	var conditions []string
+	if !filter.IncludeDeleted {
+		conditions = append(conditions, "deleted_at IS NULL")
+	}
+	var args []interface{}
+	// arg добавляет аргумент и возвращает его плейсхолдер
+	arg := func(v interface{}) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	if filter.UserID != "" {
+		conditions = append(conditions, "user_id = "+arg(filter.UserID))
+	}
+	if filter.ServiceName != "" {
+		conditions = append(conditions, "service_name = "+arg(filter.ServiceName))
+	}
+	if filter.ServiceNameContains != "" {
+		conditions = append(conditions, "service_name ILIKE "+arg("%"+escapeLike(filter.ServiceNameContains)+"%"))
+	}
+	if filter.ActiveFrom != nil {
+		conditions = append(conditions, "(end_date >= "+arg(*filter.ActiveFrom)+" OR end_date IS NULL)")
+	}
+	if filter.ActiveTo != nil {
+		conditions = append(conditions, "start_date <= "+arg(*filter.ActiveTo))
+	}
+	// Цена для фильтра и сортировки - действующая на PriceDate; плейсхолдер даты добавляется, только если цена нужна
+	priceColumn := ""
+	price := func() string {
+		if priceColumn == "" {
+			priceColumn = "price"
+			if !filter.PriceDate.IsZero() {
+				priceColumn = fmt.Sprintf(priceAtSQL, arg(filter.PriceDate))
+			}
+		}
+		return priceColumn
+	}
+	if filter.PriceMin != nil {
+		conditions = append(conditions, price()+" >= "+arg(*filter.PriceMin))
+	}
+	if filter.PriceMax != nil {
+		conditions = append(conditions, price()+" <= "+arg(*filter.PriceMax))
+	}
+
+	sortColumn := sortColumns[filter.SortBy]
+	if sortColumn == "" {
+		sortColumn = "id"
+	}
+	if filter.SortBy == models.SortByPrice {
+		sortColumn = price()
+	}
+	direction, cmp := "ASC", ">"
+	if filter.SortDesc {
+		direction, cmp = "DESC", "<"
+	}
+
+	// Keyset-пагинация: продолжаем строго после (значение сортировки, id) последней записи
+	if c := filter.After; c != nil {
+		if sortColumn == "id" {
+			conditions = append(conditions, "id "+cmp+" "+arg(c.ID))
+		} else {
+			conditions = append(conditions, fmt.Sprintf("(%s, id) %s (%s, %s)", sortColumn, cmp, arg(cursorValue(c)), arg(c.ID)))
+		}
+	}
+
+	query := `SELECT ` + subscriptionColumns + ` FROM subscriptions`
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += fmt.Sprintf(" ORDER BY %s %s", sortColumn, direction)
+	if sortColumn != "id" {
+		query += ", id " + direction
+	}
+	if filter.Limit > 0 {
+		query += " LIMIT " + arg(filter.Limit)
+	}
+
+	return r.query(ctx, query, args...)
+}
+
+// Колонки, по которым разрешена сортировка
+var sortColumns = map[string]string{
+	models.SortByID:          "id",
+	models.SortByPrice:       "price",
+	models.SortByStartDate:   "start_date",
+	models.SortByServiceName: "service_name",
+}
+
+// priceAtSQL - цена подписки на дату %s по истории цен, как models.Subscription.PriceAt:
+// последнее изменение не позже даты, до первого изменения - его цена, без истории - хранимая цена
+const priceAtSQL = `COALESCE(
+	(SELECT p.price FROM subscription_prices p WHERE p.subscription_id = subscriptions.id AND p.effective_month <= %[1]s ORDER BY p.effective_month DESC LIMIT 1),
+	(SELECT p.price FROM subscription_prices p WHERE p.subscription_id = subscriptions.id ORDER BY p.effective_month LIMIT 1),
+	subscriptions.price)`
+
+// cursorValue возвращает значение поля сортировки, сохранённое в курсоре
+func cursorValue(c *models.Cursor) interface{} {
+	switch c.SortBy {
+	case models.SortByPrice:
+		return c.Price
+	case models.SortByStartDate:
+		return c.StartDate
+	case models.SortByServiceName:
+		return c.ServiceName
+	}
+	return c.ID
+}
+
+// escapeLike экранирует спецсимволы шаблона LIKE
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
+
+func (r *PostgresRepository) Update(ctx context.Context, id int, apply func(sub *models.Subscription) error) (models.Subscription, error) {
+	tx, err := r.begin(ctx)
+	if err != nil {
+		return models.Subscription{}, err
+	}
+	defer tx.Rollback()
+
+	existing, err := r.getForUpdate(ctx, tx, id, false)
+	if err != nil {
+		return models.Subscription{}, err
+	}
+	// Снимок до изменения для журнала: apply меняет existing на месте
+	before := copySubscription(existing)
+	if err := apply(&existing); err != nil {
+		return models.Subscription{}, err
+	}
+
+	row := tx.QueryRowContext(ctx, `
+		UPDATE subscriptions SET
+			service_name = $1,
+			price = $2,
+			currency = $3,
+			billing_period = $4,
+			user_id = $5,
+			start_date = $6,
+			end_date = $7,
+			status = $8,
+			version = version + 1
+		WHERE id = $9
+		RETURNING `+subscriptionColumns,
+		existing.ServiceName,
+		existing.Price,
+		existing.Currency,
+		existing.BillingPeriod,
+		existing.UserID,
+		existing.StartDate,
+		existing.EndDate,
+		existing.Status,
+		id,
+	)
+
+	updated, err := scanSubscription(row)
+	if err != nil {
+		return models.Subscription{}, mapError(err)
+	}
+	if updated.Pauses, err = savePauses(ctx, tx, id, existing.Pauses); err != nil {
+		return models.Subscription{}, err
+	}
+	if updated.PriceHistory, err = savePriceHistory(ctx, tx, id, existing.PriceHistory); err != nil {
+		return models.Subscription{}, err
+	}
+	entry, err := newAuditEntry(ctx, models.AuditUpdate, &before, &updated)
+	if err != nil {
+		return models.Subscription{}, err
+	}
+	if err := writeAudit(ctx, tx, entry); err != nil {
+		return models.Subscription{}, err
+	}
+	if err := tx.Commit(); err != nil {
+		return models.Subscription{}, err
+	}
+	return updated, nil
+}
+
+func (r *PostgresRepository) Delete(ctx context.Context, id int, check func(sub models.Subscription) error) (int64, error) {
+	tx, err := r.begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	existing, err := r.getForUpdate(ctx, tx, id, false)
+	if errors.Is(err, ErrNotFound) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	if err := check(existing); err != nil {
+		return 0, err
+	}
+	result, err := tx.ExecContext(ctx, `
+		UPDATE subscriptions SET deleted_at = now(), version = version + 1 WHERE id = $1 AND deleted_at IS NULL
+	`, id)
+	if err != nil {
+		return 0, err
+	}
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	entry, err := newAuditEntry(ctx, models.AuditDelete, &existing, nil)
+	if err != nil {
+		return 0, err
+	}
+	if err := writeAudit(ctx, tx, entry); err != nil {
+		return 0, err
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return deleted, nil
+}
+
+func (r *PostgresRepository) ListForPeriod(ctx context.Context, userID, serviceName string, from, to time.Time, includeDeleted bool) ([]models.Subscription, error) {
+	query := `
+		SELECT ` + subscriptionColumns + `
+		FROM subscriptions
+		WHERE (start_date <= $2) AND (end_date >= $1 OR end_date IS NULL)
+	`
+	if !includeDeleted {
+		query += " AND deleted_at IS NULL"
+	}
+	//Интерфейс для дополнения sql query другими агрументами, если существуют
+	args := []interface{}{from, to}
+	argID := 3 //счетчик позиции для след. аргументов в sql query
+
+	if serviceName != "" {
+		query += fmt.Sprintf(" AND service_name ILIKE $%d", argID)
+		args = append(args, "%"+escapeLike(serviceName)+"%")
+		argID++
+	}
+	if userID != "" {
+		query += fmt.Sprintf(" AND user_id = $%d", argID)
+		args = append(args, userID)
+		argID++
+	}
+
+	return r.query(ctx, query, args...)
+}
+
+func (r *PostgresRepository) query(ctx context.Context, query string, args ...any) ([]models.Subscription, error) {
+	rows, err := r.conn().QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var subs []models.Subscription
+	for rows.Next() {
+		sub, err := scanSubscription(rows)
+		if err != nil {
+			return nil, err
+		}
+		subs = append(subs, sub)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return subs, loadDetails(ctx, r.conn(), subs)
+}
+
+func (r *PostgresRepository) Restore(ctx context.Context, id int) (models.Subscription, error) {
+	tx, err := r.begin(ctx)
+	if err != nil {
+		return models.Subscription{}, err
+	}
+	defer tx.Rollback()
+
+	existing, err := r.getForUpdate(ctx, tx, id, true)
+	if err != nil {
+		return models.Subscription{}, err
+	}
+	if existing.DeletedAt == nil {
+		return models.Subscription{}, ErrNotDeleted
+	}
+	// Уникальный индекс проверяет восстановленную запись среди неудалённых
+	if _, err := tx.ExecContext(ctx, `UPDATE subscriptions SET deleted_at = NULL, version = version + 1 WHERE id = $1`, id); err != nil {
+		return models.Subscription{}, mapError(err)
+	}
+	restored := copySubscription(existing)
+	restored.DeletedAt = nil
+	restored.Version++
+
+	entry, err := newAuditEntry(ctx, models.AuditRestore, &existing, &restored)
+	if err != nil {
+		return models.Subscription{}, err
+	}
+	if err := writeAudit(ctx, tx, entry); err != nil {
+		return models.Subscription{}, err
+	}
+	if err := tx.Commit(); err != nil {
+		return models.Subscription{}, err
+	}
+	return restored, nil
+}
+
+func (r *PostgresRepository) Purge(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	// Удаление и запись в журнал - одним запросом, то есть в одной транзакции
+	result, err := r.conn().ExecContext(ctx, `
+		WITH purged AS (
+			DELETE FROM subscriptions WHERE deleted_at < $1
+			RETURNING id, user_id
+		)
+		INSERT INTO subscription_audit (subscription_id, user_id, actor, operation)
+		SELECT id, user_id, $2, $3 FROM purged
+	`, deletedBefore, ActorFromContext(ctx), models.AuditPurge)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
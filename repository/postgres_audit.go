@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"usersubs/models"
+)
+
+// writeAudit добавляет запись в журнал в транзакции изменения
+func writeAudit(ctx context.Context, tx queryer, entry models.AuditEntry) error {
+	_, err := tx.ExecContext(ctx, `
+		INSERT INTO subscription_audit (subscription_id, user_id, actor, operation, before, after)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`, entry.SubscriptionID, entry.UserID, entry.Actor, entry.Operation, nullJSON(entry.Before), nullJSON(entry.After))
+	return err
+}
+
+// nullJSON превращает пустой JSON в NULL
+func nullJSON(data []byte) any {
+	if len(data) == 0 {
+		return nil
+	}
+	return string(data)
+}
+
+func (r *PostgresRepository) ListAudit(ctx context.Context, filter models.AuditFilter) ([]models.AuditEntry, error) {
+	var conditions []string
+	var args []any
+	arg := func(v any) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	if filter.SubscriptionID != 0 {
+		conditions = append(conditions, "subscription_id = "+arg(filter.SubscriptionID))
+	}
+	if filter.UserID != "" {
+		conditions = append(conditions, "user_id = "+arg(filter.UserID))
+	}
+	if filter.From != nil {
+		conditions = append(conditions, "changed_at >= "+arg(*filter.From))
+	}
+	if filter.To != nil {
+		conditions = append(conditions, "changed_at < "+arg(*filter.To))
+	}
+	if filter.BeforeID > 0 {
+		conditions = append(conditions, "id < "+arg(filter.BeforeID))
+	}
+
+	query := `SELECT id, subscription_id, user_id, actor, operation, changed_at, before, after FROM subscription_audit`
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += " ORDER BY id DESC"
+	if filter.Limit > 0 {
+		query += " LIMIT " + arg(filter.Limit)
+	}
+
+	rows, err := r.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []models.AuditEntry
+	for rows.Next() {
+		var entry models.AuditEntry
+		var before, after []byte
+		err := rows.Scan(&entry.ID, &entry.SubscriptionID, &entry.UserID, &entry.Actor, &entry.Operation, &entry.ChangedAt, &before, &after)
+		if err != nil {
+			return nil, err
+		}
+		entry.Before, entry.After = before, after
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
+}
@@ -0,0 +1,141 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"usersubs/models"
+
+	"github.com/lib/pq"
+)
+
+// loadDetails заполняет у подписок связанные данные: паузы и историю цен
+func loadDetails(ctx context.Context, q queryer, subs []models.Subscription) error {
+	if len(subs) == 0 {
+		return nil
+	}
+	index := make(map[int]int, len(subs))
+	ids := make([]int64, len(subs))
+	for i, sub := range subs {
+		index[sub.ID] = i
+		ids[i] = int64(sub.ID)
+	}
+
+	if err := loadPauses(ctx, q, subs, index, ids); err != nil {
+		return err
+	}
+	return loadPriceHistory(ctx, q, subs, index, ids)
+}
+
+// loadPauses заполняет Pauses у подписок одним запросом; index - позиция подписки в subs по ID
+func loadPauses(ctx context.Context, q queryer, subs []models.Subscription, index map[int]int, ids []int64) error {
+	rows, err := q.QueryContext(ctx, `
+		SELECT id, subscription_id, paused_on, resumed_on
+		FROM subscription_pauses
+		WHERE subscription_id = ANY($1)
+		ORDER BY paused_on
+	`, pq.Array(ids))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var pause models.Pause
+		var subID int
+		var resumedOn sql.NullTime
+		if err := rows.Scan(&pause.ID, &subID, &pause.PausedOn, &resumedOn); err != nil {
+			return err
+		}
+		if resumedOn.Valid {
+			pause.ResumedOn = &resumedOn.Time
+		}
+		sub := &subs[index[subID]]
+		sub.Pauses = append(sub.Pauses, pause)
+	}
+	return rows.Err()
+}
+
+// savePauses сохраняет изменения интервалов приостановки: новые (ID == 0) добавляются,
+// у существующих обновляется дата возобновления. Возвращает интервалы с заполненными ID.
+func savePauses(ctx context.Context, tx queryer, subID int, pauses []models.Pause) ([]models.Pause, error) {
+	saved := make([]models.Pause, 0, len(pauses))
+	for _, pause := range pauses {
+		if pause.ID == 0 {
+			err := tx.QueryRowContext(ctx, `
+				INSERT INTO subscription_pauses (subscription_id, paused_on, resumed_on)
+				VALUES ($1, $2, $3)
+				RETURNING id
+			`, subID, pause.PausedOn, pause.ResumedOn).Scan(&pause.ID)
+			if err != nil {
+				return nil, err
+			}
+		} else {
+			_, err := tx.ExecContext(ctx, `
+				UPDATE subscription_pauses SET resumed_on = $1 WHERE id = $2 AND subscription_id = $3
+			`, pause.ResumedOn, pause.ID, subID)
+			if err != nil {
+				return nil, err
+			}
+		}
+		saved = append(saved, pause)
+	}
+	if len(saved) == 0 {
+		return nil, nil
+	}
+	return saved, nil
+}
+
+// loadPriceHistory заполняет PriceHistory у подписок одним запросом; index - позиция подписки в subs по ID
+func loadPriceHistory(ctx context.Context, q queryer, subs []models.Subscription, index map[int]int, ids []int64) error {
+	rows, err := q.QueryContext(ctx, `
+		SELECT id, subscription_id, effective_month, price
+		FROM subscription_prices
+		WHERE subscription_id = ANY($1)
+		ORDER BY effective_month
+	`, pq.Array(ids))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var change models.PriceChange
+		var subID int
+		if err := rows.Scan(&change.ID, &subID, &change.EffectiveMonth, &change.Price); err != nil {
+			return err
+		}
+		sub := &subs[index[subID]]
+		sub.PriceHistory = append(sub.PriceHistory, change)
+	}
+	return rows.Err()
+}
+
+// savePriceHistory сохраняет историю цен: новые изменения (ID == 0) добавляются,
+// у существующих обновляется цена. Возвращает историю с заполненными ID.
+func savePriceHistory(ctx context.Context, tx queryer, subID int, history []models.PriceChange) ([]models.PriceChange, error) {
+	saved := make([]models.PriceChange, 0, len(history))
+	for _, change := range history {
+		if change.ID == 0 {
+			err := tx.QueryRowContext(ctx, `
+				INSERT INTO subscription_prices (subscription_id, effective_month, price)
+				VALUES ($1, $2, $3)
+				RETURNING id
+			`, subID, change.EffectiveMonth, change.Price).Scan(&change.ID)
+			if err != nil {
+				return nil, err
+			}
+		} else {
+			_, err := tx.ExecContext(ctx, `
+				UPDATE subscription_prices SET price = $1 WHERE id = $2 AND subscription_id = $3
+			`, change.Price, change.ID, subID)
+			if err != nil {
+				return nil, err
+			}
+		}
+		saved = append(saved, change)
+	}
+	if len(saved) == 0 {
+		return nil, nil
+	}
+	return saved, nil
+}
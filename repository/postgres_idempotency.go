@@ -0,0 +1,65 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+	"usersubs/models"
+)
+
+func (r *PostgresRepository) ReserveIdempotencyKey(ctx context.Context, rec models.IdempotencyRecord) (models.IdempotencyRecord, bool, error) {
+	// Истёкшая запись не мешает занять ключ заново
+	_, err := r.DB.ExecContext(ctx, `
+		DELETE FROM idempotency_keys WHERE key = $1 AND owner = $2 AND expires_at <= now()
+	`, rec.Key, rec.Owner)
+	if err != nil {
+		return models.IdempotencyRecord{}, false, err
+	}
+
+	result, err := r.DB.ExecContext(ctx, `
+		INSERT INTO idempotency_keys (key, owner, request_hash, expires_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (key, owner) DO NOTHING
+	`, rec.Key, rec.Owner, rec.RequestHash, rec.ExpiresAt)
+	if err != nil {
+		return models.IdempotencyRecord{}, false, err
+	}
+	if n, err := result.RowsAffected(); err != nil {
+		return models.IdempotencyRecord{}, false, err
+	} else if n == 1 {
+		return rec, true, nil
+	}
+
+	existing := models.IdempotencyRecord{Key: rec.Key, Owner: rec.Owner}
+	var status sql.NullInt64
+	err = r.DB.QueryRowContext(ctx, `
+		SELECT request_hash, status_code, content_type, response_body, expires_at
+		FROM idempotency_keys WHERE key = $1 AND owner = $2
+	`, rec.Key, rec.Owner).Scan(&existing.RequestHash, &status, &existing.ContentType, &existing.Body, &existing.ExpiresAt)
+	if err != nil {
+		return models.IdempotencyRecord{}, false, mapError(err)
+	}
+	existing.StatusCode = int(status.Int64)
+	return existing, false, nil
+}
+
+func (r *PostgresRepository) CompleteIdempotencyKey(ctx context.Context, rec models.IdempotencyRecord) error {
+	_, err := r.DB.ExecContext(ctx, `
+		UPDATE idempotency_keys SET status_code = $3, content_type = $4, response_body = $5
+		WHERE key = $1 AND owner = $2
+	`, rec.Key, rec.Owner, rec.StatusCode, rec.ContentType, rec.Body)
+	return err
+}
+
+func (r *PostgresRepository) ReleaseIdempotencyKey(ctx context.Context, key, owner string) error {
+	_, err := r.DB.ExecContext(ctx, `DELETE FROM idempotency_keys WHERE key = $1 AND owner = $2`, key, owner)
+	return err
+}
+
+func (r *PostgresRepository) DeleteExpiredIdempotencyKeys(ctx context.Context, now time.Time) (int64, error) {
+	result, err := r.DB.ExecContext(ctx, `DELETE FROM idempotency_keys WHERE expires_at <= $1`, now)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
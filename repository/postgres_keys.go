@@ -0,0 +1,88 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"usersubs/models"
+)
+
+const apiKeyColumns = `id, name, prefix, role, user_id, created_at, revoked_at`
+
+func scanAPIKey(row rowScanner) (models.APIKey, error) {
+	var key models.APIKey
+	var userID sql.NullString
+	var revokedAt sql.NullTime
+	if err := row.Scan(&key.ID, &key.Name, &key.Prefix, &key.Role, &userID, &key.CreatedAt, &revokedAt); err != nil {
+		return models.APIKey{}, err
+	}
+	key.UserID = userID.String
+	if revokedAt.Valid {
+		key.RevokedAt = &revokedAt.Time
+	}
+	return key, nil
+}
+
+// nullString превращает пустую строку в NULL
+func nullString(s string) any {
+	if s == "" {
+		return nil
+	}
+	return s
+}
+
+func (r *PostgresRepository) CreateAPIKey(ctx context.Context, key models.APIKey, hash string) (models.APIKey, error) {
+	row := r.DB.QueryRowContext(ctx, `
+		INSERT INTO api_keys (name, prefix, key_hash, role, user_id)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING `+apiKeyColumns,
+		key.Name, key.Prefix, hash, key.Role, nullString(key.UserID))
+	created, err := scanAPIKey(row)
+	if err != nil {
+		return models.APIKey{}, mapError(err)
+	}
+	return created, nil
+}
+
+func (r *PostgresRepository) FindAPIKey(ctx context.Context, hash string) (models.APIKey, error) {
+	row := r.DB.QueryRowContext(ctx, `
+		SELECT `+apiKeyColumns+` FROM api_keys WHERE key_hash = $1 AND revoked_at IS NULL
+	`, hash)
+	key, err := scanAPIKey(row)
+	if err != nil {
+		return models.APIKey{}, mapError(err)
+	}
+	return key, nil
+}
+
+func (r *PostgresRepository) ListAPIKeys(ctx context.Context) ([]models.APIKey, error) {
+	rows, err := r.DB.QueryContext(ctx, `SELECT `+apiKeyColumns+` FROM api_keys ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var keys []models.APIKey
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return keys, rows.Err()
+}
+
+func (r *PostgresRepository) RevokeAPIKey(ctx context.Context, id int) error {
+	result, err := r.DB.ExecContext(ctx, `UPDATE api_keys SET revoked_at = now() WHERE id = $1 AND revoked_at IS NULL`, id)
+	if err != nil {
+		return err
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
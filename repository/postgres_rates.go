@@ -0,0 +1,55 @@
+package repository
+
+import (
+	"context"
+	"time"
+	"usersubs/models"
+)
+
+func (r *PostgresRepository) SaveRates(ctx context.Context, rates []models.ExchangeRate) error {
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, `
+		INSERT INTO exchange_rates (currency, rate_date, rate)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (currency, rate_date) DO UPDATE SET rate = EXCLUDED.rate
+	`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, rate := range rates {
+		if _, err := stmt.ExecContext(ctx, rate.Currency, rate.Date, rate.Rate); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (r *PostgresRepository) ListRates(ctx context.Context, currency string, until time.Time) ([]models.ExchangeRate, error) {
+	rows, err := r.DB.QueryContext(ctx, `
+		SELECT currency, rate_date, rate
+		FROM exchange_rates
+		WHERE currency = $1 AND rate_date <= $2
+		ORDER BY rate_date
+	`, currency, until)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var rates []models.ExchangeRate
+	for rows.Next() {
+		var rate models.ExchangeRate
+		if err := rows.Scan(&rate.Currency, &rate.Date, &rate.Rate); err != nil {
+			return nil, err
+		}
+		rates = append(rates, rate)
+	}
+	return rates, rows.Err()
+}
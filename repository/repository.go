@@ -0,0 +1,90 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+	"usersubs/models"
+)
+
+var (
+	// Запись с указанным ID не существует
+	ErrNotFound = errors.New("запись не найдена")
+	// Нарушено ограничение уникальности (user_id, service_name, start_date)
+	ErrDuplicate = errors.New("подписка с такими user_id, service_name и start_date уже существует")
+	// Восстанавливаемая запись не удалена
+	ErrNotDeleted = errors.New("запись не удалена")
+)
+
+// SubscriptionRepository - хранилище подписок, не зависящее от конкретной БД.
+// Реализации: PostgresRepository (основная) и MemoryRepository (локальный запуск и демо).
+type SubscriptionRepository interface {
+	Create(ctx context.Context, sub models.Subscription) (int, error)
+	// Get возвращает запись по ID; мягко удалённая запись возвращается только с includeDeleted
+	Get(ctx context.Context, id int, includeDeleted bool) (models.Subscription, error)
+	// List возвращает отфильтрованные и отсортированные записи, начиная с позиции filter.After.
+	// filter.Limit == 0 - без ограничения количества.
+	List(ctx context.Context, filter models.SubscriptionFilter) ([]models.Subscription, error)
+	// Update читает неудалённую запись, блокируя её до конца изменения, применяет к ней apply
+	// и сохраняет результат с увеличенной версией
+	Update(ctx context.Context, id int, apply func(sub *models.Subscription) error) (models.Subscription, error)
+	// Delete мягко удаляет запись: проставляет deleted_at. Перед удалением заблокированная запись передаётся в check;
+	// ошибка check отменяет удаление. Возвращает 0, если запись не найдена или уже удалена.
+	Delete(ctx context.Context, id int, check func(sub models.Subscription) error) (int64, error)
+	// Restore снимает пометку удаления; ErrNotDeleted - если запись не удалена
+	Restore(ctx context.Context, id int) (models.Subscription, error)
+	// Purge окончательно удаляет записи, мягко удалённые раньше deletedBefore. Возвращает число удалённых записей.
+	Purge(ctx context.Context, deletedBefore time.Time) (int64, error)
+	// ListForPeriod возвращает подписки, активные хотя бы в одном месяце периода [from, to].
+	// Используется для подсчёта суммарной стоимости.
+	ListForPeriod(ctx context.Context, userID, serviceName string, from, to time.Time, includeDeleted bool) ([]models.Subscription, error)
+	// InTx выполняет fn в одной транзакции: все изменения через repo сохраняются вместе,
+	// ошибка fn отменяет их все
+	InTx(ctx context.Context, fn func(repo SubscriptionRepository) error) error
+}
+
+// ExchangeRateRepository - хранилище курсов валют к рублю
+type ExchangeRateRepository interface {
+	// SaveRates сохраняет курсы; курс той же валюты на ту же дату перезаписывается
+	SaveRates(ctx context.Context, rates []models.ExchangeRate) error
+	// ListRates возвращает курсы валюты с датой не позже until в порядке возрастания даты
+	ListRates(ctx context.Context, currency string, until time.Time) ([]models.ExchangeRate, error)
+}
+
+// AuditRepository - журнал изменений подписок.
+// Записи добавляются реализациями SubscriptionRepository в той же транзакции, что и изменение.
+type AuditRepository interface {
+	ListAudit(ctx context.Context, filter models.AuditFilter) ([]models.AuditEntry, error)
+}
+
+// APIKeyRepository - хранилище API-ключей; ключ ищется по хешу
+type APIKeyRepository interface {
+	CreateAPIKey(ctx context.Context, key models.APIKey, hash string) (models.APIKey, error)
+	// FindAPIKey возвращает неотозванный ключ по хешу; ErrNotFound - если такого нет
+	FindAPIKey(ctx context.Context, hash string) (models.APIKey, error)
+	ListAPIKeys(ctx context.Context) ([]models.APIKey, error)
+	// RevokeAPIKey отзывает ключ; ErrNotFound - если ключа нет или он уже отозван
+	RevokeAPIKey(ctx context.Context, id int) error
+}
+
+// IdempotencyRepository - сохранённые ответы на запросы с Idempotency-Key
+type IdempotencyRepository interface {
+	// ReserveIdempotencyKey занимает ключ за первым запросом (reserved = true).
+	// Если ключ уже занят и не истёк, возвращает существующую запись.
+	ReserveIdempotencyKey(ctx context.Context, rec models.IdempotencyRecord) (existing models.IdempotencyRecord, reserved bool, err error)
+	// CompleteIdempotencyKey сохраняет ответ на запрос
+	CompleteIdempotencyKey(ctx context.Context, rec models.IdempotencyRecord) error
+	// ReleaseIdempotencyKey освобождает ключ, если ответ не нужно сохранять
+	ReleaseIdempotencyKey(ctx context.Context, key, owner string) error
+	// DeleteExpiredIdempotencyKeys удаляет записи, срок хранения которых истёк к моменту now
+	DeleteExpiredIdempotencyKeys(ctx context.Context, now time.Time) (int64, error)
+}
+
+// Storage объединяет все хранилища; PostgresRepository и MemoryRepository реализуют его целиком
+type Storage interface {
+	SubscriptionRepository
+	ExchangeRateRepository
+	AuditRepository
+	APIKeyRepository
+	IdempotencyRepository
+}
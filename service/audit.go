@@ -0,0 +1,43 @@
+package service
+
+import (
+	"context"
+	"usersubs/models"
+	"usersubs/repository"
+)
+
+// SubscriptionHistory возвращает журнал изменений подписки от новых к старым.
+// История удалённой подписки тоже доступна; ErrNotFound - если записей о подписке нет.
+func (s *SubscriptionsService) SubscriptionHistory(ctx context.Context, id int) ([]models.AuditEntry, error) {
+	filter := models.AuditFilter{SubscriptionID: id}
+	// Клиенту с ролью user видны только записи о его подписках
+	if p, ok := PrincipalFromContext(ctx); ok && !p.IsAdmin() {
+		filter.UserID = p.UserID
+	}
+	entries, err := s.Audit.ListAudit(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	if len(entries) == 0 {
+		return nil, repository.ErrNotFound
+	}
+	return entries, nil
+}
+
+// ListAudit возвращает записи журнала по фильтру; размер выборки ограничивается MaxPageSize
+func (s *SubscriptionsService) ListAudit(ctx context.Context, filter models.AuditFilter) ([]models.AuditEntry, error) {
+	if err := requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+	if filter.Limit <= 0 {
+		filter.Limit = DefaultPageSize
+	}
+	if filter.Limit > MaxPageSize {
+		filter.Limit = MaxPageSize
+	}
+	entries, err := s.Audit.ListAudit(ctx, filter)
+	if entries == nil {
+		entries = []models.AuditEntry{}
+	}
+	return entries, err
+}
@@ -0,0 +1,152 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"usersubs/models"
+	"usersubs/repository"
+	"usersubs/utils"
+)
+
+var (
+	// Учётные данные не переданы или недействительны
+	ErrUnauthorized = errors.New("требуется аутентификация")
+	// Клиенту недоступна запрошенная операция или чужие данные
+	ErrForbidden = errors.New("доступ запрещён")
+	// Некорректные параметры создаваемого ключа
+	ErrInvalidAPIKey = errors.New("некорректные параметры ключа")
+)
+
+const (
+	apiKeyPrefix    = "usk_"
+	apiKeyPrefixLen = len(apiKeyPrefix) + 8 // Сколько символов ключа показывается в списке
+	// Субъект в журнале изменений для ключа из ADMIN_API_KEY
+	bootstrapAdminSubject = "admin_key:bootstrap"
+)
+
+type principalKey struct{}
+
+// WithPrincipal возвращает контекст с аутентифицированным клиентом
+func WithPrincipal(ctx context.Context, p models.Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// PrincipalFromContext возвращает клиента запроса; ok = false для внутренних вызовов без аутентификации
+func PrincipalFromContext(ctx context.Context) (models.Principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(models.Principal)
+	return p, ok
+}
+
+// AuthService выдаёт, отзывает и проверяет API-ключи
+type AuthService struct {
+	Keys repository.APIKeyRepository
+	// AdminKey - ключ администратора из конфигурации для первоначальной настройки; пусто - не используется
+	AdminKey string
+	// Tokens проверяет JWT; nil - аутентификация по токенам не настроена
+	Tokens *TokenVerifier
+	// CalendarKey - секрет для подписи ссылок на календарь продлений; пусто - ссылки не выдаются
+	CalendarKey []byte
+}
+
+func NewAuthService(keys repository.APIKeyRepository, adminKey string, tokens *TokenVerifier, calendarKey []byte) *AuthService {
+	return &AuthService{Keys: keys, AdminKey: adminKey, Tokens: tokens, CalendarKey: calendarKey}
+}
+
+// AuthenticateBearer возвращает клиента по JWT
+func (s *AuthService) AuthenticateBearer(ctx context.Context, token string) (models.Principal, error) {
+	if s.Tokens == nil {
+		return models.Principal{}, fmt.Errorf("%w: аутентификация по JWT не настроена", ErrUnauthorized)
+	}
+	return s.Tokens.Verify(ctx, token)
+}
+
+// hashAPIKey возвращает SHA-256 ключа. Ключи случайные и длинные, поэтому соль и медленный хеш не нужны.
+func hashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// AuthenticateAPIKey возвращает клиента по API-ключу
+func (s *AuthService) AuthenticateAPIKey(ctx context.Context, key string) (models.Principal, error) {
+	if key == "" {
+		return models.Principal{}, ErrUnauthorized
+	}
+	if s.AdminKey != "" && subtle.ConstantTimeCompare([]byte(key), []byte(s.AdminKey)) == 1 {
+		return models.Principal{Subject: bootstrapAdminSubject, Role: models.RoleAdmin}, nil
+	}
+
+	stored, err := s.Keys.FindAPIKey(ctx, hashAPIKey(key))
+	if errors.Is(err, repository.ErrNotFound) {
+		return models.Principal{}, ErrUnauthorized
+	}
+	if err != nil {
+		return models.Principal{}, err
+	}
+	return models.Principal{
+		Subject: "api_key:" + strconv.Itoa(stored.ID),
+		Role:    stored.Role,
+		UserID:  stored.UserID,
+	}, nil
+}
+
+// CreateAPIKey создаёт ключ; секрет возвращается только в ответе на создание
+func (s *AuthService) CreateAPIKey(ctx context.Context, input models.APIKeyInput) (models.CreatedAPIKey, error) {
+	input.Name = strings.TrimSpace(input.Name)
+	if input.Name == "" {
+		return models.CreatedAPIKey{}, utils.Errorf(ErrInvalidAPIKey, utils.MsgKeyNameRequired)
+	}
+	switch input.Role {
+	case models.RoleAdmin:
+		if input.UserID != "" {
+			return models.CreatedAPIKey{}, utils.Errorf(ErrInvalidAPIKey, utils.MsgKeyAdminUserID)
+		}
+	case models.RoleUser:
+		if !utils.IsValidUUID(input.UserID) {
+			return models.CreatedAPIKey{}, utils.Errorf(ErrInvalidAPIKey, utils.MsgKeyUserIDRequired)
+		}
+		input.UserID = strings.ToLower(input.UserID)
+	default:
+		return models.CreatedAPIKey{}, utils.Errorf(ErrInvalidAPIKey, utils.MsgKeyRole)
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return models.CreatedAPIKey{}, err
+	}
+	key := apiKeyPrefix + base64.RawURLEncoding.EncodeToString(secret)
+
+	created, err := s.Keys.CreateAPIKey(ctx, models.APIKey{
+		Name:   input.Name,
+		Prefix: key[:apiKeyPrefixLen],
+		Role:   input.Role,
+		UserID: input.UserID,
+	}, hashAPIKey(key))
+	if err != nil {
+		return models.CreatedAPIKey{}, err
+	}
+	return models.CreatedAPIKey{APIKey: created, Key: key}, nil
+}
+
+func (s *AuthService) ListAPIKeys(ctx context.Context) ([]models.APIKey, error) {
+	keys, err := s.Keys.ListAPIKeys(ctx)
+	if keys == nil {
+		keys = []models.APIKey{}
+	}
+	return keys, err
+}
+
+func (s *AuthService) RevokeAPIKey(ctx context.Context, id int) error {
+	err := s.Keys.RevokeAPIKey(ctx, id)
+	if errors.Is(err, repository.ErrNotFound) {
+		return utils.Errorf(err, utils.MsgKeyNotFound)
+	}
+	return err
+}
@@ -0,0 +1,85 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"usersubs/models"
+	"usersubs/repository"
+	"usersubs/utils"
+)
+
+// Наибольшее число операций в одном пакетном запросе
+const MaxBatchOperations = 100
+
+// Операция не применена: атомарный пакет отменён из-за ошибки другой операции
+var ErrBatchAborted = errors.New("пакет отменён")
+
+// Batch выполняет операции пакета. В режиме atomic все операции выполняются в одной транзакции
+// и ошибка любой из них отменяет остальные; в режиме best_effort операции независимы.
+// Ошибки операций возвращаются в Err результатов; ошибка Batch - сбой, не относящийся к операциям.
+func (s *SubscriptionsService) Batch(ctx context.Context, ops []models.BatchOperation, mode string) ([]models.BatchResult, error) {
+	results := make([]models.BatchResult, len(ops))
+	for i, op := range ops {
+		results[i] = models.BatchResult{Index: i, Op: op.Op}
+	}
+
+	if mode == models.BatchBestEffort {
+		for i, op := range ops {
+			s.applyBatchOperation(ctx, op, &results[i])
+		}
+		return results, nil
+	}
+
+	failed := -1
+	err := s.Repo.InTx(ctx, func(repo repository.SubscriptionRepository) error {
+		// Копия сервиса, работающая в транзакции пакета
+		tx := *s
+		tx.Repo = repo
+		for i, op := range ops {
+			if err := tx.applyBatchOperation(ctx, op, &results[i]); err != nil {
+				failed = i
+				return err
+			}
+		}
+		return nil
+	})
+	if err == nil {
+		return results, nil
+	}
+	if failed < 0 {
+		return nil, err
+	}
+	for i := range results {
+		if i != failed {
+			results[i] = models.BatchResult{Index: i, Op: ops[i].Op, Err: utils.Errorf(ErrBatchAborted, utils.MsgBatchAborted, failed)}
+		}
+	}
+	return results, nil
+}
+
+// applyBatchOperation выполняет одну операцию пакета и записывает итог в result
+func (s *SubscriptionsService) applyBatchOperation(ctx context.Context, op models.BatchOperation, result *models.BatchResult) error {
+	err := op.Err
+	if err == nil {
+		switch op.Op {
+		case models.BatchCreate:
+			result.ID, err = s.CreateSubscription(ctx, op.Subscription)
+		case models.BatchUpdate:
+			var updated models.Subscription
+			if updated, err = s.UpdateSubscription(ctx, op.Update, op.ID, op.IfMatch); err == nil {
+				result.ID, result.Subscription = updated.ID, &updated
+			}
+		case models.BatchDelete:
+			var deleted int64
+			deleted, err = s.DeleteSubscription(ctx, op.ID, op.IfMatch)
+			if err == nil && deleted == 0 {
+				err = repository.ErrNotFound
+			}
+			if err == nil {
+				result.ID = op.ID
+			}
+		}
+	}
+	result.Err = err
+	return err
+}
@@ -0,0 +1,228 @@
+package service
+
+import (
+	"math/big"
+	"time"
+	"usersubs/models"
+)
+
+// activeMonths возвращает первые числа месяцев периода [from, to], в которых подписка активна.
+// Месяцы начала и окончания подписки учитываются целиком; месяц, в котором подписка
+// всё время была приостановлена, не учитывается.
+func activeMonths(sub models.Subscription, from, to time.Time) []time.Time {
+	actualStart := maxTime(sub.StartDate, from)
+	actualEnd := to
+	if sub.EndDate != nil && sub.EndDate.Before(to) {
+		actualEnd = *sub.EndDate
+	}
+
+	var months []time.Time
+	for m := monthStart(actualStart); !m.After(actualEnd); m = m.AddDate(0, 1, 0) {
+		dayFrom := maxTime(m, actualStart)
+		dayTo := minTime(monthEnd(m), actualEnd)
+		if daysBetween(dayFrom, dayTo)+1 > pausedDays(sub, dayFrom, dayTo) {
+			months = append(months, m)
+		}
+	}
+	return months
+}
+
+// isPaused проверяет, что подписка приостановлена в указанный день
+func isPaused(sub models.Subscription, day time.Time) bool {
+	for _, pause := range sub.Pauses {
+		if pause.Covers(day) {
+			return true
+		}
+	}
+	return false
+}
+
+// pausedDays возвращает число дней интервала [from, to], приходящихся на паузы
+func pausedDays(sub models.Subscription, from, to time.Time) int64 {
+	var days int64
+	for _, pause := range sub.Pauses {
+		pauseFrom := maxTime(pause.PausedOn, from)
+		pauseTo := to
+		if pause.ResumedOn != nil {
+			pauseTo = minTime(pause.ResumedOn.AddDate(0, 0, -1), to)
+		}
+		if !pauseTo.Before(pauseFrom) {
+			days += daysBetween(pauseFrom, pauseTo) + 1
+		}
+	}
+	return days
+}
+
+// today возвращает текущую дату (UTC, без времени)
+func today() time.Time {
+	now := time.Now().UTC()
+	return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+func monthStart(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+func maxTime(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
+
+// addMonths прибавляет n месяцев; день, которого нет в итоговом месяце, заменяется последним днём месяца
+// (31 января + 1 месяц = 28/29 февраля)
+func addMonths(t time.Time, n int) time.Time {
+	first := time.Date(t.Year(), t.Month()+time.Month(n), 1, 0, 0, 0, 0, time.UTC)
+	day := t.Day()
+	if last := first.AddDate(0, 1, -1).Day(); day > last {
+		day = last
+	}
+	return first.AddDate(0, 0, day-1)
+}
+
+// monthEnd возвращает последний день месяца
+func monthEnd(t time.Time) time.Time {
+	return monthStart(t).AddDate(0, 1, -1)
+}
+
+// nthCharge возвращает дату n-го списания (n = 0 - дата начала подписки)
+func nthCharge(sub models.Subscription, n int) time.Time {
+	switch sub.BillingPeriod {
+	case models.BillingWeekly:
+		return sub.StartDate.AddDate(0, 0, 7*n)
+	case models.BillingQuarterly:
+		return addMonths(sub.StartDate, 3*n)
+	case models.BillingYearly:
+		return addMonths(sub.StartDate, 12*n)
+	}
+	return addMonths(sub.StartDate, n)
+}
+
+// lastActiveDay возвращает последний день действия подписки (end_date включительно); ok = false для бессрочной
+func lastActiveDay(sub models.Subscription) (time.Time, bool) {
+	if sub.EndDate == nil {
+		return time.Time{}, false
+	}
+	return *sub.EndDate, true
+}
+
+// chargeDates возвращает даты списаний по подписке, попадающие в интервал [from, to] (включительно).
+// Списания, приходящиеся на паузу, пропускаются; график списаний после паузы не сдвигается.
+func chargeDates(sub models.Subscription, from, to time.Time) []time.Time {
+	if last, ok := lastActiveDay(sub); ok && last.Before(to) {
+		to = last
+	}
+
+	var dates []time.Time
+	for n := 0; ; n++ {
+		d := nthCharge(sub, n)
+		if d.After(to) {
+			break
+		}
+		if !d.Before(from) && !isPaused(sub, d) {
+			dates = append(dates, d)
+		}
+	}
+	return dates
+}
+
+// monthlyFactor - коэффициент приведения цены за период списания к цене за месяц
+func monthlyFactor(period string) *big.Rat {
+	switch period {
+	case models.BillingWeekly:
+		return big.NewRat(52, 12)
+	case models.BillingQuarterly:
+		return big.NewRat(1, 3)
+	case models.BillingYearly:
+		return big.NewRat(1, 12)
+	}
+	return big.NewRat(1, 1)
+}
+
+// daysBetween возвращает число дней от a до b (даты без времени, UTC)
+func daysBetween(a, b time.Time) int64 {
+	return int64(b.Sub(a).Hours() / 24)
+}
+
+// minTime возвращает более раннюю из дат
+func minTime(a, b time.Time) time.Time {
+	if a.Before(b) {
+		return a
+	}
+	return b
+}
+
+// proratedByMonth распределяет стоимость подписки по дням: каждый оплаченный период
+// [дата списания, следующая дата списания) стоит цену, действующую на дату списания, а в интервал [from, to] попадает
+// доля, пропорциональная числу активных дней (дни паузы не учитываются).
+// Результат добавляется в add по месяцам.
+func proratedByMonth(sub models.Subscription, from, to time.Time, add func(month time.Time, amount *big.Rat)) {
+	activeFrom := maxTime(sub.StartDate, from)
+	activeTo := to
+	if last, ok := lastActiveDay(sub); ok {
+		activeTo = minTime(last, to)
+	}
+	if activeTo.Before(activeFrom) {
+		return
+	}
+
+	for n := 0; ; n++ {
+		periodStart, periodEnd := nthCharge(sub, n), nthCharge(sub, n+1) // periodEnd не включается
+		if periodStart.After(activeTo) {
+			break
+		}
+		// Пересечение периода с активным интервалом, разбитое по месяцам
+		dayFrom := maxTime(periodStart, activeFrom)
+		dayTo := minTime(periodEnd.AddDate(0, 0, -1), activeTo)
+		periodDays := daysBetween(periodStart, periodEnd)
+		price := int64(sub.PriceAt(periodStart))
+		for d := dayFrom; !d.After(dayTo); {
+			pieceEnd := minTime(monthEnd(d), dayTo)
+			days := daysBetween(d, pieceEnd) + 1 - pausedDays(sub, d, pieceEnd)
+			if days > 0 {
+				add(monthStart(d), big.NewRat(price*days, periodDays))
+			}
+			d = pieceEnd.AddDate(0, 0, 1)
+		}
+	}
+}
+
+// costByMonth распределяет стоимость подписки по месяцам периода [q.From, q.To].
+// Ключ - первое число месяца. Значения точные (big.Rat), округление выполняется при выводе.
+func costByMonth(sub models.Subscription, q models.CostQuery) map[time.Time]*big.Rat {
+	costs := make(map[time.Time]*big.Rat)
+	add := func(month time.Time, amount *big.Rat) {
+		if costs[month] == nil {
+			costs[month] = new(big.Rat)
+		}
+		costs[month].Add(costs[month], amount)
+	}
+	// priceAt - цена, действующая в указанный день, по истории цен
+	priceAt := func(day time.Time) *big.Rat {
+		return big.NewRat(int64(sub.PriceAt(day)), 1)
+	}
+
+	switch {
+	case q.Mode == models.CostModeMonthlyEquivalent:
+		factor := monthlyFactor(sub.BillingPeriod)
+		for _, m := range activeMonths(sub, q.From, q.To) {
+			add(m, new(big.Rat).Mul(priceAt(maxTime(m, sub.StartDate)), factor))
+		}
+	case q.Proration == models.ProrationDaily:
+		proratedByMonth(sub, q.From, q.To, add)
+	default:
+		for _, d := range chargeDates(sub, q.From, q.To) {
+			add(monthStart(d), priceAt(d))
+		}
+	}
+	return costs
+}
+
+// roundRat округляет сумму в минимальных единицах валюты до целого, половины - от нуля
+func roundRat(r *big.Rat) models.Money {
+	num := new(big.Int).Mul(r.Num(), big.NewInt(2))
+	num.Add(num, new(big.Int).Mul(big.NewInt(int64(r.Sign())), r.Denom()))
+	den := new(big.Int).Mul(r.Denom(), big.NewInt(2))
+	return models.Money(num.Quo(num, den).Int64())
+}
@@ -0,0 +1,245 @@
+package service
+
+import (
+	"maps"
+	"math/big"
+	"slices"
+	"testing"
+	"time"
+	"usersubs/models"
+)
+
+// date разбирает дату YYYY-MM-DD для таблиц тестов
+func date(s string) time.Time {
+	t, err := time.Parse("2006-01-02", s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func datePtr(s string) *time.Time {
+	t := date(s)
+	return &t
+}
+
+func formatDates(dates []time.Time) []string {
+	out := make([]string, 0, len(dates))
+	for _, d := range dates {
+		out = append(out, d.Format("2006-01-02"))
+	}
+	return out
+}
+
+func TestAddMonths(t *testing.T) {
+	tests := []struct {
+		from string
+		n    int
+		want string
+	}{
+		{"2026-01-15", 1, "2026-02-15"},
+		{"2026-01-31", 1, "2026-02-28"},
+		{"2028-01-31", 1, "2028-02-29"},
+		{"2026-01-31", 2, "2026-03-31"},
+		{"2026-03-30", 11, "2027-02-28"},
+		{"2026-11-30", 3, "2027-02-28"},
+		{"2026-05-31", 12, "2027-05-31"},
+	}
+	for _, tt := range tests {
+		if got := addMonths(date(tt.from), tt.n).Format("2006-01-02"); got != tt.want {
+			t.Errorf("addMonths(%s, %d) = %s, want %s", tt.from, tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestChargeDates(t *testing.T) {
+	tests := []struct {
+		name     string
+		sub      models.Subscription
+		from, to string
+		want     []string
+	}{
+		{
+			name: "monthly from the 31st",
+			sub:  models.Subscription{BillingPeriod: models.BillingMonthly, StartDate: date("2026-01-31")},
+			from: "2026-01-01", to: "2026-05-31",
+			want: []string{"2026-01-31", "2026-02-28", "2026-03-31", "2026-04-30", "2026-05-31"},
+		},
+		{
+			name: "weekly within the period",
+			sub:  models.Subscription{BillingPeriod: models.BillingWeekly, StartDate: date("2026-01-01")},
+			from: "2026-01-10", to: "2026-01-31",
+			want: []string{"2026-01-15", "2026-01-22", "2026-01-29"},
+		},
+		{
+			name: "quarterly",
+			sub:  models.Subscription{BillingPeriod: models.BillingQuarterly, StartDate: date("2025-11-30")},
+			from: "2026-01-01", to: "2026-12-31",
+			want: []string{"2026-02-28", "2026-05-30", "2026-08-30", "2026-11-30"},
+		},
+		{
+			name: "yearly from 29 February",
+			sub:  models.Subscription{BillingPeriod: models.BillingYearly, StartDate: date("2024-02-29")},
+			from: "2024-01-01", to: "2028-12-31",
+			want: []string{"2024-02-29", "2025-02-28", "2026-02-28", "2027-02-28", "2028-02-29"},
+		},
+		{
+			name: "end date stops charges",
+			sub:  models.Subscription{BillingPeriod: models.BillingMonthly, StartDate: date("2026-01-10"), EndDate: datePtr("2026-03-09")},
+			from: "2026-01-01", to: "2026-12-31",
+			want: []string{"2026-01-10", "2026-02-10"},
+		},
+		{
+			name: "charges during a pause are skipped",
+			sub: models.Subscription{
+				BillingPeriod: models.BillingMonthly, StartDate: date("2026-01-05"),
+				Pauses: []models.Pause{{PausedOn: date("2026-02-01"), ResumedOn: datePtr("2026-03-05")}},
+			},
+			from: "2026-01-01", to: "2026-04-30",
+			want: []string{"2026-01-05", "2026-03-05", "2026-04-05"},
+		},
+		{
+			name: "open pause",
+			sub: models.Subscription{
+				BillingPeriod: models.BillingMonthly, StartDate: date("2026-01-05"),
+				Pauses: []models.Pause{{PausedOn: date("2026-03-01")}},
+			},
+			from: "2026-01-01", to: "2026-06-30",
+			want: []string{"2026-01-05", "2026-02-05"},
+		},
+		{
+			name: "starts after the period",
+			sub:  models.Subscription{BillingPeriod: models.BillingMonthly, StartDate: date("2027-01-01")},
+			from: "2026-01-01", to: "2026-12-31",
+			want: []string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := formatDates(chargeDates(tt.sub, date(tt.from), date(tt.to)))
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("chargeDates = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProratedByMonth(t *testing.T) {
+	monthly := func(start string) models.Subscription {
+		return models.Subscription{BillingPeriod: models.BillingMonthly, Price: 3100, StartDate: date(start)}
+	}
+	tests := []struct {
+		name     string
+		sub      models.Subscription
+		from, to string
+		want     map[string]models.Money
+	}{
+		{
+			name: "whole month",
+			sub:  monthly("2026-01-01"),
+			from: "2026-01-01", to: "2026-01-31",
+			want: map[string]models.Money{"2026-01": 3100},
+		},
+		{
+			name: "period starts inside the interval",
+			sub:  monthly("2026-01-01"),
+			from: "2026-01-16", to: "2026-01-31",
+			want: map[string]models.Money{"2026-01": 1600},
+		},
+		{
+			name: "end date",
+			sub: func() models.Subscription {
+				sub := monthly("2026-01-01")
+				sub.EndDate = datePtr("2026-01-10")
+				return sub
+			}(),
+			from: "2026-01-01", to: "2026-12-31",
+			want: map[string]models.Money{"2026-01": 1000},
+		},
+		{
+			name: "paused days are not charged",
+			sub: func() models.Subscription {
+				sub := monthly("2026-01-01")
+				sub.Pauses = []models.Pause{{PausedOn: date("2026-01-11"), ResumedOn: datePtr("2026-01-21")}}
+				return sub
+			}(),
+			from: "2026-01-01", to: "2026-01-31",
+			want: map[string]models.Money{"2026-01": 2100},
+		},
+		{
+			name: "period split across months",
+			sub:  monthly("2026-01-16"),
+			from: "2026-01-01", to: "2026-02-28",
+			// Февраль: 15 дней периода из 31 и 13 дней периода из 28
+			want: map[string]models.Money{"2026-01": 1600, "2026-02": 2939},
+		},
+		{
+			name: "weekly",
+			sub:  models.Subscription{BillingPeriod: models.BillingWeekly, Price: 700, StartDate: date("2026-01-01")},
+			from: "2026-01-01", to: "2026-01-31",
+			want: map[string]models.Money{"2026-01": 3100},
+		},
+		{
+			name: "price change",
+			sub: func() models.Subscription {
+				sub := monthly("2026-01-01")
+				sub.PriceHistory = []models.PriceChange{
+					{EffectiveMonth: date("2026-01-01"), Price: 3100},
+					{EffectiveMonth: date("2026-02-01"), Price: 2800},
+				}
+				return sub
+			}(),
+			from: "2026-01-01", to: "2026-02-28",
+			want: map[string]models.Money{"2026-01": 3100, "2026-02": 2800},
+		},
+		{
+			name: "ended before the interval",
+			sub: func() models.Subscription {
+				sub := monthly("2025-01-01")
+				sub.EndDate = datePtr("2025-12-31")
+				return sub
+			}(),
+			from: "2026-01-01", to: "2026-01-31",
+			want: map[string]models.Money{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sums := make(map[string]*big.Rat)
+			proratedByMonth(tt.sub, date(tt.from), date(tt.to), func(month time.Time, amount *big.Rat) {
+				key := month.Format("2006-01")
+				if sums[key] == nil {
+					sums[key] = new(big.Rat)
+				}
+				sums[key].Add(sums[key], amount)
+			})
+			got := make(map[string]models.Money)
+			for month, sum := range sums {
+				got[month] = roundRat(sum)
+			}
+			if !maps.Equal(got, tt.want) {
+				t.Errorf("proratedByMonth = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRoundRat(t *testing.T) {
+	tests := []struct {
+		num, den int64
+		want     models.Money
+	}{
+		{1, 2, 1},
+		{-1, 2, -1},
+		{4, 3, 1},
+		{5, 3, 2},
+		{-5, 3, -2},
+		{299, 1, 299},
+		{0, 7, 0},
+	}
+	for _, tt := range tests {
+		if got := roundRat(big.NewRat(tt.num, tt.den)); got != tt.want {
+			t.Errorf("roundRat(%d/%d) = %d, want %d", tt.num, tt.den, got, tt.want)
+		}
+	}
+}
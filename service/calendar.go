@@ -0,0 +1,126 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"usersubs/models"
+)
+
+// ErrCalendarDisabled - секрет для ссылок на календарь продлений не настроен
+var ErrCalendarDisabled = errors.New("ссылки на календарь не настроены")
+
+const (
+	calendarTokenPrefix = "cal_"
+	// Субъект в журнале изменений для доступа по ссылке на календарь
+	calendarSubjectPrefix = "calendar:"
+)
+
+// calendarToken возвращает токен ссылки на календарь пользователя: HMAC-SHA256 от user_id.
+// Токен не хранится; смена секрета отзывает все выданные ссылки.
+func calendarToken(key []byte, userID string) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte("renewals.ics:" + strings.ToLower(userID)))
+	return calendarTokenPrefix + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// CalendarToken возвращает токен секретной ссылки на календарь продлений пользователя
+func (s *AuthService) CalendarToken(ctx context.Context, userID string) (string, error) {
+	if len(s.CalendarKey) == 0 {
+		return "", ErrCalendarDisabled
+	}
+	if _, err := ScopeUserID(ctx, userID); err != nil {
+		return "", err
+	}
+	return calendarToken(s.CalendarKey, userID), nil
+}
+
+// AuthenticateCalendarToken возвращает клиента по токену ссылки на календарь:
+// доступно только чтение подписок пользователя userID
+func (s *AuthService) AuthenticateCalendarToken(userID, token string) (models.Principal, error) {
+	if len(s.CalendarKey) == 0 {
+		return models.Principal{}, fmt.Errorf("%w: %w", ErrUnauthorized, ErrCalendarDisabled)
+	}
+	if !hmac.Equal([]byte(token), []byte(calendarToken(s.CalendarKey, userID))) {
+		return models.Principal{}, ErrUnauthorized
+	}
+	return models.Principal{
+		Subject: calendarSubjectPrefix + strings.ToLower(userID),
+		Role:    models.RoleUser,
+		UserID:  strings.ToLower(userID),
+		Scopes:  []string{models.ScopeSubscriptionsRead},
+	}, nil
+}
+
+// Renewals передаёт в fn действующие подписки пользователя, у которых ещё будут продления
+func (s *SubscriptionsService) Renewals(ctx context.Context, userID string, fn func(sub models.Subscription) error) error {
+	from := today()
+	filter := models.SubscriptionFilter{UserID: userID, ActiveFrom: &from, SortBy: models.SortByStartDate}
+	return s.ExportSubscriptions(ctx, filter, func(sub models.Subscription) error {
+		if sub.Status != models.StatusActive || (sub.EndDate != nil && sub.EndDate.Before(from)) {
+			return nil
+		}
+		return fn(sub)
+	})
+}
+
+// RenewalRule возвращает правило повторения RRULE (RFC 5545) для дат списаний подписки
+// и даты списаний, пропущенных из-за пауз (EXDATE)
+func RenewalRule(sub models.Subscription) (string, []time.Time) {
+	var rule string
+	switch sub.BillingPeriod {
+	case models.BillingWeekly:
+		rule = "FREQ=WEEKLY"
+	case models.BillingQuarterly:
+		rule = "FREQ=MONTHLY;INTERVAL=3"
+	case models.BillingYearly:
+		rule = "FREQ=YEARLY"
+	default:
+		rule = "FREQ=MONTHLY"
+	}
+	// addMonths переносит списание 29-31 числа на последний день короткого месяца, а по RFC 5545
+	// несуществующие даты пропускаются. Берётся последний из дней 28..день начала, который есть в месяце.
+	if day := sub.StartDate.Day(); day > 28 && sub.BillingPeriod != models.BillingWeekly {
+		if sub.BillingPeriod == models.BillingYearly {
+			rule += ";BYMONTH=" + strconv.Itoa(int(sub.StartDate.Month()))
+		}
+		days := make([]string, 0, day-27)
+		for d := 28; d <= day; d++ {
+			days = append(days, strconv.Itoa(d))
+		}
+		rule += ";BYMONTHDAY=" + strings.Join(days, ",") + ";BYSETPOS=-1"
+	}
+	if sub.EndDate != nil {
+		rule += ";UNTIL=" + sub.EndDate.Format("20060102")
+	}
+	return rule, pausedCharges(sub)
+}
+
+// pausedCharges возвращает даты списаний, приходящиеся на завершённые паузы
+func pausedCharges(sub models.Subscription) []time.Time {
+	var last time.Time
+	for _, pause := range sub.Pauses {
+		if pause.ResumedOn != nil && pause.ResumedOn.After(last) {
+			last = *pause.ResumedOn
+		}
+	}
+	if last.IsZero() {
+		return nil
+	}
+	var dates []time.Time
+	for n := 0; ; n++ {
+		d := nthCharge(sub, n)
+		if !d.Before(last) || (sub.EndDate != nil && d.After(*sub.EndDate)) {
+			return dates
+		}
+		if isPaused(sub, d) {
+			dates = append(dates, d)
+		}
+	}
+}
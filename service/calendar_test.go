@@ -0,0 +1,83 @@
+package service
+
+import (
+	"slices"
+	"testing"
+	"time"
+	"usersubs/models"
+)
+
+func TestRenewalRule(t *testing.T) {
+	tests := []struct {
+		period  string
+		start   string
+		endDate *time.Time
+		want    string
+	}{
+		{models.BillingMonthly, "2026-01-15", nil, "FREQ=MONTHLY"},
+		{models.BillingMonthly, "2026-01-28", nil, "FREQ=MONTHLY"},
+		{models.BillingMonthly, "2026-01-29", nil, "FREQ=MONTHLY;BYMONTHDAY=28,29;BYSETPOS=-1"},
+		{models.BillingMonthly, "2026-01-30", nil, "FREQ=MONTHLY;BYMONTHDAY=28,29,30;BYSETPOS=-1"},
+		{models.BillingMonthly, "2026-01-31", nil, "FREQ=MONTHLY;BYMONTHDAY=28,29,30,31;BYSETPOS=-1"},
+		{models.BillingQuarterly, "2026-01-31", nil, "FREQ=MONTHLY;INTERVAL=3;BYMONTHDAY=28,29,30,31;BYSETPOS=-1"},
+		{models.BillingYearly, "2024-02-29", nil, "FREQ=YEARLY;BYMONTH=2;BYMONTHDAY=28,29;BYSETPOS=-1"},
+		{models.BillingYearly, "2026-03-31", nil, "FREQ=YEARLY;BYMONTH=3;BYMONTHDAY=28,29,30,31;BYSETPOS=-1"},
+		{models.BillingWeekly, "2026-01-31", nil, "FREQ=WEEKLY"},
+		{models.BillingMonthly, "2026-01-31", datePtr("2026-12-31"), "FREQ=MONTHLY;BYMONTHDAY=28,29,30,31;BYSETPOS=-1;UNTIL=20261231"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.period+" "+tt.start, func(t *testing.T) {
+			sub := models.Subscription{BillingPeriod: tt.period, StartDate: date(tt.start), EndDate: tt.endDate}
+			if got, _ := RenewalRule(sub); got != tt.want {
+				t.Errorf("RenewalRule = %q, want %q", got, tt.want)
+			}
+
+			// BYMONTHDAY=28..день;BYSETPOS=-1 выбирает min(день, длина месяца) - тот же день, что и chargeDates
+			if tt.period == models.BillingWeekly {
+				return
+			}
+			day := sub.StartDate.Day()
+			for _, d := range chargeDates(sub, sub.StartDate, sub.StartDate.AddDate(4, 0, 0)) {
+				if want := min(day, monthEnd(d).Day()); d.Day() != want {
+					t.Errorf("charge on %s, the rule gives day %d", d.Format("2006-01-02"), want)
+				}
+			}
+		})
+	}
+}
+
+func TestPausedCharges(t *testing.T) {
+	tests := []struct {
+		name   string
+		pauses []models.Pause
+		want   []string
+	}{
+		{name: "no pauses"},
+		{
+			name:   "completed pause",
+			pauses: []models.Pause{{PausedOn: date("2026-03-01"), ResumedOn: datePtr("2026-05-01")}},
+			want:   []string{"2026-03-31", "2026-04-30"},
+		},
+		{
+			name:   "ongoing pause is not excluded",
+			pauses: []models.Pause{{PausedOn: date("2026-03-01")}},
+		},
+		{
+			name: "two pauses",
+			pauses: []models.Pause{
+				{PausedOn: date("2026-02-01"), ResumedOn: datePtr("2026-03-01")},
+				{PausedOn: date("2026-06-15"), ResumedOn: datePtr("2026-07-15")},
+			},
+			want: []string{"2026-02-28", "2026-06-30"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sub := models.Subscription{BillingPeriod: models.BillingMonthly, StartDate: date("2026-01-31"), Pauses: tt.pauses}
+			_, exDates := RenewalRule(sub)
+			if got := formatDates(exDates); !slices.Equal(got, tt.want) {
+				t.Errorf("EXDATE = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,131 @@
+package service
+
+import (
+	"context"
+	"math/big"
+	"time"
+	"usersubs/logger"
+	"usersubs/models"
+
+	"go.uber.org/zap"
+)
+
+// MaxCostPeriodMonths - наибольшая длина периода подсчёта стоимости в месяцах
+const MaxCostPeriodMonths = 120
+
+// forEachCost вызывает fn для стоимости каждой подписки в каждом месяце периода,
+// переведённой в валюту q.Currency по курсу этого месяца
+func (s *SubscriptionsService) forEachCost(ctx context.Context, q models.CostQuery,
+	fn func(sub models.Subscription, month time.Time, cost *big.Rat)) error {
+	userID, err := ScopeUserID(ctx, q.UserID)
+	if err != nil {
+		return err
+	}
+	q.UserID = userID
+	if q.IncludeDeleted {
+		if err := requireAdmin(ctx); err != nil {
+			return err
+		}
+	}
+	subs, err := s.Repo.ListForPeriod(ctx, q.UserID, q.ServiceName, q.From, q.To, q.IncludeDeleted)
+	if err != nil {
+		return err
+	}
+
+	currencies := []string{q.Currency}
+	for _, sub := range subs {
+		currencies = append(currencies, sub.Currency)
+	}
+	rates, err := loadRateTable(ctx, s.Rates, currencies, monthEnd(q.To))
+	if err != nil {
+		return err
+	}
+
+	for _, sub := range subs {
+		for month, cost := range costByMonth(sub, q) {
+			converted, err := rates.convert(cost, sub.Currency, q.Currency, month)
+			if err != nil {
+				return err
+			}
+			fn(sub, month, converted)
+		}
+	}
+	logger.L().Debug("Подсчитана стоимость", zap.Int("subscriptions", len(subs)), zap.String("mode", q.Mode))
+	return nil
+}
+
+// GetTotalCost считает суммарную стоимость подписок за период [q.From, q.To] (месяцы включительно)
+// в валюте q.Currency
+func (s *SubscriptionsService) GetTotalCost(ctx context.Context, q models.CostQuery) (models.TotalCost, error) {
+	total := new(big.Rat)
+	err := s.forEachCost(ctx, q, func(_ models.Subscription, _ time.Time, cost *big.Rat) {
+		total.Add(total, cost)
+	})
+	if err != nil {
+		return models.TotalCost{}, err
+	}
+	return models.TotalCost{TotalCost: roundRat(total), Currency: q.Currency}, nil
+}
+
+// GetCostBreakdown считает стоимость подписок по каждому месяцу периода [q.From, q.To]
+// с той же логикой, что и GetTotalCost.
+// q.GroupBy - пусто, models.GroupByServiceName или models.GroupByUserID.
+func (s *SubscriptionsService) GetCostBreakdown(ctx context.Context, q models.CostQuery) (models.CostBreakdown, error) {
+	// Суммы копим точными и округляем только при формировании ответа
+	type monthSums struct {
+		total  *big.Rat
+		groups map[string]*big.Rat
+	}
+	sums := make(map[time.Time]*monthSums)
+	grandTotal := new(big.Rat)
+
+	err := s.forEachCost(ctx, q, func(sub models.Subscription, m time.Time, cost *big.Rat) {
+		ms := sums[m]
+		if ms == nil {
+			ms = &monthSums{total: new(big.Rat), groups: make(map[string]*big.Rat)}
+			sums[m] = ms
+		}
+		ms.total.Add(ms.total, cost)
+		grandTotal.Add(grandTotal, cost)
+
+		group := ""
+		switch q.GroupBy {
+		case models.GroupByServiceName:
+			group = sub.ServiceName
+		case models.GroupByUserID:
+			group = sub.UserID
+		default:
+			return
+		}
+		if ms.groups[group] == nil {
+			ms.groups[group] = new(big.Rat)
+		}
+		ms.groups[group].Add(ms.groups[group], cost)
+	})
+	if err != nil {
+		return models.CostBreakdown{}, err
+	}
+
+	// Заполняем все месяцы периода, включая месяцы без подписок
+	breakdown := models.CostBreakdown{
+		GroupBy:  q.GroupBy,
+		Currency: q.Currency,
+		Months:   []models.MonthlyCost{},
+		Total:    roundRat(grandTotal),
+	}
+	for m := monthStart(q.From); !m.After(monthStart(q.To)); m = m.AddDate(0, 1, 0) {
+		month := models.MonthlyCost{Month: m.Format("01-2006")}
+		if q.GroupBy != "" {
+			month.Groups = make(map[string]models.Money)
+		}
+		if ms := sums[m]; ms != nil {
+			month.Total = roundRat(ms.total)
+			for group, cost := range ms.groups {
+				month.Groups[group] = roundRat(cost)
+			}
+		}
+		breakdown.Months = append(breakdown.Months, month)
+	}
+
+	return breakdown, nil
+}
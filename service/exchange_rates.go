@@ -0,0 +1,306 @@
+package service
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+	"usersubs/models"
+	"usersubs/repository"
+	"usersubs/utils"
+)
+
+// Форматы файлов курсов
+const (
+	RatesFormatCSV = "csv" // date,currency,rate[,nominal]
+	RatesFormatCBR = "cbr" // XML в формате ЦБ РФ (XML_daily.asp)
+)
+
+var ErrInvalidRatesFile = errors.New("некорректный файл курсов")
+
+// MissingRateError - не найден курс валюты на нужный месяц
+type MissingRateError struct {
+	Currency string
+	Month    time.Time
+}
+
+func (e *MissingRateError) Error() string {
+	return fmt.Sprintf("нет курса %s на %s", e.Currency, e.Month.Format("01-2006"))
+}
+
+type ExchangeRateService struct {
+	Repo repository.ExchangeRateRepository
+}
+
+func NewExchangeRateService(repo repository.ExchangeRateRepository) *ExchangeRateService {
+	return &ExchangeRateService{Repo: repo}
+}
+
+// Import разбирает файл курсов в формате format и сохраняет курсы
+func (s *ExchangeRateService) Import(ctx context.Context, format string, r io.Reader) (models.RatesImportResult, error) {
+	var rates []models.ExchangeRate
+	var err error
+	switch format {
+	case RatesFormatCSV:
+		rates, err = parseRatesCSV(r)
+	case RatesFormatCBR:
+		rates, err = parseRatesCBR(r)
+	default:
+		return models.RatesImportResult{}, utils.Errorf(ErrInvalidRatesFile, utils.MsgRatesUnknownFormat, format)
+	}
+	if err != nil {
+		return models.RatesImportResult{}, err
+	}
+	if err := s.Repo.SaveRates(ctx, rates); err != nil {
+		return models.RatesImportResult{}, err
+	}
+	return models.RatesImportResult{Imported: len(rates)}, nil
+}
+
+// ListRates возвращает курсы валюты, действующие не позже until
+func (s *ExchangeRateService) ListRates(ctx context.Context, currency string, until time.Time) ([]models.ExchangeRate, error) {
+	rates, err := s.Repo.ListRates(ctx, currency, until)
+	if rates == nil {
+		rates = []models.ExchangeRate{}
+	}
+	return rates, err
+}
+
+// newRate проверяет значения и приводит курс к рублям за одну единицу валюты
+func newRate(currency, date, value, nominal string) (models.ExchangeRate, error) {
+	currency = strings.ToUpper(strings.TrimSpace(currency))
+	// Курс не зависит от минимальной единицы валюты, поэтому проверяется только формат кода
+	if !models.IsCurrencyCode(currency) {
+		return models.ExchangeRate{}, utils.Errorf(ErrInvalidRatesFile, utils.MsgRatesCurrency, currency)
+	}
+
+	d, err := parseRateDate(strings.TrimSpace(date))
+	if err != nil {
+		return models.ExchangeRate{}, err
+	}
+
+	rate, ok := parseDecimal(value)
+	if !ok || rate.Sign() <= 0 {
+		return models.ExchangeRate{}, utils.Errorf(ErrInvalidRatesFile, utils.MsgRatesValue, value)
+	}
+	if nominal = strings.TrimSpace(nominal); nominal != "" {
+		n, ok := parseDecimal(nominal)
+		if !ok || n.Sign() <= 0 {
+			return models.ExchangeRate{}, utils.Errorf(ErrInvalidRatesFile, utils.MsgRatesNominal, nominal)
+		}
+		rate.Quo(rate, n)
+	}
+
+	return models.ExchangeRate{Currency: currency, Date: d, Rate: rate.FloatString(10)}, nil
+}
+
+// parseRateDate принимает даты YYYY-MM-DD и DD.MM.YYYY (формат ЦБ)
+func parseRateDate(s string) (time.Time, error) {
+	for _, layout := range []string{"2006-01-02", "02.01.2006"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, utils.Errorf(ErrInvalidRatesFile, utils.MsgRatesDate, s)
+}
+
+// parseDecimal разбирает десятичное число; допускается запятая как разделитель
+func parseDecimal(s string) (*big.Rat, bool) {
+	s = strings.ReplaceAll(strings.TrimSpace(s), ",", ".")
+	if s == "" {
+		return nil, false
+	}
+	return new(big.Rat).SetString(s)
+}
+
+// parseRatesCSV читает CSV с заголовком: date,currency,rate и необязательной колонкой nominal
+func parseRatesCSV(r io.Reader) ([]models.ExchangeRate, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, utils.Errorf(ErrInvalidRatesFile, utils.MsgRatesHeader, fileErrorMessage(err))
+	}
+	columns := make(map[string]int)
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, name := range []string{"date", "currency", "rate"} {
+		if _, ok := columns[name]; !ok {
+			return nil, utils.Errorf(ErrInvalidRatesFile, utils.MsgRatesMissingColumn, name)
+		}
+	}
+	field := func(record []string, name string) string {
+		i, ok := columns[name]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return record[i]
+	}
+
+	var rates []models.ExchangeRate
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, utils.Errorf(ErrInvalidRatesFile, utils.MsgRatesLine, line, fileErrorMessage(err))
+		}
+		rate, err := newRate(field(record, "currency"), field(record, "date"), field(record, "rate"), field(record, "nominal"))
+		if err != nil {
+			return nil, utils.Errorf(ErrInvalidRatesFile, utils.MsgRatesLineValue, line, fileErrorMessage(err))
+		}
+		rates = append(rates, rate)
+	}
+	return rates, nil
+}
+
+// cbrValCurs - структура XML ЦБ РФ
+type cbrValCurs struct {
+	Date    string `xml:"Date,attr"`
+	Valutes []struct {
+		CharCode string `xml:"CharCode"`
+		Nominal  string `xml:"Nominal"`
+		Value    string `xml:"Value"`
+	} `xml:"Valute"`
+}
+
+// parseRatesCBR читает XML ЦБ РФ; также принимает несколько ValCurs внутри произвольного корневого элемента
+func parseRatesCBR(r io.Reader) ([]models.ExchangeRate, error) {
+	decoder := xml.NewDecoder(r)
+	decoder.CharsetReader = charsetReader
+
+	var rates []models.ExchangeRate
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, utils.Errorf(ErrInvalidRatesFile, utils.MsgRatesParse, fileErrorMessage(err))
+		}
+		start, ok := token.(xml.StartElement)
+		if !ok || start.Name.Local != "ValCurs" {
+			continue
+		}
+
+		var valCurs cbrValCurs
+		if err := decoder.DecodeElement(&valCurs, &start); err != nil {
+			return nil, utils.Errorf(ErrInvalidRatesFile, utils.MsgRatesParse, fileErrorMessage(err))
+		}
+		for _, v := range valCurs.Valutes {
+			rate, err := newRate(v.CharCode, valCurs.Date, v.Value, v.Nominal)
+			if err != nil {
+				return nil, utils.Errorf(ErrInvalidRatesFile, utils.MsgRatesValCursValue, valCurs.Date, fileErrorMessage(err))
+			}
+			rates = append(rates, rate)
+		}
+	}
+	if len(rates) == 0 {
+		return nil, utils.Errorf(ErrInvalidRatesFile, utils.MsgRatesNoValutes)
+	}
+	return rates, nil
+}
+
+// charsetReader поддерживает windows-1251, в которой ЦБ публикует XML
+func charsetReader(charset string, input io.Reader) (io.Reader, error) {
+	switch strings.ToLower(charset) {
+	case "utf-8", "utf8":
+		return input, nil
+	case "windows-1251", "cp1251":
+		data, err := io.ReadAll(input)
+		if err != nil {
+			return nil, err
+		}
+		return strings.NewReader(decodeWindows1251(data)), nil
+	}
+	return nil, utils.Errorf(ErrInvalidRatesFile, utils.MsgRatesCharset, charset)
+}
+
+// decodeWindows1251 переводит текст из windows-1251 в UTF-8.
+// Кириллица декодируется точно, прочие символы верхней половины таблицы заменяются на U+FFFD.
+func decodeWindows1251(data []byte) string {
+	var b strings.Builder
+	for _, c := range data {
+		switch {
+		case c < 0x80:
+			b.WriteByte(c)
+		case c >= 0xC0:
+			b.WriteRune(rune(0x0410 + int(c) - 0xC0))
+		case c == 0xA8:
+			b.WriteRune('Ё')
+		case c == 0xB8:
+			b.WriteRune('ё')
+		default:
+			b.WriteRune(utf8.RuneError)
+		}
+	}
+	return b.String()
+}
+
+// rateTable - курсы валют, загруженные для подсчёта стоимости
+type rateTable map[string][]models.ExchangeRate
+
+// loadRateTable загружает курсы указанных валют, действующие не позже until
+func loadRateTable(ctx context.Context, repo repository.ExchangeRateRepository, currencies []string, until time.Time) (rateTable, error) {
+	table := make(rateTable)
+	for _, currency := range currencies {
+		if currency == models.BaseCurrency {
+			continue
+		}
+		if _, ok := table[currency]; ok {
+			continue
+		}
+		rates, err := repo.ListRates(ctx, currency, until)
+		if err != nil {
+			return nil, err
+		}
+		table[currency] = rates
+	}
+	return table, nil
+}
+
+// rate возвращает курс валюты для месяца: последний курс, действующий на конец месяца
+func (t rateTable) rate(currency string, month time.Time) (*big.Rat, error) {
+	if currency == models.BaseCurrency {
+		return big.NewRat(1, 1), nil
+	}
+	rates := t[currency]
+	end := monthEnd(month)
+	i := sort.Search(len(rates), func(i int) bool { return rates[i].Date.After(end) })
+	if i == 0 {
+		return nil, &MissingRateError{Currency: currency, Month: month}
+	}
+	rate, ok := new(big.Rat).SetString(rates[i-1].Rate)
+	if !ok {
+		return nil, fmt.Errorf("некорректный курс %s в хранилище: %q", currency, rates[i-1].Rate)
+	}
+	return rate, nil
+}
+
+// convert переводит сумму из валюты from в валюту to по курсам месяца month
+func (t rateTable) convert(amount *big.Rat, from, to string, month time.Time) (*big.Rat, error) {
+	if from == to {
+		return amount, nil
+	}
+	fromRate, err := t.rate(from, month)
+	if err != nil {
+		return nil, err
+	}
+	toRate, err := t.rate(to, month)
+	if err != nil {
+		return nil, err
+	}
+	converted := new(big.Rat).Mul(amount, fromRate)
+	return converted.Quo(converted, toRate), nil
+}
@@ -0,0 +1,44 @@
+package service
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"io"
+	"net/http"
+	"usersubs/utils"
+)
+
+// fileErrorMessage переводит ошибку чтения или разбора загруженного файла в сообщение каталога.
+// Тексты ошибок стандартной библиотеки не переведены, поэтому в сообщение попадают только позиция и вид ошибки.
+func fileErrorMessage(err error) utils.Message {
+	if msg, ok := utils.MessageOf(err); ok {
+		return msg
+	}
+	var csvErr *csv.ParseError
+	var xmlErr *xml.SyntaxError
+	var jsonErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	var sizeErr *http.MaxBytesError
+	switch {
+	case errors.Is(err, io.EOF):
+		return utils.NewMessage(utils.MsgFileEmpty)
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		return utils.NewMessage(utils.MsgFileTruncated)
+	case errors.Is(err, bufio.ErrTooLong):
+		return utils.NewMessage(utils.MsgLineTooLong)
+	case errors.As(err, &sizeErr):
+		return utils.NewMessage(utils.MsgFileTooLarge, sizeErr.Limit)
+	case errors.As(err, &csvErr):
+		return utils.NewMessage(utils.MsgCSVSyntax, csvErr.Column)
+	case errors.As(err, &xmlErr):
+		return utils.NewMessage(utils.MsgXMLSyntax, xmlErr.Line)
+	case errors.As(err, &jsonErr):
+		return utils.NewMessage(utils.MsgJSONSyntax, jsonErr.Offset)
+	case errors.As(err, &typeErr):
+		return utils.NewMessage(utils.MsgJSONType, typeErr.Field)
+	}
+	return utils.NewMessage(utils.MsgFileUnreadable)
+}
@@ -0,0 +1,82 @@
+package service
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"testing"
+	"usersubs/utils"
+)
+
+func TestFileErrorMessage(t *testing.T) {
+	var v struct {
+		Price string `json:"price"`
+	}
+	syntaxErr := json.Unmarshal([]byte(`{"price":`), &v)
+	typeErr := json.Unmarshal([]byte(`{"price":1}`), &v)
+
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{"empty file", io.EOF, "the file is empty"},
+		{"truncated", fmt.Errorf("read: %w", io.ErrUnexpectedEOF), "the file is truncated"},
+		{"long line", bufio.ErrTooLong, "the line is too long"},
+		{"size limit", &http.MaxBytesError{Limit: 1024}, "the file exceeds 1024 bytes"},
+		{"csv", &csv.ParseError{StartLine: 3, Line: 3, Column: 7, Err: csv.ErrQuote}, "CSV syntax error at column 7"},
+		{"xml", &xml.SyntaxError{Msg: "unexpected EOF", Line: 12}, "XML syntax error at line 12"},
+		{"json syntax", syntaxErr, "JSON syntax error at offset 9"},
+		{"json type", typeErr, "wrong value type for field price"},
+		{"catalog message", utils.Errorf(ErrInvalidRatesFile, utils.MsgRatesCurrency, "US"), `currency code "US"`},
+		{"other error", errors.New("open /var/lib/rates.csv: permission denied"), "cannot read the file"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := fileErrorMessage(tt.err).In(utils.LangEN); got != tt.want {
+				t.Errorf("fileErrorMessage(%v) = %q, want %q", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewRate(t *testing.T) {
+	tests := []struct {
+		name                           string
+		currency, date, value, nominal string
+		want                           string // Курс за единицу валюты; пусто - ошибка
+		wantErr                        string // Сообщение ошибки на английском
+	}{
+		{name: "plain", currency: "usd", date: "2026-01-15", value: "90.5", want: "90.5000000000"},
+		{name: "CBR format with nominal", currency: "JPY", date: "15.01.2026", value: "57,1234", nominal: "100", want: "0.5712340000"},
+		{name: "bad currency", currency: "US", date: "2026-01-15", value: "90", wantErr: `currency code "US"`},
+		{name: "bad date", currency: "USD", date: "2026/01/15", value: "90", wantErr: `date "2026/01/15"`},
+		{name: "zero rate", currency: "USD", date: "2026-01-15", value: "0", wantErr: `rate "0"`},
+		{name: "bad nominal", currency: "USD", date: "2026-01-15", value: "90", nominal: "-1", wantErr: `nominal "-1"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rate, err := newRate(tt.currency, tt.date, tt.value, tt.nominal)
+			if tt.wantErr != "" {
+				if !errors.Is(err, ErrInvalidRatesFile) {
+					t.Fatalf("newRate error = %v, want ErrInvalidRatesFile", err)
+				}
+				if got := fileErrorMessage(err).In(utils.LangEN); got != tt.wantErr {
+					t.Errorf("message = %q, want %q", got, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("newRate: %v", err)
+			}
+			if rate.Rate != tt.want {
+				t.Errorf("Rate = %s, want %s", rate.Rate, tt.want)
+			}
+		})
+	}
+}
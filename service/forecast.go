@@ -0,0 +1,109 @@
+package service
+
+import (
+	"context"
+	"math/big"
+	"time"
+	"usersubs/models"
+)
+
+// MaxForecastMonths - наибольшая длина прогноза в месяцах
+const MaxForecastMonths = 120
+
+// GetForecast прогнозирует расходы на подписки на q.Months месяцев начиная с текущего.
+// Учитываются даты списаний по периоду подписки, end_date, паузы и запланированные изменения цены;
+// при заданном росте цен цена, действующая на дату списания, увеличивается на годовой процент
+// за каждый полный год от начала прогноза (или от месяца изменения цены, если оно позже).
+// Суммы переводятся в q.Currency по последнему известному курсу на каждый месяц.
+func (s *SubscriptionsService) GetForecast(ctx context.Context, q models.ForecastQuery) (models.Forecast, error) {
+	userID, err := ScopeUserID(ctx, q.UserID)
+	if err != nil {
+		return models.Forecast{}, err
+	}
+	from := monthStart(today())
+	to := monthEnd(from.AddDate(0, q.Months-1, 0))
+	subs, err := s.Repo.ListForPeriod(ctx, userID, q.ServiceName, from, to, false)
+	if err != nil {
+		return models.Forecast{}, err
+	}
+
+	currencies := []string{q.Currency}
+	for _, sub := range subs {
+		currencies = append(currencies, sub.Currency)
+	}
+	rates, err := loadRateTable(ctx, s.Rates, currencies, to)
+	if err != nil {
+		return models.Forecast{}, err
+	}
+
+	sums := make(map[time.Time]*big.Rat)
+	for _, sub := range subs {
+		inflation := q.InflationFor(sub.ServiceName)
+		for _, d := range chargeDates(sub, from, to) {
+			amount := new(big.Rat).SetInt64(int64(sub.PriceAt(d)))
+			if inflation != nil {
+				base := maxTime(from, priceEffectiveMonth(sub, d))
+				amount.Mul(amount, compound(inflation, fullYears(base, d)))
+			}
+			month := monthStart(d)
+			converted, err := rates.convert(amount, sub.Currency, q.Currency, month)
+			if err != nil {
+				return models.Forecast{}, err
+			}
+			if sums[month] == nil {
+				sums[month] = new(big.Rat)
+			}
+			sums[month].Add(sums[month], converted)
+		}
+	}
+
+	// Накопленный итог считается по точным суммам, поэтому может отличаться от суммы округлённых месяцев
+	forecast := models.Forecast{Currency: q.Currency, Months: make([]models.ForecastMonth, 0, q.Months)}
+	cumulative := new(big.Rat)
+	for m := from; m.Before(to); m = m.AddDate(0, 1, 0) {
+		month := models.ForecastMonth{Month: m.Format("01-2006")}
+		if sum := sums[m]; sum != nil {
+			month.Total = roundRat(sum)
+			cumulative.Add(cumulative, sum)
+		}
+		month.Cumulative = roundRat(cumulative)
+		forecast.Months = append(forecast.Months, month)
+	}
+	forecast.Total = roundRat(cumulative)
+	return forecast, nil
+}
+
+// priceEffectiveMonth возвращает месяц, с которого действует цена подписки на дату day;
+// без истории цен - дату начала подписки
+func priceEffectiveMonth(sub models.Subscription, day time.Time) time.Time {
+	effective := sub.StartDate
+	for _, change := range sub.PriceHistory {
+		if change.EffectiveMonth.After(day) {
+			break
+		}
+		effective = change.EffectiveMonth
+	}
+	return effective
+}
+
+// fullYears возвращает число полных лет от from до to
+func fullYears(from, to time.Time) int {
+	months := (to.Year()-from.Year())*12 + int(to.Month()-from.Month())
+	if to.Day() < from.Day() {
+		months--
+	}
+	if months < 0 {
+		return 0
+	}
+	return months / 12
+}
+
+// compound возвращает множитель (1 + rate)^years
+func compound(rate *big.Rat, years int) *big.Rat {
+	factor := big.NewRat(1, 1)
+	step := new(big.Rat).Add(big.NewRat(1, 1), rate)
+	for range years {
+		factor.Mul(factor, step)
+	}
+	return factor
+}
@@ -0,0 +1,123 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"time"
+	"usersubs/logger"
+	"usersubs/models"
+	"usersubs/repository"
+
+	"go.uber.org/zap"
+)
+
+// Максимальная длина значения Idempotency-Key
+const maxIdempotencyKeyLen = 255
+
+var (
+	// Некорректное значение заголовка Idempotency-Key
+	ErrInvalidIdempotencyKey = errors.New("некорректный Idempotency-Key")
+	// Ключ уже использован с другим телом запроса
+	ErrIdempotencyMismatch = errors.New("Idempotency-Key уже использован с другим телом запроса")
+	// Первый запрос с этим ключом ещё выполняется
+	ErrIdempotencyInProgress = errors.New("запрос с этим Idempotency-Key ещё выполняется")
+)
+
+// IdempotencyService хранит ответы на запросы с Idempotency-Key в течение TTL
+type IdempotencyService struct {
+	Repo repository.IdempotencyRepository
+	TTL  time.Duration
+}
+
+func NewIdempotencyService(repo repository.IdempotencyRepository, ttl time.Duration) *IdempotencyService {
+	return &IdempotencyService{Repo: repo, TTL: ttl}
+}
+
+// IdempotencyOwner возвращает владельца ключа: user_id клиента, для администратора - его идентификатор
+func IdempotencyOwner(ctx context.Context) string {
+	p, ok := PrincipalFromContext(ctx)
+	switch {
+	case !ok:
+		return ""
+	case p.UserID != "":
+		return "user:" + p.UserID
+	}
+	return p.Subject
+}
+
+// HashRequest возвращает хеш тела запроса. JSON приводится к каноническому виду,
+// поэтому порядок полей и пробелы не влияют на результат.
+func HashRequest(body []byte) string {
+	var v any
+	if err := json.Unmarshal(body, &v); err == nil {
+		if canonical, err := json.Marshal(v); err == nil {
+			body = canonical
+		}
+	}
+	sum := sha256.Sum256(bytes.TrimSpace(body))
+	return hex.EncodeToString(sum[:])
+}
+
+// Begin занимает ключ за запросом. Если на запрос с тем же ключом и телом уже есть ответ,
+// он возвращается с replay = true, и запрос выполнять не нужно.
+func (s *IdempotencyService) Begin(ctx context.Context, key, requestHash string) (rec models.IdempotencyRecord, replay bool, err error) {
+	if key == "" || len(key) > maxIdempotencyKeyLen {
+		return models.IdempotencyRecord{}, false, ErrInvalidIdempotencyKey
+	}
+	rec = models.IdempotencyRecord{
+		Key:         key,
+		Owner:       IdempotencyOwner(ctx),
+		RequestHash: requestHash,
+		ExpiresAt:   time.Now().Add(s.TTL),
+	}
+
+	existing, reserved, err := s.Repo.ReserveIdempotencyKey(ctx, rec)
+	switch {
+	case err != nil:
+		return models.IdempotencyRecord{}, false, err
+	case reserved:
+		return rec, false, nil
+	case existing.RequestHash != requestHash:
+		return models.IdempotencyRecord{}, false, ErrIdempotencyMismatch
+	case !existing.Completed():
+		return models.IdempotencyRecord{}, false, ErrIdempotencyInProgress
+	}
+	return existing, true, nil
+}
+
+// Complete сохраняет ответ на запрос. Ответы 5xx не сохраняются: ключ освобождается, и клиент может повторить запрос.
+func (s *IdempotencyService) Complete(ctx context.Context, rec models.IdempotencyRecord, status int, contentType string, body []byte) error {
+	if status >= 500 {
+		return s.Release(ctx, rec)
+	}
+	rec.StatusCode, rec.ContentType, rec.Body = status, contentType, body
+	return s.Repo.CompleteIdempotencyKey(ctx, rec)
+}
+
+// Release освобождает ключ без сохранения ответа, например если обработчик запроса завершился паникой
+func (s *IdempotencyService) Release(ctx context.Context, rec models.IdempotencyRecord) error {
+	return s.Repo.ReleaseIdempotencyKey(ctx, rec.Key, rec.Owner)
+}
+
+// RunCleanup удаляет истёкшие записи сразу и затем каждые interval, пока не отменён ctx
+func (s *IdempotencyService) RunCleanup(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if deleted, err := s.Repo.DeleteExpiredIdempotencyKeys(ctx, time.Now()); err != nil {
+			logger.L().Error("Ошибка очистки ключей идемпотентности", zap.Error(err))
+		} else if deleted > 0 {
+			logger.L().Debug("Истёкшие ключи идемпотентности удалены", zap.Int64("count", deleted))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
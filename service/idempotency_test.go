@@ -0,0 +1,126 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+	"usersubs/repository"
+)
+
+func TestHashRequest(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+		same bool
+	}{
+		{"field order", `{"a":1,"b":"x"}`, `{"b":"x","a":1}`, true},
+		{"whitespace", `{"a": 1}`, "\n{\"a\":1}\n", true},
+		{"different value", `{"a":1}`, `{"a":2}`, false},
+		{"not JSON", `a=1`, `a=1`, true},
+		{"not JSON differs", `a=1`, `a=2`, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if same := HashRequest([]byte(tt.a)) == HashRequest([]byte(tt.b)); same != tt.same {
+				t.Errorf("HashRequest(%q) == HashRequest(%q) is %v, want %v", tt.a, tt.b, same, tt.same)
+			}
+		})
+	}
+}
+
+func TestIdempotency(t *testing.T) {
+	const body = `{"service_name":"Okko"}`
+	hash, otherHash := HashRequest([]byte(body)), HashRequest([]byte(`{"service_name":"Ivi"}`))
+
+	tests := []struct {
+		name string
+		// prepare выполняет первый запрос с ключом "key" и телом body
+		prepare    func(ctx context.Context, s *IdempotencyService) error
+		key, hash  string
+		wantErr    error
+		wantReplay bool
+	}{
+		{
+			name:    "first request",
+			prepare: func(context.Context, *IdempotencyService) error { return nil },
+			key:     "key", hash: hash,
+		},
+		{
+			name:    "replay after completion",
+			prepare: completed(hash, http.StatusCreated),
+			key:     "key", hash: hash, wantReplay: true,
+		},
+		{
+			name:    "same key, other body",
+			prepare: completed(hash, http.StatusCreated),
+			key:     "key", hash: otherHash, wantErr: ErrIdempotencyMismatch,
+		},
+		{
+			name:    "other key",
+			prepare: completed(hash, http.StatusCreated),
+			key:     "other", hash: otherHash,
+		},
+		{
+			name: "first request still running",
+			prepare: func(ctx context.Context, s *IdempotencyService) error {
+				_, _, err := s.Begin(ctx, "key", hash)
+				return err
+			},
+			key: "key", hash: hash, wantErr: ErrIdempotencyInProgress,
+		},
+		{
+			name:    "server error releases the key",
+			prepare: completed(hash, http.StatusInternalServerError),
+			key:     "key", hash: hash,
+		},
+		{
+			name: "released after a panic",
+			prepare: func(ctx context.Context, s *IdempotencyService) error {
+				rec, _, err := s.Begin(ctx, "key", hash)
+				if err != nil {
+					return err
+				}
+				return s.Release(ctx, rec)
+			},
+			key: "key", hash: otherHash,
+		},
+		{
+			name:    "empty key",
+			prepare: func(context.Context, *IdempotencyService) error { return nil },
+			key:     "", hash: hash, wantErr: ErrInvalidIdempotencyKey,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			s := NewIdempotencyService(repository.NewMemoryRepository(), time.Hour)
+			if err := tt.prepare(ctx, s); err != nil {
+				t.Fatalf("prepare: %v", err)
+			}
+
+			rec, replay, err := s.Begin(ctx, tt.key, tt.hash)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Begin error = %v, want %v", err, tt.wantErr)
+			}
+			if replay != tt.wantReplay {
+				t.Fatalf("replay = %v, want %v", replay, tt.wantReplay)
+			}
+			if replay && (rec.StatusCode != http.StatusCreated || string(rec.Body) != body || rec.ContentType != "application/json") {
+				t.Errorf("replayed response = %d %q %q", rec.StatusCode, rec.ContentType, rec.Body)
+			}
+		})
+	}
+}
+
+// completed выполняет первый запрос с ключом "key" и сохраняет ответ со статусом status
+func completed(hash string, status int) func(ctx context.Context, s *IdempotencyService) error {
+	return func(ctx context.Context, s *IdempotencyService) error {
+		rec, _, err := s.Begin(ctx, "key", hash)
+		if err != nil {
+			return err
+		}
+		return s.Complete(ctx, rec, status, "application/json", []byte(`{"service_name":"Okko"}`))
+	}
+}
@@ -0,0 +1,244 @@
+package service
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"usersubs/models"
+	"usersubs/repository"
+	"usersubs/utils"
+	"usersubs/validation"
+)
+
+// Наибольшая длина строки файла JSON Lines
+const maxImportLineSize = 1 << 20
+
+var (
+	// Файл импорта нельзя обработать: неизвестный формат, нет нужных колонок, ошибка чтения
+	ErrInvalidImportFile = errors.New("некорректный файл импорта")
+	// Строку файла импорта не удалось разобрать
+	ErrInvalidImportRow = errors.New("некорректная строка импорта")
+)
+
+// Обязательные колонки CSV; user_id необязателен для ключа пользователя
+var requiredImportColumns = []string{"service_name", "price", "start_date"}
+
+// importKey - уникальный ключ подписки (user_id, service_name, start_date)
+type importKey struct {
+	userID, serviceName, startDate string
+}
+
+// Import читает файл подписок построчно и создаёт подписки; с opts.DryRun только проверяет строки.
+// Итог каждой строки передаётся в report вместе с ошибкой строки (для ImportFailed):
+// если report вернёт ошибку, импорт прекращается.
+// Ошибка Import - файл нельзя обработать дальше; строки до неё уже сохранены и переданы в report.
+func (s *SubscriptionsService) Import(ctx context.Context, r io.Reader, opts models.ImportOptions, report func(row models.ImportRowResult, err error) error) (models.ImportResult, error) {
+	result := models.ImportResult{DryRun: opts.DryRun}
+	// При dry_run подписки не сохраняются, поэтому совпадения строк файла между собой запоминаются отдельно
+	var seen map[importKey]bool
+	if opts.DryRun {
+		seen = make(map[importKey]bool)
+	}
+
+	err := readImportRows(opts.Format, r, func(line int, input models.SubscriptionInput, err error) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		result.Rows++
+		row := models.ImportRowResult{Line: line}
+		if err == nil {
+			row.Status, row.SubscriptionID, err = s.importRow(ctx, input, opts, seen)
+		}
+		switch {
+		case err != nil:
+			row.Status = models.ImportFailed
+			result.Failed++
+		case row.Status == models.ImportCreated:
+			result.Created++
+		case row.Status == models.ImportUpdated:
+			result.Updated++
+		case row.Status == models.ImportSkipped:
+			result.Skipped++
+		}
+		return report(row, err)
+	})
+	return result, err
+}
+
+// importRow проверяет и сохраняет строку импорта; возвращает итог ImportCreated, ImportUpdated или ImportSkipped
+// и ID созданной или заменённой подписки (0 при dry_run и пропуске)
+func (s *SubscriptionsService) importRow(ctx context.Context, input models.SubscriptionInput, opts models.ImportOptions, seen map[importKey]bool) (string, int, error) {
+	userID, err := ScopeUserID(ctx, input.UserID)
+	if err != nil {
+		return "", 0, err
+	}
+	input.UserID = userID
+	sub, err := validation.Subscription(input)
+	if err != nil {
+		return "", 0, err
+	}
+
+	existing, found, err := s.findByKey(ctx, sub)
+	if err != nil {
+		return "", 0, err
+	}
+	if opts.DryRun {
+		key := importKey{sub.UserID, sub.ServiceName, sub.StartDate.Format("2006-01-02")}
+		found = found || seen[key]
+		seen[key] = true
+	}
+
+	if !found {
+		if opts.DryRun {
+			return models.ImportCreated, 0, nil
+		}
+		id, err := s.CreateSubscription(ctx, sub)
+		if err != nil {
+			return "", 0, err
+		}
+		return models.ImportCreated, id, nil
+	}
+	switch opts.OnConflict {
+	case models.ConflictOverwrite:
+		if opts.DryRun {
+			return models.ImportUpdated, 0, nil
+		}
+		if _, err := s.UpdateSubscription(ctx, models.FullUpdate(sub), existing.ID, nil); err != nil {
+			return "", 0, err
+		}
+		return models.ImportUpdated, existing.ID, nil
+	case models.ConflictFail:
+		return "", 0, repository.ErrDuplicate
+	}
+	return models.ImportSkipped, 0, nil
+}
+
+// findByKey ищет неудалённую подписку с теми же user_id, service_name и start_date
+func (s *SubscriptionsService) findByKey(ctx context.Context, sub models.Subscription) (models.Subscription, bool, error) {
+	subs, err := s.Repo.List(ctx, models.SubscriptionFilter{UserID: sub.UserID, ServiceName: sub.ServiceName})
+	if err != nil {
+		return models.Subscription{}, false, err
+	}
+	for _, existing := range subs {
+		if existing.StartDate.Equal(sub.StartDate) {
+			return existing, true, nil
+		}
+	}
+	return models.Subscription{}, false, nil
+}
+
+// readImportRows читает файл по одной строке и передаёт её в fn вместе с ошибкой разбора строки.
+// Ошибка fn прекращает чтение.
+func readImportRows(format string, r io.Reader, fn func(line int, input models.SubscriptionInput, err error) error) error {
+	switch format {
+	case models.ImportFormatCSV:
+		return readImportCSV(r, fn)
+	case models.ImportFormatJSONL:
+		return readImportJSONL(r, fn)
+	}
+	return utils.Errorf(ErrInvalidImportFile, utils.MsgImportUnknownFormat, format)
+}
+
+// readImportCSV читает CSV с заголовком; имена колонок совпадают с полями SubscriptionInput
+func readImportCSV(r io.Reader, fn func(line int, input models.SubscriptionInput, err error) error) error {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return utils.Errorf(ErrInvalidImportFile, utils.MsgImportHeader, fileErrorMessage(err))
+	}
+	columns := make(map[string]int)
+	for i, name := range header {
+		// Excel сохраняет CSV в UTF-8 с BOM
+		columns[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))] = i
+	}
+	for _, name := range requiredImportColumns {
+		if _, ok := columns[name]; !ok {
+			return utils.Errorf(ErrInvalidImportFile, utils.MsgImportMissingColumn, name)
+		}
+	}
+
+	line := 1
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return nil
+		}
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			line = parseErr.Line
+			if err := fn(parseErr.StartLine, models.SubscriptionInput{}, utils.Errorf(ErrInvalidImportRow, utils.MsgImportRowSyntax, fileErrorMessage(parseErr))); err != nil {
+				return err
+			}
+			continue
+		}
+		if err != nil {
+			return utils.Errorf(ErrInvalidImportFile, utils.MsgImportRead, line+1, fileErrorMessage(err))
+		}
+		line, _ = reader.FieldPos(0)
+
+		value := func(name string) string {
+			i, ok := columns[name]
+			if !ok || i >= len(record) {
+				return ""
+			}
+			return strings.TrimSpace(record[i])
+		}
+		input := models.SubscriptionInput{
+			ServiceName:   value("service_name"),
+			Currency:      value("currency"),
+			BillingPeriod: value("billing_period"),
+			UserID:        value("user_id"),
+			StartDate:     value("start_date"),
+		}
+		if endDate := value("end_date"); endDate != "" {
+			input.EndDate = &endDate
+		}
+		var errs validation.Errors
+		if price := value("price"); price == "" {
+			errs.Add("price", utils.MsgRequiredField)
+		} else if input.Price, err = models.ParseMoney(price); err != nil {
+			errs.Add("price", utils.MsgInvalidMoney, "price")
+		}
+		if err := fn(line, input, errs.Err()); err != nil {
+			return err
+		}
+	}
+}
+
+// readImportJSONL читает JSON Lines: по одному объекту SubscriptionInput в строке, пустые строки пропускаются
+func readImportJSONL(r io.Reader, fn func(line int, input models.SubscriptionInput, err error) error) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64<<10), maxImportLineSize)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := bytes.TrimSpace(scanner.Bytes())
+		if len(text) == 0 {
+			continue
+		}
+		var input models.SubscriptionInput
+		var rowErr error
+		if err := json.Unmarshal(text, &input); errors.Is(err, models.ErrInvalidMoney) {
+			var errs validation.Errors
+			errs.Add("price", utils.MsgInvalidMoney, "price")
+			rowErr = errs
+		} else if err != nil {
+			rowErr = utils.Errorf(ErrInvalidImportRow, utils.MsgImportRowSyntax, fileErrorMessage(err))
+		}
+		if err := fn(line, input, rowErr); err != nil {
+			return err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return utils.Errorf(ErrInvalidImportFile, utils.MsgImportRead, line+1, fileErrorMessage(err))
+	}
+	return nil
+}
@@ -0,0 +1,134 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+	"usersubs/models"
+	"usersubs/repository"
+	"usersubs/validation"
+)
+
+func TestImportDryRun(t *testing.T) {
+	const user = testUserID
+	longLine := strings.Repeat("a", maxImportLineSize+1)
+
+	tests := []struct {
+		name       string
+		format     string
+		onConflict string
+		file       string
+		existing   []models.Subscription
+		want       []string // Итоги строк: "строка:статус[:поле с ошибкой]"
+		wantErr    bool     // Импорт прерван ошибкой файла
+	}{
+		{
+			name:   "csv rows",
+			format: models.ImportFormatCSV,
+			file: "service_name,price,user_id,start_date\n" +
+				"Okko,299.90," + user + ",2026-01-01\n" +
+				"Ivi,abc," + user + ",2026-01-01\n" +
+				"Kion,199," + user + ",31-01-2026\n" +
+				"Wink,\"1\n",
+			want: []string{"2:created", "3:failed:price", "4:failed:start_date", "5:failed"},
+		},
+		{
+			name:   "csv with BOM and extra columns",
+			format: models.ImportFormatCSV,
+			file:   "\ufeffuser_id, start_date ,comment,price,service_name,billing_period\n" + user + ",01-2026,note,99,Okko,yearly\n",
+			want:   []string{"2:created"},
+		},
+		{
+			name:    "csv without a required column",
+			format:  models.ImportFormatCSV,
+			file:    "service_name,user_id,start_date\nOkko," + user + ",2026-01-01\n",
+			wantErr: true,
+		},
+		{
+			name:   "jsonl rows",
+			format: models.ImportFormatJSONL,
+			file: `{"service_name":"Okko","price":"299.90","user_id":"` + user + `","start_date":"2026-01-01"}` + "\n\n" +
+				`{"service_name":` + "\n" +
+				`{"service_name":"Ivi","price":"1.234","user_id":"` + user + `","start_date":"2026-01-01"}` + "\n",
+			want: []string{"1:created", "3:failed", "4:failed:price"},
+		},
+		{
+			name:       "duplicate rows in the file are skipped",
+			format:     models.ImportFormatCSV,
+			onConflict: models.ConflictSkip,
+			file:       "service_name,price,user_id,start_date\nOkko,1," + user + ",2026-01-01\nOkko,2," + user + ",2026-01-01\n",
+			want:       []string{"2:created", "3:skipped"},
+		},
+		{
+			name:       "duplicate rows fail",
+			format:     models.ImportFormatCSV,
+			onConflict: models.ConflictFail,
+			file:       "service_name,price,user_id,start_date\nOkko,1," + user + ",2026-01-01\nOkko,2," + user + ",2026-01-01\n",
+			want:       []string{"2:created", "3:failed"},
+		},
+		{
+			name:       "existing subscription is overwritten",
+			format:     models.ImportFormatCSV,
+			onConflict: models.ConflictOverwrite,
+			existing:   []models.Subscription{{ServiceName: "Okko", Price: 100, UserID: user, StartDate: date("2026-01-01")}},
+			file:       "service_name,price,user_id,start_date\nOkko,1," + user + ",2026-01-01\nOkko,1," + user + ",2026-02-01\n",
+			want:       []string{"2:updated", "3:created"},
+		},
+		{
+			name:    "line too long aborts after the processed rows",
+			format:  models.ImportFormatJSONL,
+			file:    `{"service_name":"Okko","price":"1","user_id":"` + user + `","start_date":"2026-01-01"}` + "\n" + longLine + "\n",
+			want:    []string{"1:created"},
+			wantErr: true,
+		},
+		{
+			name:    "unknown format",
+			format:  "xml",
+			file:    "<subscriptions/>",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			repo := repository.NewMemoryRepository()
+			for _, sub := range tt.existing {
+				if _, err := repo.Create(ctx, sub); err != nil {
+					t.Fatal(err)
+				}
+			}
+			s := NewSubscriptionsService(repo, repo, repo)
+			onConflict := tt.onConflict
+			if onConflict == "" {
+				onConflict = models.ConflictSkip
+			}
+			opts := models.ImportOptions{Format: tt.format, DryRun: true, OnConflict: onConflict}
+
+			var got []string
+			result, err := s.Import(ctx, strings.NewReader(tt.file), opts, func(row models.ImportRowResult, err error) error {
+				outcome := fmt.Sprintf("%d:%s", row.Line, row.Status)
+				var invalid validation.Errors
+				if errors.As(err, &invalid) {
+					outcome += ":" + invalid[0].Field
+				}
+				got = append(got, outcome)
+				return nil
+			})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Import error = %v, want error %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrInvalidImportFile) {
+				t.Errorf("Import error = %v, want ErrInvalidImportFile", err)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("rows = %v, want %v", got, tt.want)
+			}
+			if result.Rows != len(tt.want) || result.Created+result.Updated+result.Skipped+result.Failed != result.Rows {
+				t.Errorf("result = %+v, want %d rows", result, len(tt.want))
+			}
+		})
+	}
+}
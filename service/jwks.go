@@ -0,0 +1,170 @@
+package service
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"sync"
+	"time"
+	"usersubs/logger"
+
+	"go.uber.org/zap"
+)
+
+// Как часто проверять, не изменился ли файл JWKS
+const jwksCheckInterval = time.Second
+
+// jsonWebKey - ключ из JWKS (RFC 7517); поддерживаются RSA и EC P-256
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// JWKSFile - открытые ключи из локального файла JWKS.
+// Файл перечитывается при изменении; если новая версия некорректна, используются прежние ключи.
+type JWKSFile struct {
+	path string
+
+	mu        sync.Mutex
+	keys      map[string]crypto.PublicKey // kid -> ключ
+	modTime   time.Time
+	size      int64
+	checkedAt time.Time
+}
+
+// LoadJWKSFile читает файл JWKS; ошибка - если файл не читается или не содержит ключей
+func LoadJWKSFile(path string) (*JWKSFile, error) {
+	f := &JWKSFile{path: path}
+	if err := f.reload(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// Key возвращает ключ по kid; без kid - единственный ключ файла
+func (f *JWKSFile) Key(kid string) (crypto.PublicKey, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if time.Since(f.checkedAt) >= jwksCheckInterval {
+		f.checkedAt = time.Now()
+		if info, err := os.Stat(f.path); err == nil && (!info.ModTime().Equal(f.modTime) || info.Size() != f.size) {
+			if err := f.reload(); err != nil {
+				logger.L().Error("Не удалось перечитать JWKS, используются прежние ключи", zap.String("path", f.path), zap.Error(err))
+			} else {
+				logger.L().Info("JWKS перечитан", zap.String("path", f.path), zap.Int("keys", len(f.keys)))
+			}
+		}
+	}
+
+	if kid == "" && len(f.keys) == 1 {
+		for _, key := range f.keys {
+			return key, nil
+		}
+	}
+	key, ok := f.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("%w: неизвестный kid %q", ErrUnauthorized, kid)
+	}
+	return key, nil
+}
+
+// reload читает файл и заменяет ключи; вызывается под f.mu (или до публикации f)
+func (f *JWKSFile) reload() error {
+	info, err := os.Stat(f.path)
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(f.path)
+	if err != nil {
+		return err
+	}
+	keys, err := parseJWKS(data)
+	if err != nil {
+		return err
+	}
+	f.keys, f.modTime, f.size = keys, info.ModTime(), info.Size()
+	return nil
+}
+
+func parseJWKS(data []byte) (map[string]crypto.PublicKey, error) {
+	var set struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("некорректный JWKS: %w", err)
+	}
+
+	keys := make(map[string]crypto.PublicKey, len(set.Keys))
+	for _, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		key, err := jwk.publicKey()
+		if err != nil {
+			return nil, fmt.Errorf("ключ %q: %w", jwk.Kid, err)
+		}
+		keys[jwk.Kid] = key
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("в JWKS нет ключей подписи")
+	}
+	return keys, nil
+}
+
+func (k jsonWebKey) publicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+		if !e.IsInt64() || e.Int64() > 1<<31-1 {
+			return nil, errors.New("некорректная экспонента RSA")
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		if k.Crv != "P-256" {
+			return nil, fmt.Errorf("кривая %q не поддерживается", k.Crv)
+		}
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		if !elliptic.P256().IsOnCurve(x, y) {
+			return nil, errors.New("точка не лежит на кривой P-256")
+		}
+		return &ecdsa.PublicKey{Curve: elliptic.P256(), X: x, Y: y}, nil
+	}
+	return nil, fmt.Errorf("тип ключа %q не поддерживается", k.Kty)
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil || len(b) == 0 {
+		return nil, errors.New("некорректное значение base64url")
+	}
+	return new(big.Int).SetBytes(b), nil
+}
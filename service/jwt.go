@@ -0,0 +1,211 @@
+package service
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+	"usersubs/models"
+	"usersubs/utils"
+)
+
+// Допустимое расхождение часов при проверке exp и nbf
+const jwtLeeway = time.Minute
+
+// TokenConfig - параметры проверки JWT
+type TokenConfig struct {
+	JWKS      *JWKSFile // Ключи RS256/ES256; nil - не используются
+	HMACKey   []byte    // Секрет HS256; пусто - не используется
+	Issuer    string    // Ожидаемый iss; пусто - не проверяется
+	Audience  string    // Ожидаемый aud; пусто - не проверяется
+	UserClaim string    // Claim с user_id, по умолчанию sub
+}
+
+// TokenVerifier проверяет JWT из заголовка Authorization: Bearer
+type TokenVerifier struct {
+	cfg TokenConfig
+}
+
+func NewTokenVerifier(cfg TokenConfig) *TokenVerifier {
+	if cfg.UserClaim == "" {
+		cfg.UserClaim = "sub"
+	}
+	return &TokenVerifier{cfg: cfg}
+}
+
+// Verify проверяет подпись и срок действия токена и возвращает клиента.
+// Токен со scope admin получает роль администратора, иначе claim UserClaim должен содержать UUID пользователя.
+func (v *TokenVerifier) Verify(ctx context.Context, token string) (models.Principal, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return models.Principal{}, fmt.Errorf("%w: токен должен состоять из трёх частей", ErrUnauthorized)
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return models.Principal{}, err
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return models.Principal{}, fmt.Errorf("%w: некорректная подпись", ErrUnauthorized)
+	}
+	if err := v.verifySignature(header.Alg, header.Kid, parts[0]+"."+parts[1], signature); err != nil {
+		return models.Principal{}, err
+	}
+
+	var claims map[string]any
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return models.Principal{}, err
+	}
+	if err := v.validateClaims(claims, time.Now()); err != nil {
+		return models.Principal{}, err
+	}
+
+	p := models.Principal{Role: models.RoleUser, Scopes: tokenScopes(claims)}
+	if sub, _ := claims["sub"].(string); sub != "" {
+		p.Subject = "jwt:" + sub
+	} else {
+		p.Subject = "jwt"
+	}
+	if p.HasScope(models.ScopeAdmin) {
+		p.Role = models.RoleAdmin
+		return p, nil
+	}
+	userID, _ := claims[v.cfg.UserClaim].(string)
+	if !utils.IsValidUUID(userID) {
+		return models.Principal{}, fmt.Errorf("%w: claim %s должен содержать UUID пользователя", ErrUnauthorized, v.cfg.UserClaim)
+	}
+	p.UserID = strings.ToLower(userID)
+	return p, nil
+}
+
+func (v *TokenVerifier) verifySignature(alg, kid, signed string, signature []byte) error {
+	digest := sha256.Sum256([]byte(signed))
+
+	switch alg {
+	case "HS256":
+		if len(v.cfg.HMACKey) == 0 {
+			break
+		}
+		mac := hmac.New(sha256.New, v.cfg.HMACKey)
+		mac.Write([]byte(signed))
+		if !hmac.Equal(mac.Sum(nil), signature) {
+			return fmt.Errorf("%w: неверная подпись", ErrUnauthorized)
+		}
+		return nil
+	case "RS256", "ES256":
+		if v.cfg.JWKS == nil {
+			break
+		}
+		key, err := v.cfg.JWKS.Key(kid)
+		if err != nil {
+			return err
+		}
+		if !verifyAsymmetric(alg, key, digest[:], signature) {
+			return fmt.Errorf("%w: неверная подпись", ErrUnauthorized)
+		}
+		return nil
+	}
+	return fmt.Errorf("%w: алгоритм %q не поддерживается", ErrUnauthorized, alg)
+}
+
+// verifyAsymmetric проверяет подпись RS256 или ES256; тип ключа должен соответствовать алгоритму
+func verifyAsymmetric(alg string, key crypto.PublicKey, digest, signature []byte) bool {
+	switch key := key.(type) {
+	case *rsa.PublicKey:
+		return alg == "RS256" && rsa.VerifyPKCS1v15(key, crypto.SHA256, digest, signature) == nil
+	case *ecdsa.PublicKey:
+		// Подпись JWS ES256 - r и s по 32 байта подряд (RFC 7518, 3.4)
+		if alg != "ES256" || len(signature) != 64 {
+			return false
+		}
+		r := new(big.Int).SetBytes(signature[:32])
+		s := new(big.Int).SetBytes(signature[32:])
+		return ecdsa.Verify(key, digest, r, s)
+	}
+	return false
+}
+
+func (v *TokenVerifier) validateClaims(claims map[string]any, now time.Time) error {
+	exp, ok := numericClaim(claims, "exp")
+	if !ok {
+		return fmt.Errorf("%w: в токене нет exp", ErrUnauthorized)
+	}
+	if now.After(exp.Add(jwtLeeway)) {
+		return fmt.Errorf("%w: срок действия токена истёк", ErrUnauthorized)
+	}
+	if nbf, ok := numericClaim(claims, "nbf"); ok && now.Add(jwtLeeway).Before(nbf) {
+		return fmt.Errorf("%w: токен ещё не действует", ErrUnauthorized)
+	}
+	if v.cfg.Issuer != "" {
+		if iss, _ := claims["iss"].(string); iss != v.cfg.Issuer {
+			return fmt.Errorf("%w: неверный iss", ErrUnauthorized)
+		}
+	}
+	if v.cfg.Audience != "" && !hasAudience(claims["aud"], v.cfg.Audience) {
+		return fmt.Errorf("%w: неверный aud", ErrUnauthorized)
+	}
+	return nil
+}
+
+// numericClaim возвращает claim-время в секундах Unix
+func numericClaim(claims map[string]any, name string) (time.Time, bool) {
+	n, ok := claims[name].(float64)
+	if !ok {
+		return time.Time{}, false
+	}
+	return time.Unix(int64(n), 0), true
+}
+
+// hasAudience проверяет aud: строку или массив строк
+func hasAudience(aud any, want string) bool {
+	switch aud := aud.(type) {
+	case string:
+		return aud == want
+	case []any:
+		for _, a := range aud {
+			if a == want {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// tokenScopes возвращает области доступа из claim scope (строка через пробел) или scp (массив)
+func tokenScopes(claims map[string]any) []string {
+	scopes := []string{}
+	if scope, ok := claims["scope"].(string); ok {
+		scopes = append(scopes, strings.Fields(scope)...)
+	}
+	if scp, ok := claims["scp"].([]any); ok {
+		for _, s := range scp {
+			if s, ok := s.(string); ok {
+				scopes = append(scopes, s)
+			}
+		}
+	}
+	return scopes
+}
+
+func decodeSegment(segment string, v any) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return fmt.Errorf("%w: некорректная кодировка токена", ErrUnauthorized)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("%w: некорректный JSON в токене", ErrUnauthorized)
+	}
+	return nil
+}
@@ -0,0 +1,129 @@
+package service
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+	"usersubs/models"
+)
+
+const testUserID = "11111111-1111-1111-1111-111111111111"
+
+// signToken собирает JWT с заголовком header и claims; sign подписывает "header.payload"
+func signToken(t *testing.T, header, claims map[string]any, sign func(signed []byte) []byte) string {
+	t.Helper()
+	segment := func(v any) string {
+		data, err := json.Marshal(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return base64.RawURLEncoding.EncodeToString(data)
+	}
+	signed := segment(header) + "." + segment(claims)
+	return signed + "." + base64.RawURLEncoding.EncodeToString(sign([]byte(signed)))
+}
+
+func hs256(key []byte) func([]byte) []byte {
+	return func(signed []byte) []byte {
+		mac := hmac.New(sha256.New, key)
+		mac.Write(signed)
+		return mac.Sum(nil)
+	}
+}
+
+func es256(t *testing.T, key *ecdsa.PrivateKey) func([]byte) []byte {
+	return func(signed []byte) []byte {
+		digest := sha256.Sum256(signed)
+		r, s, err := ecdsa.Sign(rand.Reader, key, digest[:])
+		if err != nil {
+			t.Fatal(err)
+		}
+		sig := make([]byte, 64)
+		r.FillBytes(sig[:32])
+		s.FillBytes(sig[32:])
+		return sig
+	}
+}
+
+func TestVerifyToken(t *testing.T) {
+	secret := []byte("test-secret")
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	jwks := &JWKSFile{keys: map[string]crypto.PublicKey{"ec": &ecKey.PublicKey}, checkedAt: time.Now()}
+	verifier := NewTokenVerifier(TokenConfig{JWKS: jwks, HMACKey: secret, Issuer: "issuer", Audience: "usersubs"})
+
+	now := time.Now().Unix()
+	claims := func(changes map[string]any) map[string]any {
+		c := map[string]any{"sub": testUserID, "exp": now + 3600, "iss": "issuer", "aud": "usersubs"}
+		for name, v := range changes {
+			if v == nil {
+				delete(c, name)
+			} else {
+				c[name] = v
+			}
+		}
+		return c
+	}
+	hs := map[string]any{"alg": "HS256", "typ": "JWT"}
+
+	tests := []struct {
+		name      string
+		token     string
+		wantErr   bool
+		wantRole  string
+		wantScope string
+	}{
+		{name: "HS256", token: signToken(t, hs, claims(nil), hs256(secret)), wantRole: models.RoleUser},
+		{name: "ES256", token: signToken(t, map[string]any{"alg": "ES256", "kid": "ec"}, claims(nil), es256(t, ecKey)), wantRole: models.RoleUser},
+		{name: "admin scope", token: signToken(t, hs, claims(map[string]any{"sub": "ops", "scope": "admin"}), hs256(secret)), wantRole: models.RoleAdmin, wantScope: models.ScopeAdmin},
+		{name: "audience list", token: signToken(t, hs, claims(map[string]any{"aud": []string{"other", "usersubs"}}), hs256(secret)), wantRole: models.RoleUser},
+		{name: "expired within leeway", token: signToken(t, hs, claims(map[string]any{"exp": now - 30}), hs256(secret)), wantRole: models.RoleUser},
+		{name: "alg none", token: signToken(t, map[string]any{"alg": "none"}, claims(nil), func([]byte) []byte { return nil }), wantErr: true},
+		{name: "unknown alg", token: signToken(t, map[string]any{"alg": "HS512"}, claims(nil), hs256(secret)), wantErr: true},
+		{name: "wrong HMAC key", token: signToken(t, hs, claims(nil), hs256([]byte("other"))), wantErr: true},
+		{name: "HMAC signature with RS256 header", token: signToken(t, map[string]any{"alg": "RS256", "kid": "ec"}, claims(nil), hs256(secret)), wantErr: true},
+		{name: "EC key with RS256 header", token: signToken(t, map[string]any{"alg": "RS256", "kid": "ec"}, claims(nil), es256(t, ecKey)), wantErr: true},
+		{name: "unknown kid", token: signToken(t, map[string]any{"alg": "ES256", "kid": "missing"}, claims(nil), es256(t, ecKey)), wantErr: true},
+		{name: "expired", token: signToken(t, hs, claims(map[string]any{"exp": now - 120}), hs256(secret)), wantErr: true},
+		{name: "no exp", token: signToken(t, hs, claims(map[string]any{"exp": nil}), hs256(secret)), wantErr: true},
+		{name: "not yet valid", token: signToken(t, hs, claims(map[string]any{"nbf": now + 120}), hs256(secret)), wantErr: true},
+		{name: "wrong issuer", token: signToken(t, hs, claims(map[string]any{"iss": "other"}), hs256(secret)), wantErr: true},
+		{name: "wrong audience", token: signToken(t, hs, claims(map[string]any{"aud": "other"}), hs256(secret)), wantErr: true},
+		{name: "sub is not a UUID", token: signToken(t, hs, claims(map[string]any{"sub": "user"}), hs256(secret)), wantErr: true},
+		{name: "two parts", token: "a.b", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := verifier.Verify(context.Background(), tt.token)
+			if tt.wantErr {
+				if !errors.Is(err, ErrUnauthorized) {
+					t.Fatalf("Verify error = %v, want ErrUnauthorized", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Verify: %v", err)
+			}
+			if p.Role != tt.wantRole {
+				t.Errorf("Role = %q, want %q", p.Role, tt.wantRole)
+			}
+			if tt.wantRole == models.RoleUser && p.UserID != testUserID {
+				t.Errorf("UserID = %q, want %q", p.UserID, testUserID)
+			}
+			if tt.wantScope != "" && !p.HasScope(tt.wantScope) {
+				t.Errorf("Scopes = %v, want %s", p.Scopes, tt.wantScope)
+			}
+		})
+	}
+}
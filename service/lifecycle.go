@@ -0,0 +1,94 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+	"usersubs/models"
+	"usersubs/utils"
+)
+
+var (
+	// Действие недопустимо в текущем состоянии подписки
+	ErrInvalidTransition = errors.New("недопустимый переход состояния подписки")
+	// Дата действия не согласуется с датами подписки
+	ErrInvalidLifecycleDate = errors.New("некорректная дата действия")
+)
+
+// PauseSubscription приостанавливает активную подписку с даты on (включительно)
+func (s *SubscriptionsService) PauseSubscription(ctx context.Context, id int, on time.Time) (models.Subscription, error) {
+	sub, err := s.update(ctx, id, func(sub *models.Subscription) error {
+		if sub.Status != models.StatusActive {
+			return utils.Errorf(ErrInvalidTransition, utils.MsgPauseNotActive, sub.Status)
+		}
+		if on.Before(sub.StartDate) {
+			return utils.Errorf(ErrInvalidLifecycleDate, utils.MsgPauseBeforeStart)
+		}
+		if sub.EndDate != nil && on.After(*sub.EndDate) {
+			return utils.Errorf(ErrInvalidLifecycleDate, utils.MsgPauseAfterEnd)
+		}
+		if n := len(sub.Pauses); n > 0 && sub.Pauses[n-1].ResumedOn != nil && on.Before(*sub.Pauses[n-1].ResumedOn) {
+			return utils.Errorf(ErrInvalidLifecycleDate, utils.MsgPauseBeforePrevious)
+		}
+
+		sub.Status = models.StatusPaused
+		sub.Pauses = append(sub.Pauses, models.Pause{PausedOn: on})
+		return nil
+	})
+	return withCurrentPrice(sub), err
+}
+
+// ResumeSubscription возобновляет приостановленную подписку с даты on (первый активный день)
+func (s *SubscriptionsService) ResumeSubscription(ctx context.Context, id int, on time.Time) (models.Subscription, error) {
+	sub, err := s.update(ctx, id, func(sub *models.Subscription) error {
+		if sub.Status != models.StatusPaused {
+			return utils.Errorf(ErrInvalidTransition, utils.MsgResumeNotPaused, sub.Status)
+		}
+		open := openPause(sub)
+		if open == nil {
+			return utils.Errorf(ErrInvalidTransition, utils.MsgNoOpenPause)
+		}
+		if !on.After(open.PausedOn) {
+			return utils.Errorf(ErrInvalidLifecycleDate, utils.MsgResumeBeforePause)
+		}
+
+		sub.Status = models.StatusActive
+		open.ResumedOn = &on
+		return nil
+	})
+	return withCurrentPrice(sub), err
+}
+
+// CancelSubscription отменяет подписку: on становится последним днём действия (end_date).
+// Открытая пауза закрывается датой отмены.
+func (s *SubscriptionsService) CancelSubscription(ctx context.Context, id int, on time.Time) (models.Subscription, error) {
+	sub, err := s.update(ctx, id, func(sub *models.Subscription) error {
+		if sub.Status == models.StatusCancelled {
+			return utils.Errorf(ErrInvalidTransition, utils.MsgAlreadyCancelled)
+		}
+		if on.Before(sub.StartDate) {
+			return utils.Errorf(ErrInvalidLifecycleDate, utils.MsgCancelBeforeStart)
+		}
+
+		if open := openPause(sub); open != nil {
+			resumedOn := maxTime(on.AddDate(0, 0, 1), open.PausedOn.AddDate(0, 0, 1))
+			open.ResumedOn = &resumedOn
+		}
+		if sub.EndDate == nil || on.Before(*sub.EndDate) {
+			sub.EndDate = &on
+		}
+		sub.Status = models.StatusCancelled
+		return nil
+	})
+	return withCurrentPrice(sub), err
+}
+
+// openPause возвращает незавершённую паузу подписки или nil
+func openPause(sub *models.Subscription) *models.Pause {
+	for i := range sub.Pauses {
+		if sub.Pauses[i].ResumedOn == nil {
+			return &sub.Pauses[i]
+		}
+	}
+	return nil
+}
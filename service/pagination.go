@@ -0,0 +1,46 @@
+package service
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"usersubs/models"
+)
+
+const (
+	DefaultPageSize = 50
+	MaxPageSize     = 500
+)
+
+var ErrInvalidCursor = errors.New("некорректный курсор")
+
+// encodeCursor формирует непрозрачный курсор по последней записи страницы
+func encodeCursor(sub models.Subscription, filter models.SubscriptionFilter) string {
+	c := models.Cursor{SortBy: filter.SortBy, Desc: filter.SortDesc, ID: sub.ID}
+	switch filter.SortBy {
+	case models.SortByPrice:
+		c.Price = sub.Price
+	case models.SortByStartDate:
+		c.StartDate = sub.StartDate
+	case models.SortByServiceName:
+		c.ServiceName = sub.ServiceName
+	}
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeCursor разбирает курсор и проверяет, что он выдан для той же сортировки
+func decodeCursor(s string, filter models.SubscriptionFilter) (*models.Cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var c models.Cursor
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, ErrInvalidCursor
+	}
+	if c.SortBy != filter.SortBy || c.Desc != filter.SortDesc {
+		return nil, ErrInvalidCursor
+	}
+	return &c, nil
+}
@@ -0,0 +1,55 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"usersubs/models"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	sub := models.Subscription{ID: 42, Price: 29990, StartDate: date("2026-03-15"), ServiceName: "Яндекс Плюс"}
+	tests := []struct {
+		sortBy string
+		desc   bool
+		want   models.Cursor
+	}{
+		{models.SortByID, false, models.Cursor{SortBy: models.SortByID, ID: 42}},
+		{models.SortByPrice, true, models.Cursor{SortBy: models.SortByPrice, Desc: true, ID: 42, Price: 29990}},
+		{models.SortByStartDate, false, models.Cursor{SortBy: models.SortByStartDate, ID: 42, StartDate: date("2026-03-15")}},
+		{models.SortByServiceName, true, models.Cursor{SortBy: models.SortByServiceName, Desc: true, ID: 42, ServiceName: "Яндекс Плюс"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.sortBy, func(t *testing.T) {
+			filter := models.SubscriptionFilter{SortBy: tt.sortBy, SortDesc: tt.desc}
+			got, err := decodeCursor(encodeCursor(sub, filter), filter)
+			if err != nil {
+				t.Fatalf("decodeCursor: %v", err)
+			}
+			if *got != tt.want {
+				t.Errorf("decodeCursor = %+v, want %+v", *got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodeCursorInvalid(t *testing.T) {
+	byPrice := models.SubscriptionFilter{SortBy: models.SortByPrice}
+	valid := encodeCursor(models.Subscription{ID: 1, Price: 100}, byPrice)
+	tests := []struct {
+		name   string
+		cursor string
+		filter models.SubscriptionFilter
+	}{
+		{"not base64", "не курсор", byPrice},
+		{"not json", "bm90IGpzb24", byPrice},
+		{"other sort", valid, models.SubscriptionFilter{SortBy: models.SortByStartDate}},
+		{"other direction", valid, models.SubscriptionFilter{SortBy: models.SortByPrice, SortDesc: true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := decodeCursor(tt.cursor, tt.filter); !errors.Is(err, ErrInvalidCursor) {
+				t.Errorf("decodeCursor error = %v, want ErrInvalidCursor", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,50 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+	"usersubs/models"
+	"usersubs/utils"
+)
+
+var ErrInvalidPriceChange = errors.New("некорректное изменение цены")
+
+// AddPriceChange добавляет (или заменяет) цену, действующую с месяца month.
+// Месяц может быть в будущем - тогда это запланированное изменение.
+func (s *SubscriptionsService) AddPriceChange(ctx context.Context, id int, month time.Time, price models.Money) (models.Subscription, error) {
+	month = monthStart(month)
+	updated, err := s.update(ctx, id, func(sub *models.Subscription) error {
+		if month.Before(monthStart(sub.StartDate)) {
+			return utils.Errorf(ErrInvalidPriceChange, utils.MsgPriceChangeBeforeFrom)
+		}
+		if sub.EndDate != nil && month.After(*sub.EndDate) {
+			return utils.Errorf(ErrInvalidPriceChange, utils.MsgPriceChangeAfterEnd)
+		}
+		sub.SetPriceChange(month, price)
+		sub.Price = sub.PriceAt(today())
+		return nil
+	})
+	return withCurrentPrice(updated), err
+}
+
+// ListPriceHistory возвращает историю цен подписки
+func (s *SubscriptionsService) ListPriceHistory(ctx context.Context, id int) ([]models.PriceChange, error) {
+	sub, err := s.get(ctx, id, false)
+	if err != nil {
+		return nil, err
+	}
+	if sub.PriceHistory == nil {
+		return []models.PriceChange{}, nil
+	}
+	return sub.PriceHistory, nil
+}
+
+// withCurrentPrice подставляет в Price цену, действующую сегодня: запланированные изменения
+// вступают в силу без перезаписи записи
+func withCurrentPrice(sub models.Subscription) models.Subscription {
+	if len(sub.PriceHistory) > 0 {
+		sub.Price = sub.PriceAt(today())
+	}
+	return sub
+}
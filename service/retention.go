@@ -0,0 +1,53 @@
+package service
+
+import (
+	"context"
+	"time"
+	"usersubs/logger"
+	"usersubs/models"
+	"usersubs/repository"
+
+	"go.uber.org/zap"
+)
+
+// Инициатор окончательного удаления в журнале изменений
+const purgeActor = "system:purge"
+
+// RestoreSubscription восстанавливает мягко удалённую подписку
+func (s *SubscriptionsService) RestoreSubscription(ctx context.Context, id int) (models.Subscription, error) {
+	// Восстановить подписку может её владелец, хотя просматривать удалённые записи может только администратор
+	existing, err := s.Repo.Get(ctx, id, true)
+	if err != nil {
+		return models.Subscription{}, err
+	}
+	if !canAccess(ctx, existing) {
+		return models.Subscription{}, repository.ErrNotFound
+	}
+	sub, err := s.Repo.Restore(ctx, id)
+	return withCurrentPrice(sub), err
+}
+
+// PurgeDeleted окончательно удаляет подписки, мягко удалённые больше retention назад
+func (s *SubscriptionsService) PurgeDeleted(ctx context.Context, retention time.Duration) (int64, error) {
+	return s.Repo.Purge(repository.WithActor(ctx, purgeActor), time.Now().Add(-retention))
+}
+
+// RunPurge запускает очистку сразу и затем каждые interval, пока не отменён ctx
+func (s *SubscriptionsService) RunPurge(ctx context.Context, retention, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		purged, err := s.PurgeDeleted(ctx, retention)
+		if err != nil {
+			logger.L().Error("Ошибка очистки удалённых подписок", zap.Error(err))
+		} else if purged > 0 {
+			logger.L().Info("Удалённые подписки очищены", zap.Int64("count", purged))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"usersubs/models"
+	"usersubs/repository"
+)
+
+// ScopeUserID согласует user_id запроса с клиентом: клиенту с ролью user подставляется его user_id,
+// а чужой user_id запрещён. Администратору и внутренним вызовам user_id возвращается как есть.
+func ScopeUserID(ctx context.Context, userID string) (string, error) {
+	p, ok := PrincipalFromContext(ctx)
+	if !ok || p.IsAdmin() {
+		return userID, nil
+	}
+	if userID != "" && !strings.EqualFold(userID, p.UserID) {
+		return "", ErrForbidden
+	}
+	return p.UserID, nil
+}
+
+// requireAdmin разрешает операцию только администратору (и внутренним вызовам)
+func requireAdmin(ctx context.Context) error {
+	if p, ok := PrincipalFromContext(ctx); ok && !p.IsAdmin() {
+		return ErrForbidden
+	}
+	return nil
+}
+
+// canAccess проверяет, что подписка доступна клиенту
+func canAccess(ctx context.Context, sub models.Subscription) bool {
+	p, ok := PrincipalFromContext(ctx)
+	return !ok || p.IsAdmin() || strings.EqualFold(sub.UserID, p.UserID)
+}
+
+// get возвращает подписку, доступную клиенту; чужая подписка неотличима от несуществующей
+func (s *SubscriptionsService) get(ctx context.Context, id int, includeDeleted bool) (models.Subscription, error) {
+	if includeDeleted {
+		if err := requireAdmin(ctx); err != nil {
+			return models.Subscription{}, err
+		}
+	}
+	sub, err := s.Repo.Get(ctx, id, includeDeleted)
+	if err != nil {
+		return models.Subscription{}, err
+	}
+	if !canAccess(ctx, sub) {
+		return models.Subscription{}, repository.ErrNotFound
+	}
+	return sub, nil
+}
+
+// update изменяет подписку, доступную клиенту; передать подписку другому пользователю может только администратор
+func (s *SubscriptionsService) update(ctx context.Context, id int, apply func(sub *models.Subscription) error) (models.Subscription, error) {
+	return s.Repo.Update(ctx, id, func(sub *models.Subscription) error {
+		if !canAccess(ctx, *sub) {
+			return repository.ErrNotFound
+		}
+		if err := apply(sub); err != nil {
+			return err
+		}
+		if !canAccess(ctx, *sub) {
+			return ErrForbidden
+		}
+		return nil
+	})
+}
@@ -1,244 +1,180 @@
 package service
 
 import (
-	"database/sql"
-	"fmt"
-	"log"
-	"time"
+	"context"
+	"errors"
+	"slices"
 	"usersubs/logger"
 	"usersubs/models"
-
-	"go.uber.org/zap"
+	"usersubs/repository"
+	"usersubs/validation"
 )
 
+// Версия записи не совпадает с If-Match: запись изменили после того, как клиент её прочитал
+var ErrPreconditionFailed = errors.New("запись была изменена другим запросом")
+
 type SubscriptionsService struct {
-	DB *sql.DB
+	Repo  repository.SubscriptionRepository
+	Rates repository.ExchangeRateRepository
+	Audit repository.AuditRepository
 }
 
-func NewSubscriptionsService(db *sql.DB) *SubscriptionsService {
-	return &SubscriptionsService{DB: db}
+func NewSubscriptionsService(repo repository.SubscriptionRepository, rates repository.ExchangeRateRepository, audit repository.AuditRepository) *SubscriptionsService {
+	return &SubscriptionsService{Repo: repo, Rates: rates, Audit: audit}
 }
 
-func (s *SubscriptionsService) CreateSubscription(sub models.Subscription) (int, error) {
-	query := `
-		INSERT INTO subscriptions (service_name, price, user_id, start_date, end_date)
-		VALUES ($1, $2, $3, $4, $5)
-		RETURNING id
-	`
-	log.Println("получен запрос на запись")
-	var newID int
-	err := s.DB.QueryRow(query,
-		sub.ServiceName,
-		sub.Price,
-		sub.UserID,
-		sub.StartDate,
-		sub.EndDate,
-	).Scan(&newID)
-
+func (s *SubscriptionsService) CreateSubscription(ctx context.Context, sub models.Subscription) (int, error) {
+	logger.L().Debug("получен запрос на запись")
+	userID, err := ScopeUserID(ctx, sub.UserID)
 	if err != nil {
 		return 0, err
 	}
-
-	return newID, nil
+	sub.UserID = userID
+	sub.Status = models.StatusActive
+	// Начальная цена - первая запись истории цен
+	sub.PriceHistory = []models.PriceChange{{EffectiveMonth: monthStart(sub.StartDate), Price: sub.Price}}
+	return s.Repo.Create(ctx, sub)
 }
 
-func (s *SubscriptionsService) UpdateSubscription(sub models.UpdateSubscription, id int) (models.Subscription, error) {
-
-	//Ищем существующую запись
-	var existing models.Subscription
-	query := `SELECT id, service_name, price, user_id, start_date, end_date FROM subscriptions WHERE id = $1`
-	row := s.DB.QueryRow(query, id)
-	err := row.Scan(&existing.ID, &existing.ServiceName, &existing.Price, &existing.UserID, &existing.StartDate, &existing.EndDate)
-	if err != nil {
-		return models.Subscription{}, err
-	}
-	// Обновляем только указанные в теле, непустые записи
-	if sub.ServiceName != nil && *sub.ServiceName != "" {
-		existing.ServiceName = *sub.ServiceName
-	}
-	if sub.Price != nil && *sub.Price != 0 {
-		existing.Price = *sub.Price
-	}
-	if sub.UserID != nil && *sub.UserID != "" {
-		existing.UserID = *sub.UserID
-	}
-	if sub.StartDate != nil {
-		existing.StartDate = *sub.StartDate
-	}
-	if sub.EndDate != nil {
-		// Даже если *sub.EndDate == nil —> устанавливаем NULL
-		existing.EndDate = *sub.EndDate
-	}
-
-	row = s.DB.QueryRow(`
-		UPDATE subscriptions SET 
-			service_name = $1,
-			price = $2,
-			user_id = $3,
-			start_date = $4,
-			end_date = $5
-		WHERE id = $6
-		RETURNING id, service_name, price, user_id, start_date, end_date
-	`,
-		existing.ServiceName,
-		existing.Price,
-		existing.UserID,
-		existing.StartDate,
-		existing.EndDate,
-		id,
-	)
-
-	var updated models.Subscription
-	err = row.Scan(&updated.ID, &updated.ServiceName, &updated.Price, &updated.UserID, &updated.StartDate, &updated.EndDate)
-	if err != nil {
-		return models.Subscription{}, err
-	}
-	return updated, nil
+// UpdateSubscription изменяет подписку. ifMatch - допустимые версии записи (If-Match); nil - без проверки.
+func (s *SubscriptionsService) UpdateSubscription(ctx context.Context, sub models.UpdateSubscription, id int, ifMatch []int) (models.Subscription, error) {
+	updated, err := s.update(ctx, id, func(existing *models.Subscription) error {
+		if err := checkVersion(*existing, ifMatch); err != nil {
+			return err
+		}
+		// Меняем ровно те поля, что указаны в изменении; nil - поле не трогаем
+		if sub.ServiceName != nil {
+			existing.ServiceName = *sub.ServiceName
+		}
+		if sub.Currency != nil {
+			existing.Currency = *sub.Currency
+		}
+		if sub.BillingPeriod != nil {
+			existing.BillingPeriod = *sub.BillingPeriod
+		}
+		if sub.UserID != nil {
+			existing.UserID = *sub.UserID
+		}
+		if sub.StartDate != nil {
+			existing.StartDate = *sub.StartDate
+		}
+		if sub.EndDate != nil {
+			// Даже если *sub.EndDate == nil —> устанавливаем NULL
+			existing.EndDate = *sub.EndDate
+		}
+		if err := validation.Check(*existing); err != nil {
+			return err
+		}
+		// Новая цена (в том числе нулевая) действует с текущего месяца, прошлые месяцы считаются по прежней цене
+		if sub.Price != nil && *sub.Price != existing.PriceAt(today()) {
+			existing.SetPriceChange(monthStart(maxTime(today(), existing.StartDate)), *sub.Price)
+		}
+		existing.Price = existing.PriceAt(today())
+		return nil
+	})
+	return withCurrentPrice(updated), err
 }
 
-func (s *SubscriptionsService) GetSubscription(id int) (models.Subscription, bool) {
-	var sub models.Subscription
-	var endDate sql.NullTime
-
-	query := `
-		SELECT id, service_name, price, user_id, start_date, end_date
-		FROM subscriptions
-		WHERE id = $1
-	`
-
-	row := s.DB.QueryRow(query, id)
-	err := row.Scan(
-		&sub.ID,
-		&sub.ServiceName,
-		&sub.Price,
-		&sub.UserID,
-		&sub.StartDate,
-		&endDate,
-	)
+// GetSubscription возвращает подписку; мягко удалённая возвращается только с includeDeleted
+func (s *SubscriptionsService) GetSubscription(ctx context.Context, id int, includeDeleted bool) (models.Subscription, error) {
+	sub, err := s.get(ctx, id, includeDeleted)
+	return withCurrentPrice(sub), err
+}
 
+// ListSubscriptions возвращает страницу подписок по фильтру; cursor - значение next_cursor предыдущей страницы
+func (s *SubscriptionsService) ListSubscriptions(ctx context.Context, filter models.SubscriptionFilter, cursor string) (models.SubscriptionPage, error) {
+	userID, err := ScopeUserID(ctx, filter.UserID)
 	if err != nil {
-		//возвращает пустой struct, если нет строки с данным id
-		if err == sql.ErrNoRows {
-			return sub, false
+		return models.SubscriptionPage{}, err
+	}
+	filter.UserID = userID
+	if filter.IncludeDeleted {
+		if err := requireAdmin(ctx); err != nil {
+			return models.SubscriptionPage{}, err
 		}
-		log.Println("GetSubscription error:", err)
-		return sub, false
 	}
-
-	if endDate.Valid {
-		sub.EndDate = &endDate.Time
-	} else {
-		sub.EndDate = nil
+	if filter.SortBy == "" {
+		filter.SortBy = models.SortByID
 	}
-
-	return sub, true
-}
-
-func (s *SubscriptionsService) ListSubscriptions() ([]models.Subscription, error) {
-	rows, err := s.DB.Query("SELECT id, service_name, price, user_id, start_date, end_date FROM subscriptions")
-	if err != nil {
-		return nil, err
+	if filter.Limit <= 0 {
+		filter.Limit = DefaultPageSize
 	}
-	defer rows.Close()
-
-	var subs []models.Subscription
-
-	for rows.Next() {
-		var sub models.Subscription
-		var endDate sql.NullTime
-
-		err := rows.Scan(&sub.ID, &sub.ServiceName, &sub.Price, &sub.UserID, &sub.StartDate, &endDate)
+	if filter.Limit > MaxPageSize {
+		filter.Limit = MaxPageSize
+	}
+	// Фильтр, сортировка и курсор по цене используют ту же цену, что и ответ: действующую сегодня
+	filter.PriceDate = today()
+	if cursor != "" {
+		after, err := decodeCursor(cursor, filter)
 		if err != nil {
-			log.Println("Scan error:", err)
-			continue
+			return models.SubscriptionPage{}, err
 		}
-		//Добавляем дату окончания в struct, если существует
-		if endDate.Valid {
-			sub.EndDate = &endDate.Time
-		} else {
-			sub.EndDate = nil
-		}
-
-		subs = append(subs, sub)
-	}
-
-	return subs, nil
-}
-
-func (s *SubscriptionsService) DeleteSubscription(id int) (int64, error) {
-
-	query := `DELETE FROM subscriptions WHERE id = $1`
-	result, err := s.DB.Exec(query, id)
-	if err != nil {
-		return 0, err
+		filter.After = after
 	}
 
-	rowsAffected, err := result.RowsAffected()
+	// Запрашиваем на одну запись больше, чтобы понять, есть ли следующая страница
+	pageSize := filter.Limit
+	filter.Limit++
+	subs, err := s.Repo.List(ctx, filter)
 	if err != nil {
-		return 0, err
+		return models.SubscriptionPage{}, err
 	}
 
-	return rowsAffected, nil
-}
-
-func (s *SubscriptionsService) GetTotalCost(userID string, serviceName string,
-	startDate time.Time, endDate time.Time) (int, error) {
-	query := `
-		SELECT price, start_date, end_date
-		FROM subscriptions
-		WHERE (start_date <= $2) AND (end_date >= $1 OR end_date IS NULL)
-	`
-	//Интерфейс для дополнения sql query другими агрументами, если существуют
-	args := []interface{}{startDate, endDate}
-	argID := 3 //счетчик позиции для след. аргументов в sql query
-
-	if serviceName != "" {
-		query += fmt.Sprintf(" AND service_name ILIKE $%d", argID)
-		args = append(args, "%"+serviceName+"%")
-		argID++
+	for i := range subs {
+		subs[i] = withCurrentPrice(subs[i])
 	}
-	if userID != "" {
-		query += fmt.Sprintf(" AND user_id = $%d", argID)
-		args = append(args, "%"+userID+"%")
-		argID++
+	page := models.SubscriptionPage{Items: subs}
+	if len(subs) > pageSize {
+		page.Items = subs[:pageSize]
+		page.NextCursor = encodeCursor(page.Items[pageSize-1], filter)
 	}
-
-	rows, err := s.DB.Query(query, args...)
-	if err != nil {
-		return 0, err
+	if page.Items == nil {
+		page.Items = []models.Subscription{}
 	}
-	defer rows.Close()
-	// Посчитать сколько целых месяцев в промежутке, и сколько всего сумма подписок в эти месяца включительно:
-	total := 0
+	return page, nil
+}
 
-	for rows.Next() {
-		var price int
-		var subStart, subEnd *time.Time
-		err := rows.Scan(&price, &subStart, &subEnd)
+// ExportSubscriptions передаёт в fn все подписки по фильтру. Подписки читаются страницами по MaxPageSize,
+// поэтому выборка целиком в памяти не держится. Ошибка fn прекращает выгрузку.
+func (s *SubscriptionsService) ExportSubscriptions(ctx context.Context, filter models.SubscriptionFilter, fn func(sub models.Subscription) error) error {
+	filter.Limit = MaxPageSize
+	cursor := ""
+	for {
+		page, err := s.ListSubscriptions(ctx, filter, cursor)
 		if err != nil {
-			return 0, err
+			return err
 		}
-
-		actualStart := maxTime(*subStart, startDate)
-		actualEnd := endDate
-		if subEnd != nil && subEnd.Before(endDate) {
-			actualEnd = *subEnd
+		for _, sub := range page.Items {
+			if err := fn(sub); err != nil {
+				return err
+			}
 		}
-
-		// Целое кол-во месяцев:
-		months := (actualEnd.Year()-actualStart.Year())*12 + int(actualEnd.Month()-actualStart.Month()) + 1
-		if months > 0 {
-			total += months * price
+		if page.NextCursor == "" {
+			return nil
 		}
-		logger.L().Info("Целое кол-во месяцев", zap.Int("Months", months))
+		cursor = page.NextCursor
 	}
+}
 
-	return total, nil
+// DeleteSubscription мягко удаляет подписку; 0 - подписка не найдена или недоступна клиенту.
+// ifMatch - допустимые версии записи (If-Match); nil - без проверки.
+func (s *SubscriptionsService) DeleteSubscription(ctx context.Context, id int, ifMatch []int) (int64, error) {
+	deleted, err := s.Repo.Delete(ctx, id, func(sub models.Subscription) error {
+		if !canAccess(ctx, sub) {
+			return repository.ErrNotFound
+		}
+		return checkVersion(sub, ifMatch)
+	})
+	if errors.Is(err, repository.ErrNotFound) {
+		return 0, nil
+	}
+	return deleted, err
 }
 
-func maxTime(a, b time.Time) time.Time {
-	if a.After(b) {
-		return a
+// checkVersion проверяет версию записи по If-Match; ifMatch == nil - без проверки
+func checkVersion(sub models.Subscription, ifMatch []int) error {
+	if ifMatch == nil || slices.Contains(ifMatch, sub.Version) {
+		return nil
 	}
-	return b
+	return ErrPreconditionFailed
 }
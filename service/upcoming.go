@@ -0,0 +1,75 @@
+package service
+
+import (
+	"context"
+	"sort"
+	"usersubs/models"
+)
+
+// MaxUpcomingDays - наибольшая длина окна предстоящих списаний
+const MaxUpcomingDays = 366
+
+// GetUpcoming возвращает списания, которые придутся на ближайшие q.Days дней (включая сегодня),
+// и подписки, действие которых закончится в этом окне. Даты списаний считаются от start_date с периодом
+// списания подписки; списания после end_date и во время пауз не учитываются.
+func (s *SubscriptionsService) GetUpcoming(ctx context.Context, q models.UpcomingQuery) (models.Upcoming, error) {
+	userID, err := ScopeUserID(ctx, q.UserID)
+	if err != nil {
+		return models.Upcoming{}, err
+	}
+	from := today()
+	to := from.AddDate(0, 0, q.Days-1)
+	subs, err := s.Repo.ListForPeriod(ctx, userID, q.ServiceName, from, to, false)
+	if err != nil {
+		return models.Upcoming{}, err
+	}
+
+	upcoming := models.Upcoming{
+		From:     from.Format("2006-01-02"),
+		To:       to.Format("2006-01-02"),
+		Charges:  []models.UpcomingCharge{},
+		Totals:   map[string]models.Money{},
+		Expiring: []models.ExpiringSubscription{},
+	}
+	for _, sub := range subs {
+		for _, d := range chargeDates(sub, from, to) {
+			amount := sub.PriceAt(d)
+			upcoming.Charges = append(upcoming.Charges, models.UpcomingCharge{
+				Date:           d.Format("2006-01-02"),
+				SubscriptionID: sub.ID,
+				ServiceName:    sub.ServiceName,
+				UserID:         sub.UserID,
+				Amount:         amount,
+				Currency:       sub.Currency,
+				BillingPeriod:  sub.BillingPeriod,
+			})
+			upcoming.Totals[sub.Currency] += amount
+		}
+		if sub.EndDate != nil && !sub.EndDate.Before(from) && !sub.EndDate.After(to) {
+			upcoming.Expiring = append(upcoming.Expiring, models.ExpiringSubscription{
+				SubscriptionID: sub.ID,
+				ServiceName:    sub.ServiceName,
+				UserID:         sub.UserID,
+				EndDate:        sub.EndDate.Format("2006-01-02"),
+				Status:         sub.Status,
+				Price:          sub.PriceAt(*sub.EndDate),
+				Currency:       sub.Currency,
+			})
+		}
+	}
+	// Даты в формате YYYY-MM-DD упорядочиваются как строки; при равной дате - по ID подписки
+	charges, expiring := upcoming.Charges, upcoming.Expiring
+	sort.Slice(charges, func(i, j int) bool {
+		if charges[i].Date != charges[j].Date {
+			return charges[i].Date < charges[j].Date
+		}
+		return charges[i].SubscriptionID < charges[j].SubscriptionID
+	})
+	sort.Slice(expiring, func(i, j int) bool {
+		if expiring[i].EndDate != expiring[j].EndDate {
+			return expiring[i].EndDate < expiring[j].EndDate
+		}
+		return expiring[i].SubscriptionID < expiring[j].SubscriptionID
+	})
+	return upcoming, nil
+}
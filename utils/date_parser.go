@@ -1,8 +1,8 @@
 package utils
 
 import (
-	"time"
 	"fmt"
+	"time"
 )
 
 func ParseMonthYear(s string) (time.Time, error) {
@@ -12,4 +12,25 @@ func ParseMonthYear(s string) (time.Time, error) {
 		return time.Time{}, fmt.Errorf("неверный формат даты %q, ожидается MM-YYYY: %w", s, err)
 	}
 	return t, nil
-}
\ No newline at end of file
+}
+
+// ParseDate принимает дату в формате YYYY-MM-DD или MM-YYYY (первое число месяца)
+func ParseDate(s string) (time.Time, error) {
+	if t, err := time.Parse("2006-01-02", s); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse("01-2006", s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("неверный формат даты %q, ожидается YYYY-MM-DD или MM-YYYY: %w", s, err)
+	}
+	return t, nil
+}
+
+// ParseEndDate - как ParseDate, но MM-YYYY означает последний день месяца:
+// дата окончания в формате месяца включает месяц целиком
+func ParseEndDate(s string) (time.Time, error) {
+	if t, err := time.Parse("01-2006", s); err == nil {
+		return t.AddDate(0, 1, -1), nil
+	}
+	return ParseDate(s)
+}
@@ -3,21 +3,17 @@ package utils
 import "net/http"
 
 func NotFound(w http.ResponseWriter, r *http.Request) {
-	w.WriteHeader(http.StatusNotFound)
-	w.Write([]byte(`{"error":"Страница не найдена"}`)) //Возвращаем JSON
+	Error(w, r, http.StatusNotFound, CodeNotFound, MsgPageNotFound)
 }
 
 func InternalServerError(w http.ResponseWriter, r *http.Request) {
-	w.WriteHeader(http.StatusInternalServerError)
-	w.Write([]byte(`{"error":"Internal server error"}`))
+	Error(w, r, http.StatusInternalServerError, CodeInternal, "")
 }
 
 func BadRequest(w http.ResponseWriter, r *http.Request) {
-	w.WriteHeader(http.StatusBadRequest)
-	w.Write([]byte(`{"error":"Bad request"}`))
+	Error(w, r, http.StatusBadRequest, CodeBadRequest, MsgBadBody)
 }
 
 func MethodNotAllowed(w http.ResponseWriter, r *http.Request) {
-	w.WriteHeader(http.StatusMethodNotAllowed)
-	w.Write([]byte(`{"error":"Method Not Allowed"}`))
-}
\ No newline at end of file
+	Error(w, r, http.StatusMethodNotAllowed, CodeMethodNotAllowed, "")
+}
@@ -0,0 +1,37 @@
+package utils
+
+import (
+	"strconv"
+	"strings"
+)
+
+// ETag возвращает строгий ETag версии записи, например "v3"
+func ETag(version int) string {
+	return `"v` + strconv.Itoa(version) + `"`
+}
+
+// ParseIfMatch разбирает заголовок If-Match в список версий.
+// nil - заголовок не передан или равен "*" (подходит любая существующая запись).
+// Значения, не похожие на ETag записи, пропускаются: с ними не совпадёт ни одна версия.
+func ParseIfMatch(header string) []int {
+	header = strings.TrimSpace(header)
+	if header == "" || header == "*" {
+		return nil
+	}
+	versions := []int{}
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		// Слабые ETag не подходят для If-Match (RFC 9110, 13.1.1)
+		if strings.HasPrefix(tag, "W/") {
+			continue
+		}
+		v, ok := strings.CutPrefix(strings.Trim(tag, `"`), "v")
+		if !ok {
+			continue
+		}
+		if version, err := strconv.Atoi(v); err == nil {
+			versions = append(versions, version)
+		}
+	}
+	return versions
+}
@@ -0,0 +1,744 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Языки сообщений; русский - язык по умолчанию
+const (
+	LangRU = "ru"
+	LangEN = "en"
+)
+
+// Ключи каталога сообщений
+const (
+	MsgBadBody            = "bad_body"
+	MsgBodyNotObject      = "body_not_object"
+	MsgMergePatchOnly     = "merge_patch_only"
+	MsgMissingFields      = "missing_fields"
+	MsgRequiredField      = "required_field"
+	MsgRequiredParam      = "required_param"
+	MsgPeriodRequired     = "period_required"
+	MsgInvalidRecordID    = "invalid_record_id"
+	MsgInvalidKeyID       = "invalid_key_id"
+	MsgRecordNotFound     = "record_not_found"
+	MsgKeyNotFound        = "key_not_found"
+	MsgPageNotFound       = "page_not_found"
+	MsgInvalidDate        = "invalid_date"
+	MsgInvalidDay         = "invalid_day"
+	MsgInvalidMonth       = "invalid_month"
+	MsgInvalidTimestamp   = "invalid_timestamp"
+	MsgEndBeforeStart     = "end_before_start"
+	MsgPeriodTooLong      = "period_too_long"
+	MsgInvalidMoney       = "invalid_money"
+	MsgNegativePrice      = "negative_price"
+	MsgInvalidCurrency    = "invalid_currency"
+	MsgCurrencyCode       = "currency_code"
+	MsgAllowedValues      = "allowed_values"
+	MsgPositiveInteger    = "positive_integer"
+	MsgInvalidBool        = "invalid_bool"
+	MsgInvalidCursor      = "invalid_cursor"
+	MsgProrationCharges   = "proration_requires_charges"
+	MsgInvalidFields      = "invalid_fields"
+	MsgFieldNotRemovable  = "field_not_removable"
+	MsgUnknownField       = "unknown_field"
+	MsgExpectedString     = "expected_string"
+	MsgExpectedNonEmpty   = "expected_non_empty_string"
+	MsgExpectedStringNull = "expected_string_or_null"
+	MsgExpectedUUID       = "expected_uuid"
+	MsgUnauthenticated    = "unauthenticated"
+	MsgInsufficientScope  = "insufficient_scope"
+	MsgForbidden          = "forbidden"
+	MsgDuplicate          = "duplicate_subscription"
+	MsgNotDeleted         = "not_deleted"
+	MsgPreconditionFailed = "precondition_failed"
+	MsgIdempotencyKey     = "idempotency_key_invalid"
+	MsgIdempotencyReused  = "idempotency_key_reused"
+	MsgIdempotencyPending = "idempotency_in_progress"
+	MsgMissingRate        = "exchange_rate_missing"
+	MsgRatesFormat        = "rates_format_required"
+	MsgDBConflict         = "db_conflict"
+	MsgDBInvalidValue     = "db_invalid_value"
+	MsgDBInvalidDate      = "db_invalid_date"
+	MsgDBCheckFailed      = "db_check_failed"
+	MsgNameLength         = "service_name_length"
+	MsgNameCharset        = "service_name_charset"
+	MsgPriceTooHigh       = "price_too_high"
+	MsgDateOutOfRange     = "date_out_of_range"
+	MsgExpectedObject     = "expected_object"
+	MsgBatchTooLarge      = "batch_too_large"
+	MsgBatchAborted       = "batch_aborted"
+	MsgImportFormat       = "import_format_required"
+	MsgExportFormat       = "export_format"
+	MsgCalendarDisabled   = "calendar_disabled"
+	MsgIntegerRange       = "integer_range"
+	MsgInvalidPercent     = "invalid_percent"
+	MsgServiceInflation   = "service_inflation_format"
+	MsgCalendarName       = "calendar_name"
+	MsgRenewalSummary     = "renewal_summary"
+	MsgRenewalDescription = "renewal_description"
+
+	MsgPauseNotActive        = "pause_not_active"
+	MsgPauseBeforeStart      = "pause_before_start"
+	MsgPauseAfterEnd         = "pause_after_end"
+	MsgPauseBeforePrevious   = "pause_before_previous"
+	MsgResumeNotPaused       = "resume_not_paused"
+	MsgNoOpenPause           = "no_open_pause"
+	MsgResumeBeforePause     = "resume_before_pause"
+	MsgAlreadyCancelled      = "already_cancelled"
+	MsgCancelBeforeStart     = "cancel_before_start"
+	MsgPriceChangeBeforeFrom = "price_change_before_start"
+	MsgPriceChangeAfterEnd   = "price_change_after_end"
+	MsgKeyNameRequired       = "api_key_name_required"
+	MsgKeyAdminUserID        = "api_key_admin_user_id"
+	MsgKeyUserIDRequired     = "api_key_user_id_required"
+	MsgKeyRole               = "api_key_role"
+	MsgRatesUnknownFormat    = "rates_unknown_format"
+	MsgRatesHeader           = "rates_header"
+	MsgRatesMissingColumn    = "rates_missing_column"
+	MsgRatesLine             = "rates_line"
+	MsgRatesLineValue        = "rates_line_value"
+	MsgRatesParse            = "rates_parse"
+	MsgRatesValCursValue     = "rates_valcurs_value"
+	MsgRatesNoValutes        = "rates_no_valutes"
+	MsgImportUnknownFormat   = "import_unknown_format"
+	MsgImportHeader          = "import_header"
+	MsgImportMissingColumn   = "import_missing_column"
+	MsgImportRead            = "import_read"
+	MsgImportRowSyntax       = "import_row_syntax"
+	MsgRatesCurrency         = "rates_currency"
+	MsgRatesDate             = "rates_date"
+	MsgRatesValue            = "rates_value"
+	MsgRatesNominal          = "rates_nominal"
+	MsgRatesCharset          = "rates_charset"
+	MsgFileEmpty             = "file_empty"
+	MsgFileTruncated         = "file_truncated"
+	MsgFileTooLarge          = "file_too_large"
+	MsgFileUnreadable        = "file_unreadable"
+	MsgLineTooLong           = "line_too_long"
+	MsgCSVSyntax             = "csv_syntax"
+	MsgXMLSyntax             = "xml_syntax"
+	MsgJSONSyntax            = "json_syntax"
+	MsgJSONType              = "json_type"
+)
+
+// catalog - переводы сообщений: ключ -> язык -> шаблон для fmt.Sprintf.
+// Заголовки ошибок хранятся под ключами title.<код ошибки>.
+var catalog = map[string]map[string]string{
+	"title." + CodeBadRequest: {
+		LangRU: "Некорректный запрос",
+		LangEN: "Bad request",
+	},
+	"title." + CodeValidation: {
+		LangRU: "Ошибка проверки данных",
+		LangEN: "Validation failed",
+	},
+	"title." + CodeNotFound: {
+		LangRU: "Не найдено",
+		LangEN: "Not found",
+	},
+	"title." + CodeMethodNotAllowed: {
+		LangRU: "Метод не поддерживается",
+		LangEN: "Method not allowed",
+	},
+	"title." + CodeUnsupportedMediaType: {
+		LangRU: "Неподдерживаемый формат тела",
+		LangEN: "Unsupported media type",
+	},
+	"title." + CodeUnauthorized: {
+		LangRU: "Требуется аутентификация",
+		LangEN: "Authentication required",
+	},
+	"title." + CodeForbidden: {
+		LangRU: "Доступ запрещён",
+		LangEN: "Forbidden",
+	},
+	"title." + CodeInsufficientScope: {
+		LangRU: "Недостаточно прав токена",
+		LangEN: "Insufficient token scope",
+	},
+	"title." + CodeConflict: {
+		LangRU: "Конфликт",
+		LangEN: "Conflict",
+	},
+	"title." + CodeDuplicate: {
+		LangRU: "Подписка уже существует",
+		LangEN: "Subscription already exists",
+	},
+	"title." + CodeInvalidTransition: {
+		LangRU: "Недопустимое действие",
+		LangEN: "Invalid state transition",
+	},
+	"title." + CodePreconditionFailed: {
+		LangRU: "Версия записи не совпадает",
+		LangEN: "Precondition failed",
+	},
+	"title." + CodeIdempotencyMismatch: {
+		LangRU: "Ключ идемпотентности уже использован",
+		LangEN: "Idempotency key already used",
+	},
+	"title." + CodeIdempotencyInProgress: {
+		LangRU: "Запрос ещё выполняется",
+		LangEN: "Request still in progress",
+	},
+	"title." + CodeExchangeRateMissing: {
+		LangRU: "Нет курса валюты",
+		LangEN: "Exchange rate unavailable",
+	},
+	"title." + CodeNotAcceptable: {
+		LangRU: "Формат ответа не поддерживается",
+		LangEN: "Not acceptable",
+	},
+	"title." + CodeBatchAborted: {
+		LangRU: "Пакет отменён",
+		LangEN: "Batch aborted",
+	},
+	"title." + CodeInternal: {
+		LangRU: "Внутренняя ошибка сервера",
+		LangEN: "Internal server error",
+	},
+
+	MsgBadBody: {
+		LangRU: "Неправильное тело запроса",
+		LangEN: "Malformed request body",
+	},
+	MsgBodyNotObject: {
+		LangRU: "Тело запроса должно быть JSON-объектом",
+		LangEN: "Request body must be a JSON object",
+	},
+	MsgMergePatchOnly: {
+		LangRU: "Ожидается тело application/merge-patch+json",
+		LangEN: "Expected an application/merge-patch+json body",
+	},
+	MsgMissingFields: {
+		LangRU: "Отсутствуют обязательные поля",
+		LangEN: "Required fields are missing",
+	},
+	MsgRequiredField: {
+		LangRU: "Обязательное поле",
+		LangEN: "Field is required",
+	},
+	MsgRequiredParam: {
+		LangRU: "Обязательный параметр",
+		LangEN: "Parameter is required",
+	},
+	MsgPeriodRequired: {
+		LangRU: "start_date, end_date обязательны",
+		LangEN: "start_date and end_date are required",
+	},
+	MsgInvalidRecordID: {
+		LangRU: "Неправильный ID записи",
+		LangEN: "Invalid record ID",
+	},
+	MsgInvalidKeyID: {
+		LangRU: "Неправильный ID ключа",
+		LangEN: "Invalid key ID",
+	},
+	MsgRecordNotFound: {
+		LangRU: "Запись не найдена",
+		LangEN: "Record not found",
+	},
+	MsgKeyNotFound: {
+		LangRU: "Ключ не найден или уже отозван",
+		LangEN: "Key not found or already revoked",
+	},
+	MsgPageNotFound: {
+		LangRU: "Страница не найдена",
+		LangEN: "Page not found",
+	},
+	MsgInvalidDate: {
+		LangRU: "Неверный формат %s (YYYY-MM-DD или MM-YYYY)",
+		LangEN: "Invalid %s format (YYYY-MM-DD or MM-YYYY)",
+	},
+	MsgInvalidDay: {
+		LangRU: "Неверный формат %s (YYYY-MM-DD)",
+		LangEN: "Invalid %s format (YYYY-MM-DD)",
+	},
+	MsgInvalidMonth: {
+		LangRU: "Неверный формат %s (MM-YYYY)",
+		LangEN: "Invalid %s format (MM-YYYY)",
+	},
+	MsgInvalidTimestamp: {
+		LangRU: "Неверный формат %s (RFC 3339 или YYYY-MM-DD)",
+		LangEN: "Invalid %s format (RFC 3339 or YYYY-MM-DD)",
+	},
+	MsgEndBeforeStart: {
+		LangRU: "Дата окончания не может быть раньше даты начала",
+		LangEN: "End date cannot be earlier than start date",
+	},
+	MsgPeriodTooLong: {
+		LangRU: "Период не может быть длиннее %d месяцев",
+		LangEN: "The period cannot be longer than %d months",
+	},
+	MsgInvalidMoney: {
+		LangRU: "%s: ожидается сумма с не более чем двумя знаками после точки, например 299.90",
+		LangEN: "%s: expected an amount with at most two decimal places, e.g. 299.90",
+	},
+	MsgNegativePrice: {
+		LangRU: "Цена не может быть отрицательной",
+		LangEN: "Price cannot be negative",
+	},
+	MsgInvalidCurrency: {
+		LangRU: "%s: ожидается трёхбуквенный код ISO 4217 валюты с двумя знаками после запятой",
+		LangEN: "%s: expected a three-letter ISO 4217 code of a currency with two decimal places",
+	},
+	MsgCurrencyCode: {
+		LangRU: "%s: ожидается трёхбуквенный код ISO 4217",
+		LangEN: "%s: expected a three-letter ISO 4217 code",
+	},
+	MsgAllowedValues: {
+		LangRU: "%s: допустимые значения %s",
+		LangEN: "%s: allowed values are %s",
+	},
+	MsgPositiveInteger: {
+		LangRU: "%s должен быть положительным целым числом",
+		LangEN: "%s must be a positive integer",
+	},
+	MsgInvalidBool: {
+		LangRU: "%s: ожидается true или false",
+		LangEN: "%s: expected true or false",
+	},
+	MsgInvalidCursor: {
+		LangRU: "Некорректный курсор",
+		LangEN: "Invalid cursor",
+	},
+	MsgProrationCharges: {
+		LangRU: "proration=daily поддерживается только в режиме charges",
+		LangEN: "proration=daily is only supported in charges mode",
+	},
+	MsgInvalidFields: {
+		LangRU: "Некорректные поля в теле запроса",
+		LangEN: "Request body contains invalid fields",
+	},
+	MsgFieldNotRemovable: {
+		LangRU: "Поле нельзя удалить, укажите значение",
+		LangEN: "Field cannot be removed, provide a value",
+	},
+	MsgUnknownField: {
+		LangRU: "Неизвестное поле",
+		LangEN: "Unknown field",
+	},
+	MsgExpectedString: {
+		LangRU: "Ожидается строка",
+		LangEN: "Expected a string",
+	},
+	MsgExpectedNonEmpty: {
+		LangRU: "Ожидается непустая строка",
+		LangEN: "Expected a non-empty string",
+	},
+	MsgExpectedStringNull: {
+		LangRU: "Ожидается строка или null",
+		LangEN: "Expected a string or null",
+	},
+	MsgExpectedUUID: {
+		LangRU: "Ожидается UUID",
+		LangEN: "Expected a UUID",
+	},
+	MsgUnauthenticated: {
+		LangRU: "Требуется действительный токен (Authorization: Bearer) или API-ключ (X-API-Key)",
+		LangEN: "A valid token (Authorization: Bearer) or API key (X-API-Key) is required",
+	},
+	MsgInsufficientScope: {
+		LangRU: "Токену не хватает области доступа %s",
+		LangEN: "Token lacks the %s scope",
+	},
+	MsgForbidden: {
+		LangRU: "Доступ запрещён",
+		LangEN: "Access denied",
+	},
+	MsgDuplicate: {
+		LangRU: "Подписка с такими user_id, service_name и start_date уже существует",
+		LangEN: "A subscription with the same user_id, service_name and start_date already exists",
+	},
+	MsgNotDeleted: {
+		LangRU: "Подписка не удалена",
+		LangEN: "Subscription is not deleted",
+	},
+	MsgPreconditionFailed: {
+		LangRU: "Запись была изменена другим запросом: получите актуальную версию и повторите",
+		LangEN: "The record was modified by another request: fetch the current version and retry",
+	},
+	MsgIdempotencyKey: {
+		LangRU: "Idempotency-Key должен быть непустой строкой не длиннее 255 символов",
+		LangEN: "Idempotency-Key must be a non-empty string of at most 255 characters",
+	},
+	MsgIdempotencyReused: {
+		LangRU: "Idempotency-Key уже использован с другим телом запроса",
+		LangEN: "Idempotency-Key was already used with a different request body",
+	},
+	MsgIdempotencyPending: {
+		LangRU: "Запрос с этим Idempotency-Key ещё выполняется",
+		LangEN: "A request with this Idempotency-Key is still in progress",
+	},
+	MsgMissingRate: {
+		LangRU: "Не удалось пересчитать валюту: нет курса %s на %s",
+		LangEN: "Cannot convert currency: no %s rate for %s",
+	},
+	MsgRatesFormat: {
+		LangRU: "Не удалось определить формат файла: укажите format=csv или format=cbr",
+		LangEN: "Cannot detect the file format: specify format=csv or format=cbr",
+	},
+	MsgDBConflict: {
+		LangRU: "Запись с такими значениями уже существует",
+		LangEN: "A record with these values already exists",
+	},
+	MsgDBInvalidValue: {
+		LangRU: "Некорректное значение параметра: ожидается UUID или число",
+		LangEN: "Invalid parameter value: expected a UUID or a number",
+	},
+	MsgDBInvalidDate: {
+		LangRU: "Некорректная дата",
+		LangEN: "Invalid date",
+	},
+	MsgDBCheckFailed: {
+		LangRU: "Значение не прошло проверку базы данных",
+		LangEN: "Value failed a database check",
+	},
+	MsgNameLength: {
+		LangRU: "Длина названия должна быть от %d до %d символов",
+		LangEN: "Name length must be between %d and %d characters",
+	},
+	MsgNameCharset: {
+		LangRU: "Название должно начинаться с буквы или цифры и может содержать буквы, цифры, пробелы и символы %s",
+		LangEN: "Name must start with a letter or digit and may contain letters, digits, spaces and the characters %s",
+	},
+	MsgPriceTooHigh: {
+		LangRU: "Цена не может быть больше %s",
+		LangEN: "Price cannot exceed %s",
+	},
+	MsgDateOutOfRange: {
+		LangRU: "Дата должна быть в диапазоне с %s по %s",
+		LangEN: "Date must be between %s and %s",
+	},
+	MsgExpectedObject: {
+		LangRU: "Ожидается JSON-объект",
+		LangEN: "Expected a JSON object",
+	},
+	MsgBatchTooLarge: {
+		LangRU: "В одном запросе допускается не больше %d операций",
+		LangEN: "At most %d operations are allowed per request",
+	},
+	MsgImportFormat: {
+		LangRU: "Не удалось определить формат файла: укажите format=csv или format=jsonl",
+		LangEN: "Cannot detect the file format: specify format=csv or format=jsonl",
+	},
+	MsgExportFormat: {
+		LangRU: "Выгрузка доступна в форматах text/csv, application/json и XLSX; укажите Accept или format=csv|xlsx|json",
+		LangEN: "Exports are available as text/csv, application/json and XLSX; set Accept or format=csv|xlsx|json",
+	},
+	MsgIntegerRange: {
+		LangRU: "%s должен быть целым числом от %d до %d",
+		LangEN: "%s must be an integer from %d to %d",
+	},
+	MsgInvalidPercent: {
+		LangRU: "%s: ожидается процент от 0 до 100, например 7.5",
+		LangEN: "%s: expected a percentage from 0 to 100, e.g. 7.5",
+	},
+	MsgServiceInflation: {
+		LangRU: "Ожидается <service_name>:<процент>, например Netflix:10",
+		LangEN: "Expected <service_name>:<percent>, e.g. Netflix:10",
+	},
+	MsgCalendarDisabled: {
+		LangRU: "Ссылки на календарь продлений не настроены (CALENDAR_TOKEN_SECRET)",
+		LangEN: "Renewal calendar links are not configured (CALENDAR_TOKEN_SECRET)",
+	},
+	MsgCalendarName: {
+		LangRU: "Продления подписок",
+		LangEN: "Subscription renewals",
+	},
+	MsgRenewalSummary: {
+		LangRU: "Продление %s",
+		LangEN: "%s renewal",
+	},
+	MsgRenewalDescription: {
+		LangRU: "Сервис: %s\nСтоимость: %s %s\nПериод списания: %s",
+		LangEN: "Service: %s\nPrice: %s %s\nBilling period: %s",
+	},
+	MsgBatchAborted: {
+		LangRU: "Операция отменена из-за ошибки операции %d",
+		LangEN: "Operation rolled back because operation %d failed",
+	},
+
+	MsgPauseNotActive: {
+		LangRU: "приостановить можно только активную подписку, текущее состояние %s",
+		LangEN: "only an active subscription can be paused, current status is %s",
+	},
+	MsgPauseBeforeStart: {
+		LangRU: "пауза не может начаться раньше start_date",
+		LangEN: "a pause cannot start before start_date",
+	},
+	MsgPauseAfterEnd: {
+		LangRU: "пауза не может начаться позже end_date",
+		LangEN: "a pause cannot start after end_date",
+	},
+	MsgPauseBeforePrevious: {
+		LangRU: "пауза не может начаться раньше окончания предыдущей",
+		LangEN: "a pause cannot start before the previous one ended",
+	},
+	MsgResumeNotPaused: {
+		LangRU: "возобновить можно только приостановленную подписку, текущее состояние %s",
+		LangEN: "only a paused subscription can be resumed, current status is %s",
+	},
+	MsgNoOpenPause: {
+		LangRU: "у подписки нет открытой паузы",
+		LangEN: "the subscription has no open pause",
+	},
+	MsgResumeBeforePause: {
+		LangRU: "дата возобновления должна быть позже начала паузы",
+		LangEN: "the resume date must be after the pause start",
+	},
+	MsgAlreadyCancelled: {
+		LangRU: "подписка уже отменена",
+		LangEN: "the subscription is already cancelled",
+	},
+	MsgCancelBeforeStart: {
+		LangRU: "дата отмены не может быть раньше start_date",
+		LangEN: "the cancellation date cannot be before start_date",
+	},
+	MsgPriceChangeBeforeFrom: {
+		LangRU: "месяц изменения раньше начала подписки",
+		LangEN: "the change month is before the subscription start",
+	},
+	MsgPriceChangeAfterEnd: {
+		LangRU: "месяц изменения позже окончания подписки",
+		LangEN: "the change month is after the subscription end",
+	},
+	MsgKeyNameRequired: {
+		LangRU: "name обязателен",
+		LangEN: "name is required",
+	},
+	MsgKeyAdminUserID: {
+		LangRU: "ключ администратора не привязывается к user_id",
+		LangEN: "an admin key cannot be bound to a user_id",
+	},
+	MsgKeyUserIDRequired: {
+		LangRU: "для роли user нужен user_id в формате UUID",
+		LangEN: "the user role requires a user_id in UUID format",
+	},
+	MsgKeyRole: {
+		LangRU: "role: допустимые значения admin, user",
+		LangEN: "role: allowed values are admin, user",
+	},
+	MsgRatesUnknownFormat: {
+		LangRU: "неизвестный формат %q",
+		LangEN: "unknown format %q",
+	},
+	MsgRatesHeader: {
+		LangRU: "не удалось прочитать заголовок: %s",
+		LangEN: "cannot read the header: %s",
+	},
+	MsgRatesMissingColumn: {
+		LangRU: "нет колонки %s",
+		LangEN: "missing column %s",
+	},
+	MsgRatesLine: {
+		LangRU: "строка %d: %s",
+		LangEN: "line %d: %s",
+	},
+	MsgRatesLineValue: {
+		LangRU: "строка %d: некорректное значение: %s",
+		LangEN: "line %d: invalid value: %s",
+	},
+	MsgRatesParse: {
+		LangRU: "не удалось разобрать XML: %s",
+		LangEN: "cannot parse the XML: %s",
+	},
+	MsgRatesValCursValue: {
+		LangRU: "ValCurs %s: некорректное значение: %s",
+		LangEN: "ValCurs %s: invalid value: %s",
+	},
+	MsgRatesNoValutes: {
+		LangRU: "не найдено ни одного элемента ValCurs/Valute",
+		LangEN: "no ValCurs/Valute elements found",
+	},
+	MsgImportUnknownFormat: {
+		LangRU: "неизвестный формат %q",
+		LangEN: "unknown format %q",
+	},
+	MsgImportHeader: {
+		LangRU: "не удалось прочитать заголовок CSV: %s",
+		LangEN: "cannot read the CSV header: %s",
+	},
+	MsgImportMissingColumn: {
+		LangRU: "в CSV нет колонки %s",
+		LangEN: "the CSV has no %s column",
+	},
+	MsgImportRead: {
+		LangRU: "ошибка чтения файла в строке %d: %s",
+		LangEN: "cannot read the file at line %d: %s",
+	},
+	MsgRatesCurrency: {
+		LangRU: "код валюты %q",
+		LangEN: "currency code %q",
+	},
+	MsgRatesDate: {
+		LangRU: "дата %q",
+		LangEN: "date %q",
+	},
+	MsgRatesValue: {
+		LangRU: "курс %q",
+		LangEN: "rate %q",
+	},
+	MsgRatesNominal: {
+		LangRU: "номинал %q",
+		LangEN: "nominal %q",
+	},
+	MsgRatesCharset: {
+		LangRU: "неподдерживаемая кодировка %s",
+		LangEN: "unsupported encoding %s",
+	},
+	MsgFileEmpty: {
+		LangRU: "файл пуст",
+		LangEN: "the file is empty",
+	},
+	MsgFileTruncated: {
+		LangRU: "файл обрывается",
+		LangEN: "the file is truncated",
+	},
+	MsgFileTooLarge: {
+		LangRU: "файл больше %d байт",
+		LangEN: "the file exceeds %d bytes",
+	},
+	MsgFileUnreadable: {
+		LangRU: "не удалось прочитать файл",
+		LangEN: "cannot read the file",
+	},
+	MsgLineTooLong: {
+		LangRU: "слишком длинная строка",
+		LangEN: "the line is too long",
+	},
+	MsgCSVSyntax: {
+		LangRU: "ошибка синтаксиса CSV, позиция %d",
+		LangEN: "CSV syntax error at column %d",
+	},
+	MsgXMLSyntax: {
+		LangRU: "ошибка синтаксиса XML в строке %d",
+		LangEN: "XML syntax error at line %d",
+	},
+	MsgJSONSyntax: {
+		LangRU: "ошибка синтаксиса JSON (позиция %d)",
+		LangEN: "JSON syntax error at offset %d",
+	},
+	MsgJSONType: {
+		LangRU: "неверный тип значения поля %s",
+		LangEN: "wrong value type for field %s",
+	},
+	MsgImportRowSyntax: {
+		LangRU: "некорректная строка: %s",
+		LangEN: "malformed row: %s",
+	},
+}
+
+// Message - сообщение каталога с аргументами шаблона
+type Message struct {
+	Key  string
+	Args []any
+}
+
+// NewMessage создаёт сообщение каталога
+func NewMessage(key string, args ...any) Message {
+	return Message{Key: key, Args: args}
+}
+
+// In возвращает текст сообщения на языке lang (при отсутствии перевода - на русском).
+// Ключ, которого нет в каталоге, возвращается как есть.
+func (m Message) In(lang string) string {
+	translations, ok := catalog[m.Key]
+	if !ok {
+		if len(m.Args) == 0 {
+			return m.Key
+		}
+		return fmt.Sprintf(m.Key, m.localizedArgs(lang)...)
+	}
+	tmpl, ok := translations[lang]
+	if !ok {
+		tmpl = translations[LangRU]
+	}
+	if len(m.Args) == 0 {
+		return tmpl
+	}
+	return fmt.Sprintf(tmpl, m.localizedArgs(lang)...)
+}
+
+// localizedArgs переводит аргументы-сообщения на язык lang; остальные аргументы не меняются
+func (m Message) localizedArgs(lang string) []any {
+	args := make([]any, len(m.Args))
+	for i, arg := range m.Args {
+		if msg, ok := arg.(Message); ok {
+			arg = msg.In(lang)
+		}
+		args[i] = arg
+	}
+	return args
+}
+
+// Translate возвращает текст сообщения key на языке lang
+func Translate(lang, key string, args ...any) string {
+	return NewMessage(key, args...).In(lang)
+}
+
+// Language выбирает язык ответа по заголовку Accept-Language; по умолчанию русский
+func Language(r *http.Request) string {
+	type candidate struct {
+		lang string
+		q    float64
+	}
+	var candidates []candidate
+	for _, part := range strings.Split(r.Header.Get("Accept-Language"), ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		primary, _, _ := strings.Cut(strings.ToLower(strings.TrimSpace(tag)), "-")
+		if primary != LangRU && primary != LangEN {
+			continue
+		}
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		if q > 0 {
+			candidates = append(candidates, candidate{primary, q})
+		}
+	}
+	if len(candidates) == 0 {
+		return LangRU
+	}
+	// При равном q побеждает язык, указанный раньше
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].q > candidates[j].q })
+	return candidates[0].lang
+}
+
+// MessageError - ошибка с локализуемым текстом; errors.Is находит исходную ошибку Err
+type MessageError struct {
+	Err     error
+	Message Message
+}
+
+func (e *MessageError) Error() string {
+	return e.Err.Error() + ": " + e.Message.In(LangRU)
+}
+
+func (e *MessageError) Unwrap() error {
+	return e.Err
+}
+
+// MessageOf возвращает локализуемое сообщение ошибки; ok = false, если в цепочке err нет MessageError
+func MessageOf(err error) (msg Message, ok bool) {
+	var msgErr *MessageError
+	if errors.As(err, &msgErr) {
+		return msgErr.Message, true
+	}
+	return Message{}, false
+}
+
+// Errorf оборачивает err сообщением каталога key
+func Errorf(err error, key string, args ...any) error {
+	return &MessageError{Err: err, Message: NewMessage(key, args...)}
+}
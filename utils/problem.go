@@ -0,0 +1,149 @@
+package utils
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/lib/pq"
+)
+
+// Тип тела ответа с ошибкой (RFC 7807)
+const ProblemContentType = "application/problem+json"
+
+// Машиночитаемые коды ошибок; код же задаёт type ошибки: /problems/<код>
+const (
+	CodeBadRequest            = "bad_request"
+	CodeValidation            = "validation_failed"
+	CodeNotFound              = "not_found"
+	CodeMethodNotAllowed      = "method_not_allowed"
+	CodeUnsupportedMediaType  = "unsupported_media_type"
+	CodeNotAcceptable         = "not_acceptable"
+	CodeUnauthorized          = "unauthorized"
+	CodeForbidden             = "forbidden"
+	CodeInsufficientScope     = "insufficient_scope"
+	CodeConflict              = "conflict"
+	CodeDuplicate             = "duplicate_subscription"
+	CodeInvalidTransition     = "invalid_transition"
+	CodePreconditionFailed    = "precondition_failed"
+	CodeIdempotencyMismatch   = "idempotency_key_reused"
+	CodeIdempotencyInProgress = "idempotency_in_progress"
+	CodeExchangeRateMissing   = "exchange_rate_unavailable"
+	CodeBatchAborted          = "batch_aborted"
+	CodeInternal              = "internal_error"
+)
+
+// FieldError - ошибка в конкретном поле запроса
+type FieldError struct {
+	Field   string `json:"field" example:"start_date"`
+	Message string `json:"message" example:"Неверный формат start_date (YYYY-MM-DD или MM-YYYY)"`
+
+	message Message
+}
+
+// Problem - тело ответа с ошибкой в формате application/problem+json (RFC 7807)
+type Problem struct {
+	Type     string       `json:"type" example:"/problems/validation_failed"`
+	Title    string       `json:"title" example:"Ошибка проверки данных"`
+	Status   int          `json:"status" example:"400"`
+	Detail   string       `json:"detail,omitempty" example:"Неверный формат start_date (YYYY-MM-DD или MM-YYYY)"`
+	Instance string       `json:"instance,omitempty" example:"/subscriptions"`
+	Code     string       `json:"code" example:"validation_failed"`
+	Errors   []FieldError `json:"errors,omitempty"`
+
+	detail Message
+}
+
+// NewProblem создаёт ошибку со статусом status, кодом code и пояснением из каталога сообщений (key, args).
+// Заголовок и пояснение переводятся на язык клиента при записи ответа.
+func NewProblem(status int, code, key string, args ...any) *Problem {
+	return &Problem{Type: "/problems/" + code, Status: status, Code: code, detail: NewMessage(key, args...)}
+}
+
+// NewProblemMessage создаёт ошибку с готовым сообщением каталога
+func NewProblemMessage(status int, code string, msg Message) *Problem {
+	return &Problem{Type: "/problems/" + code, Status: status, Code: code, detail: msg}
+}
+
+// WithField добавляет ошибку поля field с сообщением из каталога (key, args)
+func (p *Problem) WithField(field, key string, args ...any) *Problem {
+	p.Errors = append(p.Errors, NewFieldError(field, key, args...))
+	return p
+}
+
+// WithFieldErrors добавляет готовые ошибки полей
+func (p *Problem) WithFieldErrors(errs ...FieldError) *Problem {
+	p.Errors = append(p.Errors, errs...)
+	return p
+}
+
+// NewFieldError создаёт ошибку поля field с сообщением из каталога (key, args)
+func NewFieldError(field, key string, args ...any) FieldError {
+	return FieldError{Field: field, message: NewMessage(key, args...)}
+}
+
+// Text возвращает текст ошибки поля на языке lang
+func (e FieldError) Text(lang string) string {
+	return e.message.In(lang)
+}
+
+// Localize переводит заголовок, пояснение и сообщения полей на язык lang
+func (p *Problem) Localize(lang string) *Problem {
+	p.Title = http.StatusText(p.Status)
+	if _, ok := catalog["title."+p.Code]; ok {
+		p.Title = Translate(lang, "title."+p.Code)
+	}
+	p.Detail = p.detail.In(lang)
+	for i := range p.Errors {
+		p.Errors[i].Message = p.Errors[i].message.In(lang)
+	}
+	return p
+}
+
+// WriteProblem пишет ошибку в ответ на языке из Accept-Language; instance - путь запроса
+func WriteProblem(w http.ResponseWriter, r *http.Request, p *Problem) {
+	lang := Language(r)
+	p.Localize(lang)
+	if p.Instance == "" {
+		p.Instance = r.URL.Path
+	}
+	w.Header().Set("Content-Type", ProblemContentType)
+	w.Header().Set("Content-Language", lang)
+	w.Header().Add("Vary", "Accept-Language")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(p.Status)
+	json.NewEncoder(w).Encode(p)
+}
+
+// Error пишет ошибку без ошибок полей; key, args - пояснение из каталога сообщений
+func Error(w http.ResponseWriter, r *http.Request, status int, code, key string, args ...any) {
+	WriteProblem(w, r, NewProblem(status, code, key, args...))
+}
+
+// InvalidField пишет 400 с ошибкой одного поля запроса
+func InvalidField(w http.ResponseWriter, r *http.Request, field, key string, args ...any) {
+	WriteProblem(w, r, NewProblem(http.StatusBadRequest, CodeValidation, key, args...).WithField(field, key, args...))
+}
+
+// DatabaseProblem переводит ошибку PostgreSQL в ошибку клиента; nil - ошибка сервера
+func DatabaseProblem(err error) *Problem {
+	if errors.Is(err, sql.ErrNoRows) {
+		return NewProblem(http.StatusNotFound, CodeNotFound, MsgRecordNotFound)
+	}
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return nil
+	}
+	switch pqErr.Code {
+	case "23505": // unique_violation
+		return NewProblem(http.StatusConflict, CodeConflict, MsgDBConflict)
+	case "22P02": // invalid_text_representation: например, некорректный UUID
+		return NewProblem(http.StatusBadRequest, CodeValidation, MsgDBInvalidValue)
+	case "22007", "22008": // некорректная дата
+		return NewProblem(http.StatusBadRequest, CodeValidation, MsgDBInvalidDate)
+	case "23514": // check_violation
+		return NewProblem(http.StatusBadRequest, CodeValidation, MsgDBCheckFailed)
+	}
+	return nil
+}
@@ -0,0 +1,10 @@
+package utils
+
+import "regexp"
+
+var uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// IsValidUUID проверяет строку в каноническом формате UUID (8-4-4-4-12)
+func IsValidUUID(s string) bool {
+	return uuidPattern.MatchString(s)
+}
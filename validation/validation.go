@@ -0,0 +1,256 @@
+package validation
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+	"usersubs/models"
+	"usersubs/utils"
+)
+
+// Ограничения на значения полей подписки
+const (
+	MinServiceNameLength = 1
+	MaxServiceNameLength = 100
+	// Наибольшая цена за период списания: 10 000 000.00
+	MaxPrice models.Money = 10_000_000_00
+	// Дата начала не раньше MinStartDate и не позже, чем через MaxYearsAhead лет от сегодняшнего дня
+	MaxYearsAhead = 10
+	// Подписка длится не больше MaxDurationYears лет
+	MaxDurationYears = 100
+)
+
+// MinStartDate - самая ранняя допустимая дата начала подписки
+var MinStartDate = time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// Знаки, допустимые в названии сервиса помимо букв, цифр и пробелов
+const serviceNamePunctuation = `.,:;!?&+'"()/#_-`
+
+var serviceNamePattern = regexp.MustCompile(`^[\p{L}\p{N}][\p{L}\p{N}\p{Zs}` + regexp.QuoteMeta(serviceNamePunctuation) + `]*$`)
+
+// ErrInvalid - данные не прошли проверку; подробности - в Errors
+var ErrInvalid = errors.New("данные не прошли проверку")
+
+// Errors - все нарушения правил, каждое с путём поля
+type Errors []utils.FieldError
+
+func (e Errors) Error() string {
+	parts := make([]string, len(e))
+	for i, fe := range e {
+		parts[i] = fe.Field + ": " + fe.Text(utils.LangRU)
+	}
+	return ErrInvalid.Error() + ": " + strings.Join(parts, "; ")
+}
+
+func (e Errors) Is(target error) bool {
+	return target == ErrInvalid
+}
+
+// Add добавляет нарушение поля field с сообщением из каталога (key, args)
+func (e *Errors) Add(field, key string, args ...any) {
+	*e = append(*e, utils.NewFieldError(field, key, args...))
+}
+
+// Err возвращает nil, если нарушений нет
+func (e Errors) Err() error {
+	if len(e) == 0 {
+		return nil
+	}
+	return e
+}
+
+// WithPrefix добавляет путь prefix к полям нарушений, например items[3].service_name
+func (e Errors) WithPrefix(prefix string) Errors {
+	prefixed := make(Errors, len(e))
+	for i, fe := range e {
+		prefixed[i] = fe
+		prefixed[i].Field = prefix + "." + fe.Field
+	}
+	return prefixed
+}
+
+// Subscription проверяет данные новой подписки (или полной замены) и возвращает подписку
+// со значениями по умолчанию: currency RUB, billing_period monthly. Ошибка - Errors со всеми нарушениями.
+func Subscription(input models.SubscriptionInput) (models.Subscription, error) {
+	var errs Errors
+	sub := models.Subscription{
+		ServiceName:   strings.TrimSpace(input.ServiceName),
+		Price:         input.Price,
+		Currency:      strings.ToUpper(input.Currency),
+		BillingPeriod: input.BillingPeriod,
+		UserID:        input.UserID,
+	}
+	if sub.Currency == "" {
+		sub.Currency = models.BaseCurrency
+	}
+	if sub.BillingPeriod == "" {
+		sub.BillingPeriod = models.BillingMonthly
+	}
+
+	if input.ServiceName == "" {
+		errs.Add("service_name", utils.MsgRequiredField)
+	} else {
+		checkServiceName(&errs, sub.ServiceName)
+	}
+	if input.UserID == "" {
+		errs.Add("user_id", utils.MsgRequiredField)
+	} else {
+		checkUserID(&errs, sub.UserID)
+	}
+	checkPrice(&errs, sub.Price)
+	checkCurrency(&errs, sub.Currency)
+	checkBillingPeriod(&errs, sub.BillingPeriod)
+
+	startOK := false
+	if input.StartDate == "" {
+		errs.Add("start_date", utils.MsgRequiredField)
+	} else if t, err := utils.ParseDate(input.StartDate); err != nil {
+		errs.Add("start_date", utils.MsgInvalidDate, "start_date")
+	} else {
+		sub.StartDate = t
+		startOK = checkStartDate(&errs, t)
+	}
+	if input.EndDate != nil && *input.EndDate != "" {
+		t, err := utils.ParseEndDate(*input.EndDate)
+		if err != nil {
+			errs.Add("end_date", utils.MsgInvalidDate, "end_date")
+		} else {
+			sub.EndDate = &t
+			if startOK {
+				checkEndDate(&errs, sub.StartDate, t)
+			}
+		}
+	}
+	return sub, errs.Err()
+}
+
+// Update проверяет изменяемые поля подписки. clearEndDate - снять дату окончания (end_date: null).
+// Согласованность дат с неизменёнными полями проверяет Check после применения изменения.
+func Update(input models.UpdateSubscriptionInput, clearEndDate bool) (models.UpdateSubscription, error) {
+	var errs Errors
+	var update models.UpdateSubscription
+
+	if input.ServiceName != nil {
+		name := strings.TrimSpace(*input.ServiceName)
+		checkServiceName(&errs, name)
+		update.ServiceName = &name
+	}
+	if input.UserID != nil {
+		checkUserID(&errs, *input.UserID)
+		update.UserID = input.UserID
+	}
+	if input.Price != nil {
+		checkPrice(&errs, *input.Price)
+		update.Price = input.Price
+	}
+	if input.Currency != nil {
+		currency := strings.ToUpper(*input.Currency)
+		checkCurrency(&errs, currency)
+		update.Currency = &currency
+	}
+	if input.BillingPeriod != nil {
+		checkBillingPeriod(&errs, *input.BillingPeriod)
+		update.BillingPeriod = input.BillingPeriod
+	}
+	if input.StartDate != nil {
+		if t, err := utils.ParseDate(*input.StartDate); err != nil {
+			errs.Add("start_date", utils.MsgInvalidDate, "start_date")
+		} else {
+			checkStartDate(&errs, t)
+			update.StartDate = &t
+		}
+	}
+	if clearEndDate {
+		var none *time.Time
+		update.EndDate = &none
+	} else if input.EndDate != nil {
+		if t, err := utils.ParseEndDate(*input.EndDate); err != nil {
+			errs.Add("end_date", utils.MsgInvalidDate, "end_date")
+		} else {
+			end := &t
+			update.EndDate = &end
+		}
+	}
+	return update, errs.Err()
+}
+
+// PriceChange проверяет изменение цены по тем же правилам, что и цену подписки, и возвращает месяц начала действия
+func PriceChange(input models.PriceChangeInput) (time.Time, error) {
+	var errs Errors
+	checkPrice(&errs, input.Price)
+	month, err := utils.ParseMonthYear(input.EffectiveMonth)
+	if err != nil {
+		errs.Add("effective_month", utils.MsgInvalidMonth, "effective_month")
+	}
+	return month, errs.Err()
+}
+
+// Check проверяет согласованность дат подписки после применения частичного изменения.
+// Остальные поля проверяются в Update только при их изменении, чтобы старые записи оставались изменяемыми.
+func Check(sub models.Subscription) error {
+	var errs Errors
+	if sub.EndDate != nil {
+		checkEndDate(&errs, sub.StartDate, *sub.EndDate)
+	}
+	return errs.Err()
+}
+
+func checkServiceName(errs *Errors, name string) {
+	n := utf8.RuneCountInString(name)
+	switch {
+	case n < MinServiceNameLength || n > MaxServiceNameLength:
+		errs.Add("service_name", utils.MsgNameLength, MinServiceNameLength, MaxServiceNameLength)
+	case !serviceNamePattern.MatchString(name):
+		errs.Add("service_name", utils.MsgNameCharset, serviceNamePunctuation)
+	}
+}
+
+func checkUserID(errs *Errors, userID string) {
+	if !utils.IsValidUUID(userID) {
+		errs.Add("user_id", utils.MsgExpectedUUID)
+	}
+}
+
+func checkPrice(errs *Errors, price models.Money) {
+	switch {
+	case price < 0:
+		errs.Add("price", utils.MsgNegativePrice)
+	case price > MaxPrice:
+		errs.Add("price", utils.MsgPriceTooHigh, MaxPrice.String())
+	}
+}
+
+func checkCurrency(errs *Errors, currency string) {
+	if !models.IsValidCurrency(currency) {
+		errs.Add("currency", utils.MsgInvalidCurrency, "currency")
+	}
+}
+
+func checkBillingPeriod(errs *Errors, period string) {
+	if !models.IsValidBillingPeriod(period) {
+		errs.Add("billing_period", utils.MsgAllowedValues, "billing_period", "weekly, monthly, quarterly, yearly")
+	}
+}
+
+// checkStartDate проверяет, что дата начала правдоподобна; false - дата вне диапазона
+func checkStartDate(errs *Errors, start time.Time) bool {
+	now := time.Now().UTC()
+	latest := time.Date(now.Year()+MaxYearsAhead, 12, 31, 0, 0, 0, 0, time.UTC)
+	if start.Before(MinStartDate) || start.After(latest) {
+		errs.Add("start_date", utils.MsgDateOutOfRange, MinStartDate.Format("2006-01-02"), latest.Format("2006-01-02"))
+		return false
+	}
+	return true
+}
+
+func checkEndDate(errs *Errors, start, end time.Time) {
+	latest := start.AddDate(MaxDurationYears, 0, 0)
+	switch {
+	case end.Before(start):
+		errs.Add("end_date", utils.MsgEndBeforeStart)
+	case end.After(latest):
+		errs.Add("end_date", utils.MsgDateOutOfRange, start.Format("2006-01-02"), latest.Format("2006-01-02"))
+	}
+}